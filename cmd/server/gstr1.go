@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"invo-server/internal/config"
+	database "invo-server/internal/db"
+	"invo-server/internal/services"
+)
+
+// runGSTR1 implements `server gstr1 -company 1 -period 2025-03 [-out file]`.
+// The return JSON goes to -out (or stdout); validation issues go to stderr
+// and make the command exit 2 so scripts notice them.
+func runGSTR1(args []string) {
+	fs := flag.NewFlagSet("gstr1", flag.ExitOnError)
+	companyID := fs.Int64("company", 0, "company id")
+	period := fs.String("period", "", "return period, YYYY-MM")
+	out := fs.String("out", "", "write the GSTR-1 JSON to this file instead of stdout")
+	fs.Parse(args)
+
+	if *companyID == 0 || *period == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+
+	db, err := database.NewDatabase(cfg.GetDSN())
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.DB.Close()
+
+	export, err := services.NewReportService(db.DB).GSTR1(*companyID, *period)
+	if err != nil {
+		log.Fatal("Failed to build GSTR-1:", err)
+	}
+
+	data, err := json.MarshalIndent(export.Return, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(append(data, '\n'))
+	} else if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, is := range export.Issues {
+		fmt.Fprintf(os.Stderr, "%s %s: %s: %s\n", is.DocumentType, is.DocumentNumber, is.Field, is.Message)
+	}
+	if len(export.Issues) > 0 {
+		db.DB.Close()
+		os.Exit(2)
+	}
+}
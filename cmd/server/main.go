@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"invo-server/internal/config"
 	database "invo-server/internal/db"
 	"invo-server/internal/routes"
+	"invo-server/internal/services"
 	"log"
 	"net/http"
 	"os"
@@ -13,6 +15,11 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gstr1" {
+		runGSTR1(os.Args[2:])
+		return
+	}
+
 	cfg := config.Load()
 
 	db, err := database.NewDatabase(cfg.GetDSN())
@@ -55,8 +62,30 @@ func main() {
 	r.GET("/api/v1/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
+	// Shared services (routes + background jobs)
+	ledgerService := services.NewLedgerService(db.DB)
+	stockService := services.NewStockService(db.DB, cfg.Inventory.NegativeStockPolicy)
+	invoiceService := services.NewInvoiceService(db.DB, ledgerService, stockService)
+	emailService := services.NewEmailService(
+		cfg.Email.ResendAPIKey,
+		cfg.Email.FromEmail,
+		cfg.Email.FromName,
+	)
+	recurringInvoiceService := services.NewRecurringInvoiceService(db.DB, invoiceService, emailService)
+	notificationService := services.NewNotificationService(db.DB, emailService)
+
 	// ✅ Register all routes (moved out)
-	routes.RegisterRoutes(r, db, cfg)
+	routes.RegisterRoutes(r, db, cfg, ledgerService, invoiceService, stockService, notificationService, emailService, recurringInvoiceService)
+
+	// 🔁 Background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go recurringInvoiceService.Start(ctx, cfg.Scheduler.RecurringInvoiceInterval)
+
+	if cfg.Inventory.LowStockDigest {
+		go notificationService.StartLowStockDigest(ctx, cfg.Inventory.LowStockDigestEvery)
+	}
 
 	// Start server
 	port := cfg.Server.Port
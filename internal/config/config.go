@@ -39,6 +39,20 @@ type Config struct {
 		FromEmail    string
 		FromName     string
 	}
+
+	Scheduler struct {
+		RecurringInvoiceInterval time.Duration
+	}
+
+	EInvoice struct {
+		SigningKey string
+	}
+
+	Inventory struct {
+		NegativeStockPolicy string // allow | block
+		LowStockDigest      bool   // email new low-stock alerts once a day
+		LowStockDigestEvery time.Duration
+	}
 }
 
 func Load() *Config {
@@ -71,6 +85,16 @@ func Load() *Config {
 	config.Email.FromEmail = getEnv("EMAIL_FROM", "")
 	config.Email.FromName = getEnv("EMAIL_FROM_NAME", "Invoice App")
 
+	config.Scheduler.RecurringInvoiceInterval = getEnvAsDuration("RECURRING_INVOICE_INTERVAL", time.Hour)
+
+	// Signs QR codes from the local IRP stub; defaults to the JWT secret.
+	config.EInvoice.SigningKey = getEnv("EINVOICE_SIGNING_KEY", config.JWT.Secret)
+
+	// "block" refuses to issue invoices that would take stock below zero.
+	config.Inventory.NegativeStockPolicy = getEnv("NEGATIVE_STOCK_POLICY", "allow")
+	config.Inventory.LowStockDigest = getEnv("LOW_STOCK_DIGEST", "false") == "true"
+	config.Inventory.LowStockDigestEvery = getEnvAsDuration("LOW_STOCK_DIGEST_INTERVAL", 24*time.Hour)
+
 	return config
 }
 
@@ -373,6 +373,8 @@ func (h *AuthHandler) DeleteAccount(c *gin.Context) {
 		{"otp_codes", `DELETE FROM otp_codes WHERE email = (SELECT email FROM users WHERE id = $1)`},
 		{"invoice_items", `DELETE FROM invoice_items WHERE invoice_id IN (SELECT id FROM invoices WHERE company_id IN (SELECT id FROM companies WHERE user_id = $1))`},
 		{"invoice_addresses", `DELETE FROM invoice_addresses WHERE invoice_id IN (SELECT id FROM invoices WHERE company_id IN (SELECT id FROM companies WHERE user_id = $1))`},
+		{"refunds", `DELETE FROM refunds WHERE company_id IN (SELECT id FROM companies WHERE user_id = $1)`},
+		{"debit_notes", `DELETE FROM debit_notes WHERE company_id IN (SELECT id FROM companies WHERE user_id = $1)`},
 		{"payments", `DELETE FROM payments WHERE company_id IN (SELECT id FROM companies WHERE user_id = $1)`},
 		{"ledger", `DELETE FROM ledger_entries WHERE company_id IN (SELECT id FROM companies WHERE user_id = $1)`},
 		{"credit_notes", `DELETE FROM credit_notes WHERE company_id IN (SELECT id FROM companies WHERE user_id = $1)`},
@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"invo-server/internal/models"
+	"invo-server/internal/pdf"
 	"invo-server/internal/services"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -45,19 +48,23 @@ func (h *CreditNoteHandler) Create(c *gin.Context) {
 		}
 	}()
 
-	var companyID int64
-
+	// 🔐 The note is created under the company named in the request
+	var owned bool
 	err = tx.QueryRow(`
-		SELECT id FROM companies WHERE user_id = $1
-	`, userID).Scan(&companyID)
+		SELECT EXISTS (SELECT 1 FROM companies WHERE id = $1 AND user_id = $2)
+	`, req.CompanyID, userID).Scan(&owned)
 
-	if err != nil {
+	if err != nil || !owned {
 		c.JSON(403, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	if err := h.service.CreateTx(tx, companyID, req); err != nil {
+	if err := h.service.CreateTx(tx, req.CompanyID, req); err != nil {
 		fmt.Println("Error creating credit note:", err)
+		if errors.Is(err, services.ErrInvoiceNotFound) || errors.Is(err, services.ErrClientNotInCompany) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
@@ -75,15 +82,11 @@ func (h *CreditNoteHandler) Create(c *gin.Context) {
 	c.JSON(201, gin.H{"message": "Credit note created"})
 }
 
+// GET /api/v1/credit-notes?company_id=&client_id=&limit=&offset=
 func (h *CreditNoteHandler) GetAll(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	// ✅ Must read from query param
 	companyIDStr := c.Query("company_id")
-
-	// Add this debug
-	log.Printf("🏢 GetAll called with company_id: %s, user_id: %d", companyIDStr, userID)
-
 	if companyIDStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id is required"})
 		return
@@ -97,24 +100,52 @@ func (h *CreditNoteHandler) GetAll(c *gin.Context) {
 
 	// Verify ownership
 	var exists bool
-	h.db.QueryRow(`
-        SELECT EXISTS(
-            SELECT 1 FROM companies WHERE id = $1 AND user_id = $2
-        )
-    `, companyID, userID).Scan(&exists)
+	err = h.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM companies WHERE id = $1 AND user_id = $2
+		)
+	`, companyID, userID).Scan(&exists)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	if !exists {
 		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	result, err := h.service.GetAll(companyID)
+	var clientID int64
+	if v := c.Query("client_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id"})
+			return
+		}
+		clientID = id
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	result, err := h.service.GetAll(userID, companyID, clientID, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	c.JSON(http.StatusOK, gin.H{
+		"data":   result,
+		"limit":  limit,
+		"offset": offset,
+	})
 }
 
 func (h *CreditNoteHandler) GetByID(c *gin.Context) {
@@ -133,11 +164,25 @@ func (h *CreditNoteHandler) GetByID(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
+	// 🔐 Resolve the note's company through ownership; an explicit
+	// company_id must match it
 	var companyID int64
-	err = tx.QueryRow(`SELECT id FROM companies WHERE user_id = $1`, userID).
-		Scan(&companyID)
+	err = tx.QueryRow(`
+		SELECT cn.company_id
+		FROM credit_notes cn
+		JOIN companies c ON c.id = cn.company_id
+		WHERE cn.id = $1 AND c.user_id = $2
+	`, cnID, userID).Scan(&companyID)
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "credit note not found"})
+		return
+	}
 	if err != nil {
-		c.JSON(403, gin.H{"error": "unauthorized"})
+		c.JSON(500, gin.H{"error": "failed to fetch credit note"})
+		return
+	}
+	if v := c.Query("company_id"); v != "" && v != strconv.FormatInt(companyID, 10) {
+		c.JSON(404, gin.H{"error": "credit note not found"})
 		return
 	}
 
@@ -155,3 +200,123 @@ func (h *CreditNoteHandler) GetByID(c *gin.Context) {
 	// ✅ EXACT SHAPE REQUIRED BY iOS
 	c.JSON(http.StatusOK, result)
 }
+
+// POST /api/v1/credit-notes/:id/apply
+func (h *CreditNoteHandler) Apply(c *gin.Context) {
+	cnID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit note id"})
+		return
+	}
+
+	var req models.CreditNoteApplyRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := h.service.ApplyTx(tx, c.GetInt("user_id"), cnID, req)
+	if errors.Is(err, services.ErrCreditNoteNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error applying credit note:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusOK, result)
+}
+
+// userOwnsCreditNote reports whether the credit note belongs to one of the
+// user's companies.
+func userOwnsCreditNote(db *sql.DB, userID int, cnID int64) bool {
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM credit_notes cn
+			JOIN companies c ON c.id = cn.company_id
+			WHERE cn.id = $1 AND c.user_id = $2
+		)
+	`, cnID, userID).Scan(&exists)
+	return err == nil && exists
+}
+
+// GET /api/v1/credit-notes/:id/pdf
+func (h *CreditNoteHandler) GetPDF(c *gin.Context) {
+	cnID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit note id"})
+		return
+	}
+
+	// 🔐 Authorization
+	if !userOwnsCreditNote(h.db, c.GetInt("user_id"), cnID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	data, reason, err := services.FetchCreditNotePDFData(h.db, cnID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch credit note data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch credit note data"})
+		return
+	}
+
+	pdfBytes, err := pdf.GenerateCreditNotePDF(data, reason)
+	if err != nil {
+		log.Printf("❌ PDF generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	fileName := fmt.Sprintf("CreditNote_%s.pdf", data.Invoice.InvoiceNumber)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// GET /api/v1/invoices/:id/returnable-items
+func (h *CreditNoteHandler) GetReturnableItems(c *gin.Context) {
+	invoiceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invoice id"})
+		return
+	}
+
+	items, err := h.service.ReturnableItems(c.GetInt("user_id"), invoiceID)
+	if errors.Is(err, services.ErrInvoiceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching returnable items:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch returnable items"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invoice_id": invoiceID, "items": items})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/pdf"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DebitNoteHandler struct {
+	service *services.DebitNoteService
+	db      *sql.DB
+}
+
+func NewDebitNoteHandler(service *services.DebitNoteService, db *sql.DB) *DebitNoteHandler {
+	return &DebitNoteHandler{service: service, db: db}
+}
+
+// POST /api/v1/debit-notes
+func (h *DebitNoteHandler) Create(c *gin.Context) {
+	var req models.DebitNoteRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	dn, err := h.service.CreateTx(tx, c.GetInt("user_id"), req)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error creating debit note:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, dn)
+}
+
+// GET /api/v1/companies/:companyId/debit-notes?limit=&offset=
+func (h *DebitNoteHandler) List(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	notes, err := h.service.List(companyID, limit, offset)
+	if err != nil {
+		fmt.Println("Error fetching debit notes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch debit notes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   notes,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GET /api/v1/debit-notes/:id
+func (h *DebitNoteHandler) GetByID(c *gin.Context) {
+	dnID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debit note id"})
+		return
+	}
+
+	dn, err := h.service.GetByID(c.GetInt("user_id"), dnID)
+	if errors.Is(err, services.ErrDebitNoteNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching debit note:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch debit note"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dn)
+}
+
+// GET /api/v1/debit-notes/:id/pdf
+func (h *DebitNoteHandler) GetPDF(c *gin.Context) {
+	dnID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid debit note id"})
+		return
+	}
+
+	// 🔐 Authorization
+	if _, err := h.service.GetByID(c.GetInt("user_id"), dnID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	data, reason, err := services.FetchDebitNotePDFData(h.db, dnID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch debit note data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch debit note data"})
+		return
+	}
+
+	pdfBytes, err := pdf.GenerateDebitNotePDF(data, reason)
+	if err != nil {
+		log.Printf("❌ PDF generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	fileName := fmt.Sprintf("DebitNote_%s.pdf", data.Invoice.InvoiceNumber)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
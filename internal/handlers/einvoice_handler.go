@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EInvoiceHandler struct {
+	service *services.EInvoiceService
+	db      *sql.DB
+}
+
+func NewEInvoiceHandler(service *services.EInvoiceService, db *sql.DB) *EInvoiceHandler {
+	return &EInvoiceHandler{service: service, db: db}
+}
+
+// writeEInvoiceError maps service errors shared by the e-invoice endpoints.
+func writeEInvoiceError(c *gin.Context, err error) {
+	var verr *services.EInvoiceValidationError
+	switch {
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+	case errors.As(err, &verr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "invoice is not ready for e-invoicing",
+			"problems": verr.Problems,
+		})
+	case errors.Is(err, services.ErrIRNCancelReason):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIRNAlreadyGenerated),
+		errors.Is(err, services.ErrIRNAlreadyCancelled),
+		errors.Is(err, services.ErrIRNNotGenerated),
+		errors.Is(err, services.ErrIRNCancelWindow):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrIRPRequest):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		fmt.Println("E-invoice error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "e-invoice request failed"})
+	}
+}
+
+// GET /api/v1/invoices/:id/einvoice/payload
+func (h *EInvoiceHandler) Payload(c *gin.Context) {
+	invoiceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice id"})
+		return
+	}
+
+	payload, err := h.service.BuildPayload(c.GetInt("user_id"), invoiceID)
+	if err != nil {
+		writeEInvoiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payload)
+}
+
+// POST /api/v1/invoices/:id/einvoice
+func (h *EInvoiceHandler) Generate(c *gin.Context) {
+	invoiceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice id"})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := h.service.GenerateTx(tx, c.GetInt("user_id"), invoiceID)
+	if err != nil {
+		writeEInvoiceError(c, err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "IRN generated",
+		"invoice_id":     invoiceID,
+		"irn":            result.IRN,
+		"ack_no":         result.AckNo,
+		"ack_date":       result.AckDate,
+		"signed_qr_code": result.SignedQRCode,
+	})
+}
+
+// POST /api/v1/invoices/:id/einvoice/cancel
+func (h *EInvoiceHandler) Cancel(c *gin.Context) {
+	invoiceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice id"})
+		return
+	}
+
+	var req models.CancelIRNRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = h.service.CancelTx(tx, c.GetInt("user_id"), invoiceID, req)
+	if err != nil {
+		writeEInvoiceError(c, err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "IRN cancelled",
+		"invoice_id": invoiceID,
+	})
+}
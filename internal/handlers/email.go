@@ -67,3 +67,51 @@ func (h *EmailHandler) SendInvoiceEmail(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Invoice sent successfully to " + req.ToEmail})
 }
+
+func (h *EmailHandler) SendCreditNoteEmail(c *gin.Context) {
+	cnID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit note ID"})
+		return
+	}
+
+	var req struct {
+		ToEmail string `json:"to_email" binding:"required"`
+		ToName  string `json:"to_name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !userOwnsCreditNote(h.db, c.GetInt("user_id"), cnID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	data, reason, err := services.FetchCreditNotePDFData(h.db, cnID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch credit note: %v", err)})
+		return
+	}
+
+	pdfBytes, err := pdf.GenerateCreditNotePDF(data, reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	err = h.emailService.SendCreditNoteEmail(
+		req.ToEmail,
+		req.ToName,
+		data.Invoice.InvoiceNumber,
+		pdfBytes,
+	)
+	if err != nil {
+		log.Println("EMAIL ERROR:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to send email: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Credit note sent successfully to " + req.ToEmail})
+}
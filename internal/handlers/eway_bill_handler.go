@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EWayBillHandler struct {
+	service *services.EWayBillService
+	db      *sql.DB
+}
+
+func NewEWayBillHandler(service *services.EWayBillService, db *sql.DB) *EWayBillHandler {
+	return &EWayBillHandler{service: service, db: db}
+}
+
+// POST /api/v1/invoices/:id/eway-bill
+func (h *EWayBillHandler) Generate(c *gin.Context) {
+	invoiceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice id"})
+		return
+	}
+
+	var req models.EWayBillRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	bill, err := h.service.GenerateTx(tx, c.GetInt("user_id"), invoiceID, req)
+
+	var verr *services.EWayBillValidationError
+	switch {
+	case err == nil:
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		return
+	case errors.As(err, &verr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "invoice is not ready for an e-way bill",
+			"problems": verr.Problems,
+		})
+		return
+	case errors.Is(err, services.ErrEWayBillExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrEWBRequest):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error generating e-way bill:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate e-way bill"})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, bill)
+}
+
+// GET /api/v1/invoices/:id/eway-bill
+func (h *EWayBillHandler) Get(c *gin.Context) {
+	invoiceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice id"})
+		return
+	}
+
+	bill, err := h.service.Get(c.GetInt("user_id"), invoiceID)
+	if errors.Is(err, services.ErrEWayBillNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching e-way bill:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch e-way bill"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bill)
+}
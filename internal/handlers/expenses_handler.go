@@ -4,7 +4,9 @@ import (
 	"fmt"
 	database "invo-server/internal/db"
 	"invo-server/internal/models"
+	utils "invo-server/internal/util"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,6 +47,11 @@ func (h *expenseHandler) CreateExpense(c *gin.Context) {
 		return
 	}
 
+	if msg := validateExpenseTax(request); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
 	// Ensure company belongs to this user
 	var exists bool
 	h.db.DB.QueryRow(`
@@ -61,10 +68,17 @@ func (h *expenseHandler) CreateExpense(c *gin.Context) {
 	// Insert the expense
 	var expenseID int
 	err := h.db.DB.QueryRow(`
-        INSERT INTO expensess (name, amount, description, date, company_id, user_id) 
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO expensess (
+            name, amount, description, date, company_id, user_id,
+            vendor_gstin, taxable_value, cgst, sgst, igst, itc_eligible
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10, $11, $12)
         RETURNING id
-    `, request.Name, request.Amount, request.Description, request.Date, request.CompanyID, userID).Scan(&expenseID)
+    `,
+		request.Name, request.Amount, request.Description, request.Date, request.CompanyID, userID,
+		strings.ToUpper(request.VendorGSTIN), request.TaxableValue,
+		request.CGST, request.SGST, request.IGST, request.ITCEligible,
+	).Scan(&expenseID)
 
 	if err != nil {
 		fmt.Println("SQL ERROR:", err)
@@ -78,6 +92,24 @@ func (h *expenseHandler) CreateExpense(c *gin.Context) {
 	})
 }
 
+// validateExpenseTax checks the optional GST fields of an expense and
+// returns a user-facing message, or "" when they are fine.
+func validateExpenseTax(e models.Expensess) string {
+	if e.CGST < 0 || e.SGST < 0 || e.IGST < 0 || e.TaxableValue < 0 {
+		return "Tax amounts must be >= 0"
+	}
+	if e.IGST > 0 && (e.CGST > 0 || e.SGST > 0) {
+		return "An expense is either IGST or CGST+SGST, not both"
+	}
+	if e.VendorGSTIN != "" && !utils.ValidGSTIN(e.VendorGSTIN) {
+		return "Invalid vendor GSTIN"
+	}
+	if e.ITCEligible && e.VendorGSTIN == "" {
+		return "Vendor GSTIN is required to claim input tax credit"
+	}
+	return ""
+}
+
 // GetExpenses retrieves all expenses for a company
 // GET /api/v1/companies/:id/expenses
 func (h *expenseHandler) GetExpenses(c *gin.Context) {
@@ -100,7 +132,8 @@ func (h *expenseHandler) GetExpenses(c *gin.Context) {
 
 	// Fetch expenses
 	rows, err := h.db.DB.Query(`
-        SELECT id, name, amount, description, date, created_at, updated_at
+        SELECT id, name, amount, description, date, created_at, updated_at,
+            COALESCE(vendor_gstin, ''), taxable_value, cgst, sgst, igst, itc_eligible
         FROM expensess
         WHERE company_id=$1
         ORDER BY date DESC
@@ -125,6 +158,12 @@ func (h *expenseHandler) GetExpenses(c *gin.Context) {
 			&exp.Date,
 			&exp.CreatedAt,
 			&exp.UpdatedAt,
+			&exp.VendorGSTIN,
+			&exp.TaxableValue,
+			&exp.CGST,
+			&exp.SGST,
+			&exp.IGST,
+			&exp.ITCEligible,
 		)
 		if err != nil {
 			fmt.Println("Scan ERROR:", err)
@@ -151,7 +190,8 @@ func (h *expenseHandler) GetExpenseByID(c *gin.Context) {
 
 	// Fetch expense and verify ownership
 	err := h.db.DB.QueryRow(`
-        SELECT id, name, amount, description, date, company_id, created_at, updated_at
+        SELECT id, name, amount, description, date, company_id, created_at, updated_at,
+            COALESCE(vendor_gstin, ''), taxable_value, cgst, sgst, igst, itc_eligible
         FROM expensess
         WHERE id=$1
     `, expenseID).Scan(
@@ -163,6 +203,12 @@ func (h *expenseHandler) GetExpenseByID(c *gin.Context) {
 		&companyID,
 		&exp.CreatedAt,
 		&exp.UpdatedAt,
+		&exp.VendorGSTIN,
+		&exp.TaxableValue,
+		&exp.CGST,
+		&exp.SGST,
+		&exp.IGST,
+		&exp.ITCEligible,
 	)
 
 	if err != nil {
@@ -231,6 +277,11 @@ func (h *expenseHandler) UpdateExpense(c *gin.Context) {
 		return
 	}
 
+	if msg := validateExpenseTax(request); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
 	// Update safely
 	_, err = h.db.DB.Exec(`
 		UPDATE expensess
@@ -239,6 +290,12 @@ func (h *expenseHandler) UpdateExpense(c *gin.Context) {
 			amount = COALESCE($2, amount),
 			description = COALESCE($3, description),
 			date = COALESCE($4, date),
+			vendor_gstin = NULLIF($6, ''),
+			taxable_value = $7,
+			cgst = $8,
+			sgst = $9,
+			igst = $10,
+			itc_eligible = $11,
 			updated_at = NOW()
 		WHERE id = $5
 	`,
@@ -247,6 +304,12 @@ func (h *expenseHandler) UpdateExpense(c *gin.Context) {
 		request.Description,
 		request.Date,
 		expenseID,
+		strings.ToUpper(request.VendorGSTIN),
+		request.TaxableValue,
+		request.CGST,
+		request.SGST,
+		request.IGST,
+		request.ITCEligible,
 	)
 
 	if err != nil {
@@ -330,7 +393,8 @@ func (h *expenseHandler) GetExpensesByDateRange(c *gin.Context) {
 
 	// Fetch expenses in date range
 	rows, err := h.db.DB.Query(`
-        SELECT id, name, amount, description, date, created_at, updated_at
+        SELECT id, name, amount, description, date, created_at, updated_at,
+            COALESCE(vendor_gstin, ''), taxable_value, cgst, sgst, igst, itc_eligible
         FROM expensess
         WHERE company_id=$1 AND date BETWEEN $2 AND $3
         ORDER BY date DESC
@@ -355,6 +419,12 @@ func (h *expenseHandler) GetExpensesByDateRange(c *gin.Context) {
 			&exp.Date,
 			&exp.CreatedAt,
 			&exp.UpdatedAt,
+			&exp.VendorGSTIN,
+			&exp.TaxableValue,
+			&exp.CGST,
+			&exp.SGST,
+			&exp.IGST,
+			&exp.ITCEligible,
 		)
 		if err != nil {
 			fmt.Println("Scan ERROR:", err)
@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	database "invo-server/internal/db"
 	"invo-server/internal/models"
+	"invo-server/internal/money"
 	"invo-server/internal/pdf"
 	"invo-server/internal/services"
 	utils "invo-server/internal/util"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -17,95 +20,29 @@ import (
 )
 
 type InvoiceHandler struct {
-	db            *database.Database
-	LedgerService *services.LedgerService
+	db               *database.Database
+	LedgerService    *services.LedgerService
+	InvoiceService   *services.InvoiceService
+	NumberingService *services.NumberingService
 }
 
-func NewInvoiceHandler(db *database.Database) *InvoiceHandler {
-	return &InvoiceHandler{db: db}
-}
-
-func insertInvoiceAddress(
-	tx *sql.Tx,
-	invoiceID int,
-	addressType string,
-	addr models.Address,
-) error {
-
-	_, err := tx.Exec(`
-		INSERT INTO invoice_addresses (
-			invoice_id, type,
-			name, line1, line2, city, state,
-			postal_code, country, phone, gst_number
-		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
-	`,
-		invoiceID,
-		addressType,
-		addr.Name,
-		addr.Line1,
-		addr.Line2,
-		addr.City,
-		addr.State,
-		addr.PostalCode,
-		addr.Country,
-		addr.Phone,
-		addr.GSTNumber,
-	)
-
-	return err
-}
-
-func fetchClientAddress(
-	tx *sql.Tx,
-	clientID int,
-	addressType string, // billing | shipping
-) (*models.Address, error) {
-
-	var addr models.Address
-
-	err := tx.QueryRow(`
-		SELECT
-			type,
-			name,
-			line1,
-			line2,
-			city,
-			state,
-			postal_code,
-			country,
-			phone,
-			email,
-			gst_number
-		FROM client_addresses
-		WHERE client_id = $1
-		  AND type = $2
-	`, clientID, addressType).Scan(
-		&addr.AddressType,
-		&addr.Name,
-		&addr.Line1,
-		&addr.Line2,
-		&addr.City,
-		&addr.State,
-		&addr.PostalCode,
-		&addr.Country,
-		&addr.Phone,
-		&addr.Email,
-		&addr.GSTNumber,
-	)
-
-	if err != nil {
-		return nil, err
+func NewInvoiceHandler(
+	db *database.Database,
+	ledger *services.LedgerService,
+	invoiceService *services.InvoiceService,
+	numberingService *services.NumberingService,
+) *InvoiceHandler {
+	return &InvoiceHandler{
+		db:               db,
+		LedgerService:    ledger,
+		InvoiceService:   invoiceService,
+		NumberingService: numberingService,
 	}
-
-	return &addr, nil
 }
 
 // POST /api/v1/invoices
 func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 	var req models.InvoiceRequestDTO
-	var subtotal float64
-	var taxTotal float64
 
 	userID := c.GetInt("user_id")
 
@@ -171,28 +108,13 @@ func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 		}
 	}
 
-	for _, item := range req.Items {
-		lineBase := item.Rate * float64(item.Qty)
-		lineAfterDiscount := lineBase - item.Discount
-		lineTax := lineAfterDiscount * (item.TaxRate / 100)
-
-		subtotal += lineAfterDiscount
-		taxTotal += lineTax
-
-		// insert invoice_items with lineTotal
-	}
-
-	grandTotal := subtotal + taxTotal
-
 	// 4️⃣ Parse dates
-	invDate, err := time.Parse("2006-01-02", req.InvoiceDate)
-	if err != nil {
+	if _, err := time.Parse("2006-01-02", req.InvoiceDate); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice_date (YYYY-MM-DD)"})
 		return
 	}
 
-	dueDate, err := time.Parse("2006-01-02", req.DueDate)
-	if err != nil {
+	if _, err := time.Parse("2006-01-02", req.DueDate); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date (YYYY-MM-DD)"})
 		return
 	}
@@ -211,143 +133,24 @@ func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 		}
 	}()
 
-	// 6️⃣ Generate invoice number (FY based)
-	fy := utils.FinancialYear(invDate)
-
-	var nextNumber int
-	err = tx.QueryRow(`
-		INSERT INTO invoice_counters (company_id, financial_year)
-		VALUES ($1, $2)
-		ON CONFLICT (company_id, financial_year)
-		DO UPDATE SET next_number = invoice_counters.next_number + 1
-		RETURNING next_number
-	`, req.CompanyID, fy).Scan(&nextNumber)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invoice number"})
-		return
-	}
-
-	invoiceNumber := fmt.Sprintf("INV/%s/%04d", fy, nextNumber)
-
-	// 7️⃣ Insert invoice
-	var invoiceID int
-	err = tx.QueryRow(`
-		INSERT INTO invoices (
-			company_id,
-			user_id,
-			client_id,
-			invoice_number,
-			invoice_date,
-			due_date,
-			subtotal,
-			tax,
-			total,
-			status,
-			paid_amount,
-			remaining_amount
-		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,'draft',0,$9)
-		RETURNING id
-	`,
-		req.CompanyID,
-		userID,
-		req.ClientID,
-		invoiceNumber,
-		invDate,
-		dueDate,
-		subtotal,
-		taxTotal,
-		grandTotal,
-	).Scan(&invoiceID)
-
-	if err != nil {
-		fmt.Println("SQL ERROR:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":  "Failed to create invoice",
-			"detail": err.Error(),
-		})
-		return
-	}
-
-	// err = h.LedgerService.AddEntryTx(
-	// 	tx,
-	// 	int64(req.CompanyID),
-	// 	int64(req.ClientID),
-	// 	"INVOICE",
-	// 	int64(invoiceID),
-	// 	grandTotal,
-	// 	0,
-	// 	"Invoice "+invoiceNumber,
-	// )
-	// if err != nil {
-	// 	fmt.Println("SQL ERROR:", err)
-	// 	c.JSON(http.StatusInternalServerError, gin.H{
-	// 		"error": "Failed to create ledger entry",
-	// 	})
-	// 	return
-	// }
-
-	// 8️⃣ Insert invoice items
-	for _, item := range req.Items {
-		lineTotal := (item.Rate * float64(item.Qty)) - item.Discount
-		lineTotal += lineTotal * (item.TaxRate / 100)
-
-		_, err = tx.Exec(`
-			INSERT INTO invoice_items
-				(invoice_id, item_id, qty, rate, discount, tax_rate, total)
-			VALUES ($1,$2,$3,$4,$5,$6,$7)
-		`,
-			invoiceID,
-			item.ItemID,
-			item.Qty,
-			item.Rate,
-			item.Discount,
-			item.TaxRate,
-			lineTotal,
-		)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":  "Failed to create invoice items",
-				"detail": err.Error(),
-			})
-			return
-		}
-	}
-	// 1️⃣1️⃣ Fetch client addresses (snapshot)
-	billingAddr, err := fetchClientAddress(tx, req.ClientID, "billing")
-	if err != nil {
-		fmt.Println("SQL ERROR:", err)
+	// 6️⃣ Number, insert, items and address snapshot
+	created, err := h.InvoiceService.CreateTx(tx, userID, req)
+	if errors.Is(err, services.ErrBillingAddressRequired) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Client billing address is required",
 		})
 		return
 	}
-
-	shippingAddr, _ := fetchClientAddress(tx, req.ClientID, "shipping")
-
-	// 1️⃣2️⃣ Insert invoice address snapshot
-	if err := insertInvoiceAddress(tx, invoiceID, "billing", *billingAddr); err != nil {
+	if err != nil {
 		fmt.Println("SQL ERROR:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
-
-			"error": "Failed to save invoice billing address",
+			"error":  "Failed to create invoice",
+			"detail": err.Error(),
 		})
 		return
 	}
 
-	if shippingAddr != nil {
-		if err := insertInvoiceAddress(tx, invoiceID, "shipping", *shippingAddr); err != nil {
-			fmt.Println("SQL ERROR:", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to save invoice shipping address",
-			})
-			return
-		}
-	}
-
-	// 1️⃣3️⃣ Commit transaction
+	// 7️⃣ Commit transaction
 	if err = tx.Commit(); err != nil {
 		log.Printf("Failed to commit transaction: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -361,9 +164,9 @@ func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 	// 🔟 Response
 	c.JSON(http.StatusCreated, gin.H{
 		"message":        "Invoice created successfully",
-		"invoice_id":     invoiceID,
-		"invoice_number": invoiceNumber,
-		"financial_year": fy,
+		"invoice_id":     created.ID,
+		"invoice_number": created.InvoiceNumber,
+		"financial_year": created.FinancialYear,
 	})
 }
 
@@ -453,34 +256,7 @@ func (h *InvoiceHandler) UpdateInvoice(c *gin.Context) {
 		}
 	}
 
-	// 4️⃣ Calculate totals
-	var subtotal, taxTotal float64
-
-	for _, item := range req.Items {
-		lineBase := item.Rate * float64(item.Qty)
-		lineAfterDiscount := lineBase - item.Discount
-		lineTax := lineAfterDiscount * (item.TaxRate / 100)
-
-		subtotal += lineAfterDiscount
-		taxTotal += lineTax
-	}
-
-	total := subtotal + taxTotal
-
-	// 5️⃣ Parse dates
-	invDate, err := time.Parse("2006-01-02", req.InvoiceDate)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice_date"})
-		return
-	}
-
-	dueDate, err := time.Parse("2006-01-02", req.DueDate)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due_date"})
-		return
-	}
-
-	// 6️⃣ Transaction
+	// 4️⃣ Transaction
 	tx, err := h.db.DB.Begin()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
@@ -494,73 +270,30 @@ func (h *InvoiceHandler) UpdateInvoice(c *gin.Context) {
 		}
 	}()
 
-	// 7️⃣ Update invoice header
-	_, err = tx.Exec(`
-		UPDATE invoices
-		SET
-			client_id = $1,
-			invoice_date = $2,
-			due_date = $3,
-			subtotal = $4,
-			tax = $5,
-			total = $6,
-			remaining_amount = $6,
-			updated_at = NOW()
-		WHERE id = $7
-	`,
-		req.ClientID,
-		invDate,
-		dueDate,
-		subtotal,
-		taxTotal,
-		total,
-		invoiceID,
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invoice"})
+	// 5️⃣ Update invoice, items and GST split
+	err = h.InvoiceService.UpdateTx(tx, invoiceID, companyID, req)
+	if errors.Is(err, services.ErrInvoiceNotDraft) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only draft invoices can be edited"})
 		return
 	}
-
-	// 8️⃣ Replace invoice items
-	_, err = tx.Exec(`DELETE FROM invoice_items WHERE invoice_id = $1`, invoiceID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear invoice items"})
+	if errors.Is(err, services.ErrBillingAddressRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Client billing address is required"})
 		return
 	}
-
-	for _, item := range req.Items {
-		lineTotal := (item.Rate * float64(item.Qty)) - item.Discount
-		lineTotal += lineTotal * (item.TaxRate / 100)
-
-		_, err = tx.Exec(`
-			INSERT INTO invoice_items
-				(invoice_id, item_id, qty, rate, discount, tax_rate, total)
-			VALUES ($1,$2,$3,$4,$5,$6,$7)
-		`,
-			invoiceID,
-			item.ItemID,
-			item.Qty,
-			item.Rate,
-			item.Discount,
-			item.TaxRate,
-			lineTotal,
-		)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to insert invoice items"})
-			return
-		}
+	if err != nil {
+		log.Printf("Failed to update invoice %d: %v", invoiceID, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// 9️⃣ Commit
+	// 6️⃣ Commit
 	if err = tx.Commit(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
 		return
 	}
 	committed = true
 
-	// 🔟 Response
+	// 7️⃣ Response
 	c.JSON(http.StatusOK, gin.H{
 		"message":    "Invoice updated successfully",
 		"invoice_id": invoiceID,
@@ -603,7 +336,7 @@ func (h *InvoiceHandler) GetInvoices(c *gin.Context) {
 			status,
 			created_at,
 			GREATEST(0, CURRENT_DATE - due_date) AS days_overdue,
-			CURRENT_DATE > due_date AND status != 'paid' AS is_overdue
+			CURRENT_DATE > due_date AND status NOT IN ('paid', 'cancelled') AS is_overdue
 		FROM invoices
 		WHERE user_id = $1
 	`
@@ -651,8 +384,8 @@ func (h *InvoiceHandler) GetInvoices(c *gin.Context) {
 			invoiceNumber, status   string
 			invoiceDate, dueDate    time.Time
 			createdAt               time.Time
-			subtotal, tax, total    float64
-			paidAmount, remaining   float64
+			subtotal, tax, total    money.Money
+			paidAmount, remaining   money.Money
 			daysOverdue             int
 			isOverdue               bool
 		)
@@ -717,10 +450,15 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 		clientName              string
 		invoiceDate, dueDate    time.Time
 		createdAt               time.Time
-		subtotal, tax, total    float64
-		paidAmount, remaining   float64
+		subtotal, tax, total    money.Money
+		paidAmount, remaining   money.Money
 		daysOverdue             int
 		isOverdue               bool
+		placeOfSupply           string
+		isInterState            bool
+		cgst, sgst, igst        money.Money
+		irn, irnAckNo, irnState string
+		irnAckDate              sql.NullTime
 	)
 
 	err := h.db.DB.QueryRow(`
@@ -740,7 +478,16 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 			i.created_at,
 			c.name,
 			GREATEST(0, CURRENT_DATE - i.due_date) AS days_overdue,
-			CURRENT_DATE > i.due_date AND i.status != 'paid' AS is_overdue
+			CURRENT_DATE > i.due_date AND i.status NOT IN ('paid', 'cancelled') AS is_overdue,
+			COALESCE(i.place_of_supply, ''),
+			i.is_inter_state,
+			i.cgst,
+			i.sgst,
+			i.igst,
+			COALESCE(i.irn, ''),
+			COALESCE(i.irn_ack_no, ''),
+			i.irn_ack_date,
+			COALESCE(i.irn_status, '')
 		FROM invoices i
 		JOIN clients c ON c.id = i.client_id
 		WHERE i.id = $1 AND i.user_id = $2
@@ -761,6 +508,15 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 		&clientName,
 		&daysOverdue,
 		&isOverdue,
+		&placeOfSupply,
+		&isInterState,
+		&cgst,
+		&sgst,
+		&igst,
+		&irn,
+		&irnAckNo,
+		&irnAckDate,
+		&irnState,
 	)
 
 	if err != nil {
@@ -778,7 +534,11 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 			ii.qty,
 			ii.rate,
 			ii.discount,
+			COALESCE(ii.hsn_code, ''),
 			ii.tax_rate,
+			ii.cgst_amount,
+			ii.sgst_amount,
+			ii.igst_amount,
 			ii.total
 		FROM invoice_items ii
 		WHERE ii.invoice_id = $1
@@ -794,10 +554,14 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 	defer rows.Close()
 
 	var items []gin.H
+	var taxLines []utils.TaxLine
 	for rows.Next() {
 		var (
-			itemRowID, itemID, qty             int
-			rate, discount, taxRate, lineTotal float64
+			itemRowID, itemID, qty       int
+			taxRate                      float64
+			rate, discount, lineTotal    money.Money
+			lineCGST, lineSGST, lineIGST money.Money
+			hsnCode                      string
 		)
 
 		if err := rows.Scan(
@@ -806,7 +570,11 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 			&qty,
 			&rate,
 			&discount,
+			&hsnCode,
 			&taxRate,
+			&lineCGST,
+			&lineSGST,
+			&lineIGST,
 			&lineTotal,
 		); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -816,16 +584,45 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 		}
 
 		items = append(items, gin.H{
-			"id":       itemRowID,
-			"item_id":  itemID,
-			"qty":      qty,
-			"rate":     rate,
-			"discount": discount,
-			"tax_rate": taxRate,
-			"total":    lineTotal,
+			"id":          itemRowID,
+			"item_id":     itemID,
+			"qty":         qty,
+			"rate":        rate,
+			"discount":    discount,
+			"hsn_code":    hsnCode,
+			"tax_rate":    taxRate,
+			"cgst_amount": lineCGST,
+			"sgst_amount": lineSGST,
+			"igst_amount": lineIGST,
+			"total":       lineTotal,
+		})
+
+		taxLines = append(taxLines, utils.TaxLine{
+			TaxRate: taxRate,
+			CGST:    lineCGST,
+			SGST:    lineSGST,
+			IGST:    lineIGST,
 		})
 	}
 
+	hsnSummary, err := services.InvoiceHSNSummary(h.db.DB, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to build HSN summary",
+		})
+		return
+	}
+
+	var einvoice gin.H
+	if irnState != "" {
+		einvoice = gin.H{
+			"status":   irnState,
+			"irn":      irn,
+			"ack_no":   irnAckNo,
+			"ack_date": irnAckDate.Time,
+		}
+	}
+
 	// Final response
 	c.JSON(http.StatusOK, gin.H{
 		"id":               id,
@@ -835,6 +632,13 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 		"due_date":         dueDate.Format("2006-01-02"),
 		"subtotal":         subtotal,
 		"tax":              tax,
+		"cgst":             cgst,
+		"sgst":             sgst,
+		"igst":             igst,
+		"tax_heads":        utils.SummarizeTaxHeads(taxLines),
+		"place_of_supply":  placeOfSupply,
+		"is_inter_state":   isInterState,
+		"einvoice":         einvoice,
 		"total":            total,
 		"paid_amount":      paidAmount,
 		"remaining_amount": remaining,
@@ -844,8 +648,9 @@ func (h *InvoiceHandler) GetInvoiceByID(c *gin.Context) {
 			"id":   clientID,
 			"name": clientName,
 		},
-		"items":      items,
-		"created_at": createdAt,
+		"items":       items,
+		"hsn_summary": hsnSummary,
+		"created_at":  createdAt,
 	})
 }
 
@@ -873,20 +678,24 @@ func (h *InvoiceHandler) GetInvoiceNumberPreview(c *gin.Context) {
 		return
 	}
 
-	fy := utils.FinancialYear(time.Now()) // "2024-25"
-
-	var next int
-	err = h.db.DB.QueryRow(`
-		SELECT COALESCE(next_number, 0) + 1
-        FROM invoice_counters
-        WHERE company_id = $1 AND financial_year = $2 
-	`, companyID, fy).Scan(&next)
+	// Same scheme and date rules as CreateTx; defaults to today
+	invoiceDate, ok := parsePreviewDate(c, "invoice_date")
+	if !ok {
+		return
+	}
 
+	id, err := strconv.ParseInt(companyID, 10, 64)
 	if err != nil {
-		next = 1
+		c.JSON(400, gin.H{"error": "invalid company_id"})
+		return
 	}
 
-	preview := fmt.Sprintf("INV/%s/%06d", fy, next)
+	preview, err := h.NumberingService.Preview(id, services.DocInvoice, invoiceDate)
+	if err != nil {
+		fmt.Println("Error previewing invoice number:", err)
+		c.JSON(500, gin.H{"error": "Failed to preview invoice number"})
+		return
+	}
 
 	c.JSON(200, gin.H{
 		"preview": preview,
@@ -1021,6 +830,13 @@ func (h *InvoiceHandler) IssueInvoice(c *gin.Context) {
 	invoiceID, _ := strconv.Atoi(c.Param("id"))
 	userID := c.GetInt("user_id")
 
+	var req models.IssueInvoiceRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	applyCredit := req.ApplyCredit == nil || *req.ApplyCredit
+
 	tx, err := h.db.DB.Begin()
 	if err != nil {
 		c.JSON(500, gin.H{"error": "failed to start transaction"})
@@ -1028,63 +844,89 @@ func (h *InvoiceHandler) IssueInvoice(c *gin.Context) {
 	}
 	defer tx.Rollback()
 
-	var (
-		status    string
-		total     float64
-		clientID  int64
-		companyID int64
-		number    string
-	)
+	creditApplied, err := h.InvoiceService.IssueTx(tx, userID, invoiceID, applyCredit)
+	if err == sql.ErrNoRows {
+		c.JSON(404, gin.H{"error": "invoice not found"})
+		return
+	}
+	if errors.Is(err, services.ErrInvoiceAlreadyIssued) {
+		c.JSON(400, gin.H{"error": "invoice already issued"})
+		return
+	}
+	var stockErr *services.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": stockErr.Error(),
+			"items": stockErr.Items,
+		})
+		return
+	}
+	if err != nil {
+		fmt.Println("SQL ERROR:", err)
+		c.JSON(500, gin.H{"error": "failed to issue invoice"})
+		return
+	}
 
-	err = tx.QueryRow(`
-        SELECT status, total, client_id, company_id, invoice_number
-        FROM invoices
-        WHERE id = $1 AND user_id = $2
-    `, invoiceID, userID).Scan(
-		&status, &total, &clientID, &companyID, &number,
-	)
+	tx.Commit()
 
+	c.JSON(200, gin.H{
+		"message":        "Invoice issued successfully",
+		"credit_applied": creditApplied,
+	})
+}
+
+// POST /api/v1/invoices/:id/cancel
+func (h *InvoiceHandler) CancelInvoice(c *gin.Context) {
+	invoiceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "invoice not found"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice id"})
 		return
 	}
 
-	if status != "draft" {
-		c.JSON(400, gin.H{"error": "invoice already issued"})
+	userID := c.GetInt("user_id")
+
+	var req models.CancelInvoiceRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 1️⃣ Update invoice
-	_, err = tx.Exec(`
-        UPDATE invoices
-        SET status = 'issued',
-            remaining_amount = total
-        WHERE id = $1
-    `, invoiceID)
+	tx, err := h.db.DB.Begin()
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to update invoice"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
 		return
 	}
 
-	// 2️⃣ Ledger entry (THIS is the correct place)
-	err = h.LedgerService.AddEntryTx(
-		tx,
-		companyID,
-		clientID,
-		"INVOICE",
-		int64(invoiceID),
-		total,
-		0,
-		"Invoice "+number,
-	)
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	unapplied, err := h.InvoiceService.CancelTx(tx, userID, invoiceID, req)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+		return
+	}
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to create ledger entry"})
+		fmt.Println("Error cancelling invoice:", err)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
 
-	tx.Commit()
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
 
-	c.JSON(200, gin.H{"message": "Invoice issued successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Invoice cancelled successfully",
+		"invoice_id":       invoiceID,
+		"unapplied_amount": unapplied,
+	})
 }
 
 // handlers/invoice_handler.go (add this method)
@@ -57,12 +57,26 @@ func (h *itemHandler) CreateItem(c *gin.Context) {
 		return
 	}
 
-	// Insert the item
-	// ✅ New
+	// Insert the item; starting stock is logged as its opening movement and
+	// raises a low-stock alert if it already starts below the threshold
 	_, err = h.db.DB.Exec(`
-    INSERT INTO items 
-    (name, category_id, sku, unit, description, cost_price, price, quantity, low_stock_alert, tax_rate, hsn_code, company_id, user_id) 
-    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+    WITH item AS (
+        INSERT INTO items 
+        (name, category_id, sku, unit, description, cost_price, price, quantity, low_stock_alert, tax_rate, hsn_code, company_id, user_id) 
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+        RETURNING id, company_id, user_id, name, quantity, COALESCE(low_stock_alert, 0) AS low_stock_alert
+    ),
+    opening AS (
+        INSERT INTO stock_movements (company_id, item_id, source_type, quantity, balance_after, note, created_by)
+        SELECT company_id, id, 'OPENING', quantity, quantity, 'Opening stock', user_id
+        FROM item
+        WHERE quantity <> 0
+    )
+    INSERT INTO notifications (company_id, type, title, message, item_id)
+    SELECT company_id, 'low_stock', 'Low stock: ' || name,
+           name || ' is down to ' || quantity || ' (alert below ' || low_stock_alert || ').', id
+    FROM item
+    WHERE low_stock_alert > 0 AND quantity < low_stock_alert
 `,
 		request.Name,
 		request.CategoryID,
@@ -90,7 +104,8 @@ func (h *itemHandler) CreateItem(c *gin.Context) {
 
 func (h *itemHandler) GetItems(c *gin.Context) {
 
-	companyID := c.Param("companyId")
+	// Company id; the route shares the :id wildcard with /items/:id/stock-history
+	companyID := c.Param("id")
 	userID := c.GetInt("user_id")
 
 	fmt.Println("Fetching items for company:", companyID, "by user:", userID)
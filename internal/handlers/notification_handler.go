@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	service *services.NotificationService
+	db      *sql.DB
+}
+
+func NewNotificationHandler(service *services.NotificationService, db *sql.DB) *NotificationHandler {
+	return &NotificationHandler{service: service, db: db}
+}
+
+// GET /api/v1/companies/:companyId/notifications?unread=true&limit=&offset=
+func (h *NotificationHandler) List(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 {
+		limit = 20
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	notifications, err := h.service.List(companyID, c.Query("unread") == "true", limit, offset)
+	if err != nil {
+		fmt.Println("Error fetching notifications:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   notifications,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// POST /api/v1/notifications/:id/read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification id"})
+		return
+	}
+
+	err = h.service.MarkRead(c.GetInt("user_id"), id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error marking notification read:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NumberingHandler struct {
+	service *services.NumberingService
+	db      *sql.DB
+}
+
+func NewNumberingHandler(service *services.NumberingService, db *sql.DB) *NumberingHandler {
+	return &NumberingHandler{service: service, db: db}
+}
+
+// GET /api/v1/companies/:companyId/numbering-schemes
+func (h *NumberingHandler) List(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	schemes, err := h.service.List(companyID)
+	if err != nil {
+		fmt.Println("Error fetching numbering schemes:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch numbering schemes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schemes})
+}
+
+// PUT /api/v1/companies/:companyId/numbering-schemes/:documentType
+func (h *NumberingHandler) Upsert(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	var req models.NumberingSchemeRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scheme, err := h.service.Upsert(companyID, c.Param("documentType"), req)
+
+	var verr *services.NumberingValidationError
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrUnknownDocumentType):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.As(err, &verr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": verr.Error()})
+		return
+	default:
+		fmt.Println("Error saving numbering scheme:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save numbering scheme"})
+		return
+	}
+
+	c.JSON(http.StatusOK, scheme)
+}
+
+// GET /api/v1/companies/:companyId/numbering-schemes/:documentType/preview?date=YYYY-MM-DD
+func (h *NumberingHandler) Preview(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	date, ok := parsePreviewDate(c, "date")
+	if !ok {
+		return
+	}
+
+	preview, err := h.service.Preview(companyID, c.Param("documentType"), date)
+	if errors.Is(err, services.ErrUnknownDocumentType) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error previewing document number:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview number"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"preview": preview})
+}
+
+// parsePreviewDate reads an optional YYYY-MM-DD query param, defaulting to
+// today. It writes the error response itself and returns ok=false on failure.
+func parsePreviewDate(c *gin.Context, param string) (time.Time, bool) {
+	raw := c.Query(param)
+	if raw == "" {
+		return time.Now(), true
+	}
+
+	date, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s (YYYY-MM-DD)", param)})
+		return time.Time{}, false
+	}
+
+	return date, true
+}
@@ -1,25 +1,38 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	database "invo-server/internal/db"
 	"invo-server/internal/models"
+	"invo-server/internal/pdf"
 	"invo-server/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type PaymentHandler struct {
-	db      *database.Database
-	service *services.PaymentService
+	db           *database.Database
+	service      *services.PaymentService
+	emailService *services.EmailService
 }
 
-func NewPaymentHandler(db *database.Database, service *services.PaymentService) *PaymentHandler {
+func NewPaymentHandler(
+	db *database.Database,
+	service *services.PaymentService,
+	emailService *services.EmailService,
+) *PaymentHandler {
 	return &PaymentHandler{
-		db:      db,
-		service: service,
+		db:           db,
+		service:      service,
+		emailService: emailService,
 	}
 }
 
@@ -55,16 +68,376 @@ func (h *PaymentHandler) RecordPayment(c *gin.Context) {
 		return
 	}
 
-	err = h.service.RecordPaymentTx(tx, companyID, req.ClientID, req)
+	paymentID, unapplied, err := h.service.RecordPaymentTx(tx, companyID, req.ClientID, req)
 	if err != nil {
 		fmt.Println("SQL ERROR:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	tx.Commit()
+	if err := tx.Commit(); err != nil {
+		log.Printf("❌ Failed to commit payment: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	resp := gin.H{
+		"message":          "Payment recorded successfully",
+		"payment_id":       paymentID,
+		"unapplied_amount": unapplied,
+	}
+
+	// The payment stands even if the receipt can't be sent
+	if req.EmailReceipt {
+		if err := h.emailReceipt(paymentID, req.ClientID); err != nil {
+			log.Println("EMAIL ERROR:", err)
+			resp["receipt_emailed"] = false
+			resp["email_error"] = err.Error()
+		} else {
+			resp["receipt_emailed"] = true
+		}
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// emailReceipt renders a payment's receipt and sends it to the client.
+func (h *PaymentHandler) emailReceipt(paymentID, clientID int64) error {
+	toName, toEmail, err := services.ClientEmailRecipient(h.db.DB, clientID)
+	if err != nil {
+		return err
+	}
+
+	data, err := services.FetchPaymentReceiptPDFData(h.db.DB, paymentID)
+	if err != nil {
+		return err
+	}
+
+	pdfBytes, err := pdf.GeneratePaymentReceiptPDF(data)
+	if err != nil {
+		return fmt.Errorf("generate receipt: %w", err)
+	}
+
+	return h.emailService.SendPaymentReceiptEmail(toEmail, toName, data.Number, pdfBytes)
+}
+
+// GET /api/v1/companies/:companyId/payments?client_id=&status=&payment_method=&from=&to=&limit=&offset=
+func (h *PaymentHandler) List(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db.DB)
+	if !ok {
+		return
+	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Payment recorded successfully",
+	var filter models.PaymentFilter
+
+	if v := c.Query("client_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id"})
+			return
+		}
+		filter.ClientID = id
+	}
+
+	filter.Status = c.Query("status")
+	if filter.Status != "" && filter.Status != "recorded" && filter.Status != "voided" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be recorded or voided"})
+		return
+	}
+
+	filter.PaymentMethod = c.Query("payment_method")
+
+	if v := c.Query("from"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from (YYYY-MM-DD)"})
+			return
+		}
+		filter.From = &d
+	}
+
+	if v := c.Query("to"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to (YYYY-MM-DD)"})
+			return
+		}
+		filter.To = &d
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	payments, err := h.service.List(companyID, filter, limit, offset)
+	if err != nil {
+		fmt.Println("Error fetching payments:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   payments,
+		"limit":  limit,
+		"offset": offset,
 	})
 }
+
+// GET /api/v1/payments/:id
+func (h *PaymentHandler) GetByID(c *gin.Context) {
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment id"})
+		return
+	}
+
+	payment, err := h.service.GetByID(c.GetInt("user_id"), paymentID)
+	if errors.Is(err, services.ErrPaymentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching payment:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// GET /api/v1/payments/:id/receipt.pdf
+func (h *PaymentHandler) GetReceiptPDF(c *gin.Context) {
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment id"})
+		return
+	}
+
+	// 🔐 Authorization
+	if _, err := h.service.GetByID(c.GetInt("user_id"), paymentID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	data, err := services.FetchPaymentReceiptPDFData(h.db.DB, paymentID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch payment data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payment data"})
+		return
+	}
+
+	pdfBytes, err := pdf.GeneratePaymentReceiptPDF(data)
+	if err != nil {
+		log.Printf("❌ PDF generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	fileName := fmt.Sprintf("Receipt_%s.pdf", data.Number)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// POST /api/v1/payments/:id/void
+func (h *PaymentHandler) Void(c *gin.Context) {
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment id"})
+		return
+	}
+
+	var req models.VoidPaymentRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	payment, err := h.service.VoidTx(tx, c.GetInt("user_id"), paymentID, req.Reason)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrPaymentVoided):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error voiding payment:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// POST /api/v1/payments/:id/apply
+func (h *PaymentHandler) Apply(c *gin.Context) {
+	paymentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment id"})
+		return
+	}
+
+	var req models.ApplyPaymentRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.DB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	payment, err := h.service.ApplyTx(tx, c.GetInt("user_id"), paymentID, req)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrPaymentVoided):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error applying payment:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// GET /api/v1/clients/:clientId/credit
+func (h *PaymentHandler) ClientCredit(c *gin.Context) {
+	clientID, err := strconv.ParseInt(c.Param("clientId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client id"})
+		return
+	}
+
+	credit, err := h.service.ClientCredit(c.GetInt("user_id"), clientID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching client credit:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch client credit"})
+		return
+	}
+
+	c.JSON(http.StatusOK, credit)
+}
+
+// POST /api/v1/payments/preview-allocation
+func (h *PaymentHandler) PreviewAllocation(c *gin.Context) {
+	var req models.AllocationPreviewRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.service.PreviewAllocation(c.GetInt("user_id"), req)
+	switch {
+	case err == nil:
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+		return
+	case errors.Is(err, services.ErrUnknownAllocationStrategy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error previewing allocation:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview allocation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// GET /api/v1/companies/:companyId/payment-allocation-strategy
+func (h *PaymentHandler) GetAllocationStrategy(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db.DB)
+	if !ok {
+		return
+	}
+
+	strategy, err := h.service.GetAllocationStrategy(companyID)
+	if err != nil {
+		fmt.Println("Error fetching allocation strategy:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch allocation strategy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AllocationStrategyDTO{Strategy: strategy})
+}
+
+// PUT /api/v1/companies/:companyId/payment-allocation-strategy
+func (h *PaymentHandler) SetAllocationStrategy(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db.DB)
+	if !ok {
+		return
+	}
+
+	var req models.AllocationStrategyDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.service.SetAllocationStrategy(companyID, req.Strategy)
+	if errors.Is(err, services.ErrUnknownAllocationStrategy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error saving allocation strategy:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save allocation strategy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, req)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseBillHandler struct {
+	service *services.PurchaseBillService
+	db      *sql.DB
+}
+
+func NewPurchaseBillHandler(service *services.PurchaseBillService, db *sql.DB) *PurchaseBillHandler {
+	return &PurchaseBillHandler{service: service, db: db}
+}
+
+// POST /api/v1/purchase-bills
+func (h *PurchaseBillHandler) Create(c *gin.Context) {
+	var req models.PurchaseBillRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	bill, err := h.service.CreateTx(tx, c.GetInt("user_id"), req)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrVendorNotFound), errors.Is(err, services.ErrPurchaseOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrDuplicatePurchaseBill):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error creating purchase bill:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, bill)
+}
+
+// GET /api/v1/companies/:companyId/purchase-bills?status=&limit=&offset=
+func (h *PurchaseBillHandler) List(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	bills, err := h.service.List(companyID, c.Query("status"), limit, offset)
+	if err != nil {
+		fmt.Println("Error fetching purchase bills:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchase bills"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   bills,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GET /api/v1/purchase-bills/:id
+func (h *PurchaseBillHandler) GetByID(c *gin.Context) {
+	billID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bill id"})
+		return
+	}
+
+	bill, err := h.service.GetByID(c.GetInt("user_id"), billID)
+	if errors.Is(err, services.ErrPurchaseBillNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching purchase bill:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchase bill"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bill)
+}
+
+// POST /api/v1/vendor-payments
+func (h *PurchaseBillHandler) RecordPayment(c *gin.Context) {
+	var req models.VendorPaymentRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	paymentID, err := h.service.RecordPaymentTx(tx, c.GetInt("user_id"), req)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrVendorNotFound), errors.Is(err, services.ErrPurchaseBillNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error recording vendor payment:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Vendor payment recorded successfully",
+		"payment_id": paymentID,
+	})
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/pdf"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseOrderHandler struct {
+	service *services.PurchaseOrderService
+	db      *sql.DB
+}
+
+func NewPurchaseOrderHandler(service *services.PurchaseOrderService, db *sql.DB) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service, db: db}
+}
+
+// POST /api/v1/purchase-orders
+func (h *PurchaseOrderHandler) Create(c *gin.Context) {
+	var req models.PurchaseOrderRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	po, err := h.service.CreateTx(tx, c.GetInt("user_id"), req)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrVendorNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error creating purchase order:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, po)
+}
+
+// GET /api/v1/companies/:companyId/purchase-orders?status=&limit=&offset=
+func (h *PurchaseOrderHandler) List(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	orders, err := h.service.List(companyID, c.Query("status"), limit, offset)
+	if err != nil {
+		fmt.Println("Error fetching purchase orders:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchase orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   orders,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GET /api/v1/purchase-orders/:id
+func (h *PurchaseOrderHandler) GetByID(c *gin.Context) {
+	poID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase order id"})
+		return
+	}
+
+	po, err := h.service.GetByID(c.GetInt("user_id"), poID)
+	if errors.Is(err, services.ErrPurchaseOrderNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching purchase order:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchase order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, po)
+}
+
+// POST /api/v1/purchase-orders/:id/status
+func (h *PurchaseOrderHandler) UpdateStatus(c *gin.Context) {
+	poID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase order id"})
+		return
+	}
+
+	var req models.PurchaseOrderStatusRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.service.UpdateStatus(c.GetInt("user_id"), poID, req.Status)
+	if errors.Is(err, services.ErrPurchaseOrderNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Purchase order status updated", "status": req.Status})
+}
+
+// POST /api/v1/purchase-orders/:id/receive
+func (h *PurchaseOrderHandler) Receive(c *gin.Context) {
+	poID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase order id"})
+		return
+	}
+
+	var req models.ReceivePurchaseOrderDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = h.service.ReceiveTx(tx, userID, poID, req)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrPurchaseOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrPurchaseOrderNotOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error receiving purchase order:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	po, err := h.service.GetByID(userID, poID)
+	if err != nil {
+		fmt.Println("Error fetching purchase order:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Goods received but failed to load purchase order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, po)
+}
+
+// GET /api/v1/purchase-orders/:id/pdf
+func (h *PurchaseOrderHandler) GetPDF(c *gin.Context) {
+	poID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase order id"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+
+	// 🔐 Authorization
+	var authorized bool
+	err = h.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM purchase_orders po
+			JOIN companies c ON c.id = po.company_id
+			WHERE po.id = $1 AND c.user_id = $2
+		)
+	`, poID, userID).Scan(&authorized)
+
+	if err != nil || !authorized {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	data, err := services.FetchPurchaseOrderPDFData(h.db, poID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch purchase order data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch purchase order data"})
+		return
+	}
+
+	pdfBytes, err := pdf.GeneratePurchaseOrderPDF(data)
+	if err != nil {
+		log.Printf("❌ PDF generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	fileName := fmt.Sprintf("PO_%s.pdf", data.Invoice.InvoiceNumber)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
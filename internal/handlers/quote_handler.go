@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/pdf"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuoteHandler struct {
+	service *services.QuoteService
+	db      *sql.DB
+}
+
+func NewQuoteHandler(service *services.QuoteService, db *sql.DB) *QuoteHandler {
+	return &QuoteHandler{service: service, db: db}
+}
+
+// POST /api/v1/quotes
+func (h *QuoteHandler) Create(c *gin.Context) {
+	var req models.QuoteRequestDTO
+	userID := c.GetInt("user_id")
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 1️⃣ Validate client belongs to an owned company
+	var clientOK bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM clients cl
+			JOIN companies c ON c.id = cl.company_id
+			WHERE cl.id = $1 AND cl.company_id = $2 AND c.user_id = $3
+		)
+	`, req.ClientID, req.CompanyID, userID).Scan(&clientOK)
+
+	if err != nil || !clientOK {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or unauthorized client"})
+		return
+	}
+
+	// 2️⃣ Validate items
+	for _, item := range req.Items {
+		var itemExists bool
+		err = h.db.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM items
+				WHERE id = $1 AND user_id = $2 AND company_id = $3
+			)
+		`, item.ItemID, userID, req.CompanyID).Scan(&itemExists)
+
+		if err != nil || !itemExists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid or unauthorized item",
+				"item_id": item.ItemID,
+			})
+			return
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	quoteID, quoteNumber, err := h.service.CreateTx(tx, userID, req)
+	if err != nil {
+		fmt.Println("Error creating quote:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Quote created successfully",
+		"quote_id":     quoteID,
+		"quote_number": quoteNumber,
+	})
+}
+
+// GET /api/v1/quotes?company_id=&status=&limit=&offset=
+func (h *QuoteHandler) GetAll(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	companyID, err := strconv.ParseInt(c.Query("company_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_id is required"})
+		return
+	}
+
+	var exists bool
+	h.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM companies WHERE id = $1 AND user_id = $2
+		)
+	`, companyID, userID).Scan(&exists)
+
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	result, err := h.service.GetAll(companyID, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   result,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GET /api/v1/quotes/:id
+func (h *QuoteHandler) GetByID(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	quoteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quote id"})
+		return
+	}
+
+	result, err := h.service.GetByID(userID, quoteID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "quote not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /api/v1/quotes/:id/status
+func (h *QuoteHandler) UpdateStatus(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	quoteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quote id"})
+		return
+	}
+
+	var req models.QuoteStatusRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.service.UpdateStatus(userID, quoteID, req.Status)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "quote not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quote status updated", "status": req.Status})
+}
+
+// POST /api/v1/quotes/:id/convert
+func (h *QuoteHandler) Convert(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	quoteID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quote id"})
+		return
+	}
+
+	var req models.ConvertQuoteRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	created, err := h.service.ConvertTx(tx, userID, quoteID, req)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "quote not found"})
+		return
+	}
+	if errors.Is(err, services.ErrBillingAddressRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Client billing address is required"})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error converting quote:", err)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "Quote converted to invoice",
+		"quote_id":       quoteID,
+		"invoice_id":     created.ID,
+		"invoice_number": created.InvoiceNumber,
+		"financial_year": created.FinancialYear,
+	})
+}
+
+// GET /api/v1/quotes/:id/pdf
+func (h *QuoteHandler) GetPDF(c *gin.Context) {
+	quoteID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quote id"})
+		return
+	}
+
+	userID := c.GetInt("user_id")
+
+	// 🔐 Authorization
+	var authorized bool
+	err = h.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM quotes q
+			JOIN companies c ON c.id = q.company_id
+			WHERE q.id = $1 AND c.user_id = $2
+		)
+	`, quoteID, userID).Scan(&authorized)
+
+	if err != nil || !authorized {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	data, err := services.FetchQuotePDFData(h.db, quoteID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch quote data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quote data"})
+		return
+	}
+
+	pdfBytes, err := pdf.GenerateQuotePDF(data)
+	if err != nil {
+		log.Printf("❌ PDF generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	fileName := fmt.Sprintf("Quote_%s.pdf", data.Invoice.InvoiceNumber)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
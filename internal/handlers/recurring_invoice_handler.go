@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RecurringInvoiceHandler struct {
+	service *services.RecurringInvoiceService
+	db      *sql.DB
+}
+
+func NewRecurringInvoiceHandler(service *services.RecurringInvoiceService, db *sql.DB) *RecurringInvoiceHandler {
+	return &RecurringInvoiceHandler{service: service, db: db}
+}
+
+// POST /api/v1/recurring-invoices
+func (h *RecurringInvoiceHandler) Create(c *gin.Context) {
+	var req models.RecurringInvoiceRequestDTO
+	userID := c.GetInt("user_id")
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 1️⃣ Validate client belongs to an owned company
+	var clientOK bool
+	err := h.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM clients cl
+			JOIN companies c ON c.id = cl.company_id
+			WHERE cl.id = $1 AND cl.company_id = $2 AND c.user_id = $3
+		)
+	`, req.ClientID, req.CompanyID, userID).Scan(&clientOK)
+
+	if err != nil || !clientOK {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or unauthorized client"})
+		return
+	}
+
+	// 2️⃣ Validate items
+	for _, item := range req.Items {
+		var itemExists bool
+		err = h.db.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM items
+				WHERE id = $1 AND user_id = $2 AND company_id = $3
+			)
+		`, item.ItemID, userID, req.CompanyID).Scan(&itemExists)
+
+		if err != nil || !itemExists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid or unauthorized item",
+				"item_id": item.ItemID,
+			})
+			return
+		}
+	}
+
+	id, err := h.service.Create(userID, req)
+	if err != nil {
+		fmt.Println("Error creating recurring profile:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Recurring invoice profile created",
+		"id":      id,
+	})
+}
+
+// GET /api/v1/companies/:companyId/recurring-invoices
+func (h *RecurringInvoiceHandler) List(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	companyID, err := strconv.ParseInt(c.Param("companyId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid company id"})
+		return
+	}
+
+	var exists bool
+	h.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM companies WHERE id = $1 AND user_id = $2
+		)
+	`, companyID, userID).Scan(&exists)
+
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized company access"})
+		return
+	}
+
+	result, err := h.service.List(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// GET /api/v1/recurring-invoices/:id
+func (h *RecurringInvoiceHandler) GetByID(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile id"})
+		return
+	}
+
+	result, err := h.service.GetByID(userID, id)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recurring profile not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// POST /api/v1/recurring-invoices/:id/pause
+func (h *RecurringInvoiceHandler) Pause(c *gin.Context) {
+	h.setStatus(c, "paused")
+}
+
+// POST /api/v1/recurring-invoices/:id/resume
+func (h *RecurringInvoiceHandler) Resume(c *gin.Context) {
+	h.setStatus(c, "active")
+}
+
+func (h *RecurringInvoiceHandler) setStatus(c *gin.Context, status string) {
+	userID := c.GetInt("user_id")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile id"})
+		return
+	}
+
+	err = h.service.SetStatus(userID, id, status)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recurring profile not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring profile " + status, "status": status})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/pdf"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	service *services.RefundService
+	db      *sql.DB
+}
+
+func NewRefundHandler(service *services.RefundService, db *sql.DB) *RefundHandler {
+	return &RefundHandler{service: service, db: db}
+}
+
+// POST /api/v1/refunds
+func (h *RefundHandler) Create(c *gin.Context) {
+	var req models.RefundRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	refund, err := h.service.CreateTx(tx, c.GetInt("user_id"), req)
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrCreditNoteNotFound), errors.Is(err, services.ErrPaymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error creating refund:", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, refund)
+}
+
+// GET /api/v1/companies/:companyId/refunds?limit=&offset=
+func (h *RefundHandler) List(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 {
+		limit = 10
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	refunds, err := h.service.List(companyID, limit, offset)
+	if err != nil {
+		fmt.Println("Error fetching refunds:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refunds"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   refunds,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// GET /api/v1/refunds/:id
+func (h *RefundHandler) GetByID(c *gin.Context) {
+	refundID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund id"})
+		return
+	}
+
+	refund, err := h.service.GetByID(c.GetInt("user_id"), refundID)
+	if errors.Is(err, services.ErrRefundNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching refund:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refund"})
+		return
+	}
+
+	c.JSON(http.StatusOK, refund)
+}
+
+// GET /api/v1/refunds/:id/pdf
+func (h *RefundHandler) GetPDF(c *gin.Context) {
+	refundID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid refund id"})
+		return
+	}
+
+	// 🔐 Authorization
+	if _, err := h.service.GetByID(c.GetInt("user_id"), refundID); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	data, err := services.FetchRefundVoucherPDFData(h.db, refundID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch refund data: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch refund data"})
+		return
+	}
+
+	pdfBytes, err := pdf.GenerateRefundVoucherPDF(data)
+	if err != nil {
+		log.Printf("❌ PDF generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	fileName := fmt.Sprintf("Refund_%s.pdf", data.Number)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"invo-server/internal/pdf"
+	"invo-server/internal/services"
+	utils "invo-server/internal/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	service *services.ReportService
+	db      *sql.DB
+}
+
+func NewReportHandler(service *services.ReportService, db *sql.DB) *ReportHandler {
+	return &ReportHandler{service: service, db: db}
+}
+
+func (h *ReportHandler) authorizeCompany(c *gin.Context) (int64, bool) {
+	return authorizeCompany(c, h.db)
+}
+
+// authorizeCompany parses :companyId and checks it belongs to the user.
+// It writes the error response itself and returns ok=false on failure.
+func authorizeCompany(c *gin.Context, db *sql.DB) (int64, bool) {
+	userID := c.GetInt("user_id")
+
+	companyID, err := strconv.ParseInt(c.Param("companyId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid company id"})
+		return 0, false
+	}
+
+	var exists bool
+	db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM companies WHERE id = $1 AND user_id = $2
+		)
+	`, companyID, userID).Scan(&exists)
+
+	if !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized company access"})
+		return 0, false
+	}
+
+	return companyID, true
+}
+
+// parseDateRange reads ?from=&to= (YYYY-MM-DD), defaulting to the current
+// month up to today.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from (YYYY-MM-DD)"})
+			return from, to, false
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to (YYYY-MM-DD)"})
+			return from, to, false
+		}
+	}
+
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return from, to, false
+	}
+
+	return from, to, true
+}
+
+// GET /api/v1/companies/:companyId/reports/hsn-summary?from=&to=
+func (h *ReportHandler) HSNSummary(c *gin.Context) {
+	companyID, ok := h.authorizeCompany(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.HSNSummary(companyID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GET /api/v1/companies/:companyId/reports/gstr1?period=YYYY-MM
+func (h *ReportHandler) GSTR1(c *gin.Context) {
+	companyID, ok := h.authorizeCompany(c)
+	if !ok {
+		return
+	}
+
+	period := c.Query("period")
+	if _, _, _, err := utils.ParseReturnPeriod(period); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	export, err := h.service.GSTR1(companyID, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ?download=1 returns just the file the offline tool imports
+	if c.Query("download") == "1" {
+		c.Header("Content-Disposition",
+			fmt.Sprintf("attachment; filename=GSTR1_%s_%s.json", export.Return.GSTIN, export.Return.FP))
+		c.JSON(http.StatusOK, export.Return)
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// GET /api/v1/companies/:companyId/reports/gstr3b?period=YYYY-MM
+func (h *ReportHandler) GSTR3B(c *gin.Context) {
+	companyID, ok := h.authorizeCompany(c)
+	if !ok {
+		return
+	}
+
+	period := c.Query("period")
+	if _, _, _, err := utils.ParseReturnPeriod(period); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.GSTR3B(companyID, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GET /api/v1/companies/:companyId/reports/gstr3b/pdf?period=YYYY-MM
+func (h *ReportHandler) GSTR3BPDF(c *gin.Context) {
+	companyID, ok := h.authorizeCompany(c)
+	if !ok {
+		return
+	}
+
+	period := c.Query("period")
+	if _, _, _, err := utils.ParseReturnPeriod(period); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.GSTR3B(companyID, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var companyName string
+	h.db.QueryRow(`SELECT name FROM companies WHERE id = $1`, companyID).Scan(&companyName)
+
+	pdfBytes, err := pdf.GenerateGSTR3BPDF(services.GSTR3BPDFData(companyName, report))
+	if err != nil {
+		log.Printf("❌ PDF generation failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=GSTR3B_%s.pdf", period))
+	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// GET /api/v1/companies/:companyId/reports/reorder?days=30
+func (h *ReportHandler) Reorder(c *gin.Context) {
+	companyID, ok := h.authorizeCompany(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+
+	report, err := h.service.Reorder(companyID, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
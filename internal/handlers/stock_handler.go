@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockHandler struct {
+	service *services.StockService
+	db      *sql.DB
+}
+
+func NewStockHandler(service *services.StockService, db *sql.DB) *StockHandler {
+	return &StockHandler{service: service, db: db}
+}
+
+// GET /api/v1/items/:id/stock-history?limit=&offset=
+func (h *StockHandler) History(c *gin.Context) {
+	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item id"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 {
+		limit = 50
+	}
+
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	movements, err := h.service.History(c.GetInt("user_id"), itemID, limit, offset)
+	if errors.Is(err, services.ErrStockItemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching stock history:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   movements,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// POST /api/v1/items/:id/stock-adjustments
+func (h *StockHandler) Adjust(c *gin.Context) {
+	itemID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item id"})
+		return
+	}
+
+	var req models.StockAdjustmentRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		return
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	movement, err := h.service.AdjustTx(tx, c.GetInt("user_id"), itemID, req)
+
+	var stockErr *services.InsufficientStockError
+	switch {
+	case err == nil:
+	case errors.Is(err, services.ErrStockItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	case errors.As(err, &stockErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "adjustment would take stock below zero",
+			"items": stockErr.Items,
+		})
+		return
+	default:
+		fmt.Println("Error adjusting stock:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust stock"})
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+	committed = true
+
+	c.JSON(http.StatusCreated, movement)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"invo-server/internal/models"
+	"invo-server/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VendorHandler struct {
+	service *services.VendorService
+	ledger  *services.VendorLedgerService
+	db      *sql.DB
+}
+
+func NewVendorHandler(service *services.VendorService, ledger *services.VendorLedgerService, db *sql.DB) *VendorHandler {
+	return &VendorHandler{service: service, ledger: ledger, db: db}
+}
+
+// POST /api/v1/vendors
+func (h *VendorHandler) Create(c *gin.Context) {
+	var req models.Vendor
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vendor, err := h.service.Create(c.GetInt("user_id"), req)
+	switch {
+	case err == nil:
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized company access"})
+		return
+	case errors.Is(err, services.ErrInvalidGSTIN):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		fmt.Println("Error creating vendor:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vendor"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, vendor)
+}
+
+// GET /api/v1/companies/:companyId/vendors
+func (h *VendorHandler) List(c *gin.Context) {
+	companyID, ok := authorizeCompany(c, h.db)
+	if !ok {
+		return
+	}
+
+	vendors, err := h.service.List(companyID)
+	if err != nil {
+		fmt.Println("Error fetching vendors:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": vendors})
+}
+
+// GET /api/v1/vendors/:vendorId
+func (h *VendorHandler) GetByID(c *gin.Context) {
+	vendorID, err := strconv.ParseInt(c.Param("vendorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vendor id"})
+		return
+	}
+
+	vendor, err := h.service.GetByID(c.GetInt("user_id"), vendorID)
+	if errors.Is(err, services.ErrVendorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching vendor:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, vendor)
+}
+
+// POST /api/v1/vendors/:vendorId/address
+func (h *VendorHandler) SaveAddress(c *gin.Context) {
+	vendorID, err := strconv.ParseInt(c.Param("vendorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vendor id"})
+		return
+	}
+
+	var req models.Address
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if req.AddressType != "billing" && req.AddressType != "shipping" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be billing or shipping"})
+		return
+	}
+
+	if req.Line1 == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Address line1 is required"})
+		return
+	}
+
+	err = h.service.SaveAddress(c.GetInt("user_id"), vendorID, req)
+	if errors.Is(err, services.ErrVendorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error saving vendor address:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save vendor address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Vendor address saved"})
+}
+
+// GET /api/v1/vendors/:vendorId/address?type=billing|shipping
+func (h *VendorHandler) GetAddress(c *gin.Context) {
+	vendorID, err := strconv.ParseInt(c.Param("vendorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vendor id"})
+		return
+	}
+
+	addrType := c.Query("type")
+	if addrType != "billing" && addrType != "shipping" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be billing or shipping"})
+		return
+	}
+
+	address, err := h.service.GetAddress(c.GetInt("user_id"), vendorID, addrType)
+	if err != nil {
+		fmt.Println("Error fetching vendor address:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor address"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": address})
+}
+
+// GET /api/v1/vendors/:vendorId/ledger
+func (h *VendorHandler) Ledger(c *gin.Context) {
+	vendorID, err := strconv.ParseInt(c.Param("vendorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vendor id"})
+		return
+	}
+
+	vendor, err := h.service.GetByID(c.GetInt("user_id"), vendorID)
+	if errors.Is(err, services.ErrVendorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		fmt.Println("Error fetching vendor:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor"})
+		return
+	}
+
+	entries, err := h.ledger.GetVendorLedger(c.Request.Context(), int64(vendor.CompanyID), vendorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
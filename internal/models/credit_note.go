@@ -1,19 +1,23 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
 
 type CreditNote struct {
-	ID         int64   `json:"id"`
-	CompanyID  int64   `json:"company_id"`
-	ClientID   int64   `json:"client_id"`
-	InvoiceID  *int64  `json:"invoice_id"`
-	Type       string  `json:"type"` // item | value
-	CreditNo   string  `json:"credit_number"`
-	CreditDate string  `json:"credit_date"`
-	Reason     string  `json:"reason"`
-	Subtotal   float64 `json:"subtotal"`
-	Tax        float64 `json:"tax"`
-	Total      float64 `json:"total"`
+	ID         int64       `json:"id"`
+	CompanyID  int64       `json:"company_id"`
+	ClientID   int64       `json:"client_id"`
+	InvoiceID  *int64      `json:"invoice_id"`
+	Type       string      `json:"type"` // item | value
+	CreditNo   string      `json:"credit_number"`
+	CreditDate string      `json:"credit_date"`
+	Reason     string      `json:"reason"`
+	Subtotal   money.Money `json:"subtotal"`
+	Tax        money.Money `json:"tax"`
+	Total      money.Money `json:"total"`
 }
 
 type CreditNoteRequestDTO struct {
@@ -24,53 +28,96 @@ type CreditNoteRequestDTO struct {
 	CreditDate string              `json:"credit_date" binding:"required"`
 	Reason     string              `json:"reason"`
 	Items      []CreditNoteItemDTO `json:"items"`
-	Amount     float64             `json:"amount"`
+	Amount     money.Money         `json:"amount"`
 }
 
+// CreditNoteItemDTO is a returned line. On a note linked to an invoice, Rate
+// and TaxRate default to the invoiced values.
 type CreditNoteItemDTO struct {
-	ItemID  int64   `json:"item_id"`
-	Qty     float64 `json:"qty"`
-	Rate    float64 `json:"rate"`
-	TaxRate float64 `json:"tax_rate"`
+	ItemID  int64        `json:"item_id"`
+	Qty     float64      `json:"qty"`
+	Rate    *money.Money `json:"rate"`
+	TaxRate *float64     `json:"tax_rate"`
+}
+
+// ReturnableItem is an item billed on an invoice with how much of it can
+// still be returned on a credit note.
+type ReturnableItem struct {
+	ItemID        int64       `json:"item_id"`
+	ItemName      string      `json:"item_name"`
+	InvoicedQty   float64     `json:"invoiced_qty"`
+	ReturnedQty   float64     `json:"returned_qty"`
+	ReturnableQty float64     `json:"returnable_qty"`
+	Rate          money.Money `json:"rate"` // net of line discount
+	TaxRate       float64     `json:"tax_rate"`
+}
+
+// CreditNoteApplyRequestDTO applies a credit note to open invoices. Without
+// allocations, Amount (default: the whole balance) is applied oldest
+// invoice first.
+type CreditNoteApplyRequestDTO struct {
+	Amount      money.Money               `json:"amount"`
+	Allocations []CreditNoteAllocationDTO `json:"allocations,omitempty"`
+}
+
+type CreditNoteAllocationDTO struct {
+	InvoiceID int64       `json:"invoice_id" binding:"required"`
+	Amount    money.Money `json:"amount" binding:"required,gt=0"`
+}
+
+type CreditNoteAllocationResponse struct {
+	ID            int64       `json:"id"`
+	InvoiceID     int64       `json:"invoice_id"`
+	InvoiceNumber string      `json:"invoice_number"`
+	Amount        money.Money `json:"amount"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+type CreditNoteApplyResponse struct {
+	CreditNoteID int64                     `json:"credit_note_id"`
+	Applied      money.Money               `json:"applied"`
+	Balance      money.Money               `json:"balance"`
+	Status       string                    `json:"status"` // issued | partially_applied | applied
+	Allocations  []CreditNoteAllocationDTO `json:"allocations"`
 }
 
 type CreditNoteListDTO struct {
-	ID           int64     `json:"id"`
-	CreditNumber string    `json:"credit_number"`
-	ClientID     int64     `json:"client_id"`
-	ClientName   string    `json:"client_name"`
-	Type         string    `json:"type"`
-	Total        float64   `json:"total"`
-	Balance      float64   `json:"balance"`
-	Status       string    `json:"status"`
-	CreditDate   time.Time `json:"credit_date"`
+	ID           int64       `json:"id"`
+	CreditNumber string      `json:"credit_number"`
+	ClientID     int64       `json:"client_id"`
+	ClientName   string      `json:"client_name"`
+	Type         string      `json:"type"`
+	Total        money.Money `json:"total"`
+	Balance      money.Money `json:"balance"`
+	Status       string      `json:"status"`
+	CreditDate   time.Time   `json:"credit_date"`
 }
 
 type CreditNoteDetailDTO struct {
-	ID           int64     `json:"id"`
-	CreditNumber string    `json:"credit_number"`
-	ClientID     int64     `json:"client_id"`
-	ClientName   string    `json:"client_name"`
-	InvoiceID    *int64    `json:"invoice_id,omitempty"`
-	Type         string    `json:"type"`
-	Reason       *string   `json:"reason,omitempty"`
-	Subtotal     float64   `json:"subtotal"`
-	Tax          float64   `json:"tax"`
-	Total        float64   `json:"total"`
-	Balance      float64   `json:"balance"`
-	Status       string    `json:"status"`
-	CreditDate   time.Time `json:"credit_date"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           int64       `json:"id"`
+	CreditNumber string      `json:"credit_number"`
+	ClientID     int64       `json:"client_id"`
+	ClientName   string      `json:"client_name"`
+	InvoiceID    *int64      `json:"invoice_id,omitempty"`
+	Type         string      `json:"type"`
+	Reason       *string     `json:"reason,omitempty"`
+	Subtotal     money.Money `json:"subtotal"`
+	Tax          money.Money `json:"tax"`
+	Total        money.Money `json:"total"`
+	Balance      money.Money `json:"balance"`
+	Status       string      `json:"status"`
+	CreditDate   time.Time   `json:"credit_date"`
+	CreatedAt    time.Time   `json:"created_at"`
 }
 
 type CreditNoteItemResponse struct {
-	ID       int64   `json:"id"`
-	ItemID   int64   `json:"item_id"`
-	ItemName string  `json:"item_name"`
-	Qty      float64 `json:"qty"`
-	Rate     float64 `json:"rate"`
-	TaxRate  float64 `json:"tax_rate"`
-	Total    float64 `json:"total"`
+	ID       int64       `json:"id"`
+	ItemID   int64       `json:"item_id"`
+	ItemName string      `json:"item_name"`
+	Qty      float64     `json:"qty"`
+	Rate     money.Money `json:"rate"`
+	TaxRate  float64     `json:"tax_rate"`
+	Total    money.Money `json:"total"`
 }
 
 type CreditNoteDetailResponse struct {
@@ -86,14 +133,15 @@ type CreditNoteDetailResponse struct {
 	Type   string  `json:"type"`
 	Reason *string `json:"reason"`
 
-	Subtotal float64 `json:"subtotal"`
-	Tax      float64 `json:"tax"`
-	Total    float64 `json:"total"`
-	Balance  float64 `json:"balance"`
+	Subtotal money.Money `json:"subtotal"`
+	Tax      money.Money `json:"tax"`
+	Total    money.Money `json:"total"`
+	Balance  money.Money `json:"balance"`
 
 	Status     string `json:"status"`
 	CreditDate string `json:"credit_date"`
 	CreatedAt  string `json:"created_at"`
 
-	Items []CreditNoteItemResponse `json:"items"`
+	Items       []CreditNoteItemResponse       `json:"items"`
+	Allocations []CreditNoteAllocationResponse `json:"allocations"`
 }
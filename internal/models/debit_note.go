@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
+
+// DebitNoteItemDTO is an under-billed line. ItemID must be an item on the
+// original invoice.
+type DebitNoteItemDTO struct {
+	ItemID  int64       `json:"item_id" binding:"required"`
+	Qty     float64     `json:"qty" binding:"required,gt=0"`
+	Rate    money.Money `json:"rate"`
+	TaxRate float64     `json:"tax_rate"`
+}
+
+// DebitNoteRequestDTO raises the amount billed on an issued invoice. With
+// items the note is itemised; without, Amount is the taxable value of the
+// revision and TaxRate the rate it is taxed at.
+type DebitNoteRequestDTO struct {
+	InvoiceID int64              `json:"invoice_id" binding:"required"`
+	DebitDate string             `json:"debit_date"` // defaults to today
+	Reason    string             `json:"reason"`
+	Items     []DebitNoteItemDTO `json:"items" binding:"dive"`
+	Amount    money.Money        `json:"amount"`
+	TaxRate   float64            `json:"tax_rate"`
+}
+
+type DebitNoteItem struct {
+	ID          int64       `json:"id"`
+	ItemID      *int64      `json:"item_id"`
+	Description string      `json:"description"`
+	HSNCode     string      `json:"hsn_code"`
+	Qty         float64     `json:"qty"`
+	Rate        money.Money `json:"rate"`
+	TaxRate     float64     `json:"tax_rate"`
+	Total       money.Money `json:"total"`
+}
+
+type DebitNote struct {
+	ID            int64           `json:"id"`
+	CompanyID     int64           `json:"company_id"`
+	ClientID      int64           `json:"client_id"`
+	ClientName    string          `json:"client_name"`
+	InvoiceID     int64           `json:"invoice_id"`
+	InvoiceNumber string          `json:"invoice_number"`
+	DebitNumber   string          `json:"debit_number"`
+	DebitDate     time.Time       `json:"debit_date"`
+	Reason        string          `json:"reason"`
+	Subtotal      money.Money     `json:"subtotal"`
+	Tax           money.Money     `json:"tax"`
+	Total         money.Money     `json:"total"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Items         []DebitNoteItem `json:"items,omitempty"`
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
+
+// EInvoicePayload is the NIC e-invoice schema (INV-01, version 1.1).
+type EInvoicePayload struct {
+	Version    string            `json:"Version"`
+	TranDtls   EInvoiceTranDtls  `json:"TranDtls"`
+	DocDtls    EInvoiceDocDtls   `json:"DocDtls"`
+	SellerDtls EInvoiceParty     `json:"SellerDtls"`
+	BuyerDtls  EInvoiceParty     `json:"BuyerDtls"`
+	ShipDtls   *EInvoiceParty    `json:"ShipDtls,omitempty"`
+	ItemList   []EInvoiceItem    `json:"ItemList"`
+	ValDtls    EInvoiceValueDtls `json:"ValDtls"`
+	RefDtls    *EInvoiceRefDtls  `json:"RefDtls,omitempty"`
+}
+
+type EInvoiceTranDtls struct {
+	TaxSch      string `json:"TaxSch"` // GST
+	SupTyp      string `json:"SupTyp"` // B2B
+	RegRev      string `json:"RegRev"` // reverse charge, Y/N
+	IgstOnIntra string `json:"IgstOnIntra"`
+}
+
+type EInvoiceDocDtls struct {
+	Typ string `json:"Typ"` // INV | CRN | DBN
+	No  string `json:"No"`
+	Dt  string `json:"Dt"` // DD/MM/YYYY
+}
+
+type EInvoiceParty struct {
+	Gstin string `json:"Gstin"`
+	LglNm string `json:"LglNm"`
+	Pos   string `json:"Pos,omitempty"` // buyer only
+	Addr1 string `json:"Addr1"`
+	Addr2 string `json:"Addr2,omitempty"`
+	Loc   string `json:"Loc"`
+	Pin   int    `json:"Pin"`
+	Stcd  string `json:"Stcd"`
+	Ph    string `json:"Ph,omitempty"`
+	Em    string `json:"Em,omitempty"`
+}
+
+type EInvoiceItem struct {
+	SlNo       string      `json:"SlNo"`
+	PrdDesc    string      `json:"PrdDesc"`
+	IsServc    string      `json:"IsServc"` // Y/N
+	HsnCd      string      `json:"HsnCd"`
+	Qty        float64     `json:"Qty"`
+	Unit       string      `json:"Unit"`
+	UnitPrice  money.Money `json:"UnitPrice"`
+	TotAmt     money.Money `json:"TotAmt"`
+	Discount   money.Money `json:"Discount"`
+	AssAmt     money.Money `json:"AssAmt"`
+	GstRt      float64     `json:"GstRt"`
+	IgstAmt    money.Money `json:"IgstAmt"`
+	CgstAmt    money.Money `json:"CgstAmt"`
+	SgstAmt    money.Money `json:"SgstAmt"`
+	TotItemVal money.Money `json:"TotItemVal"`
+}
+
+type EInvoiceValueDtls struct {
+	AssVal    money.Money `json:"AssVal"`
+	CgstVal   money.Money `json:"CgstVal"`
+	SgstVal   money.Money `json:"SgstVal"`
+	IgstVal   money.Money `json:"IgstVal"`
+	TotInvVal money.Money `json:"TotInvVal"`
+}
+
+type EInvoiceRefDtls struct {
+	PrecDocDtls []EInvoicePrecDoc `json:"PrecDocDtls"`
+}
+
+// EInvoicePrecDoc points a credit/debit note at the invoice it amends.
+type EInvoicePrecDoc struct {
+	InvNo string `json:"InvNo"`
+	InvDt string `json:"InvDt"`
+}
+
+// IRNResult is what the IRP returns for a registered document.
+type IRNResult struct {
+	IRN          string    `json:"irn"`
+	AckNo        string    `json:"ack_no"`
+	AckDate      time.Time `json:"ack_date"`
+	SignedQRCode string    `json:"signed_qr_code"`
+}
+
+type CancelIRNRequestDTO struct {
+	// 1 duplicate, 2 data entry mistake, 3 order cancelled, 4 others
+	ReasonCode string `json:"reason_code" binding:"required"`
+	Remark     string `json:"remark"`
+}
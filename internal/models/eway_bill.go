@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
+
+type EWayBillRequestDTO struct {
+	// 1 road, 2 rail, 3 air, 4 ship; defaults to road
+	TransMode       string `json:"trans_mode"`
+	TransporterID   string `json:"transporter_id"`
+	TransporterName string `json:"transporter_name"`
+	VehicleNo       string `json:"vehicle_no"`
+	VehicleType     string `json:"vehicle_type"` // R regular, O over-dimensional cargo
+	DistanceKm      int    `json:"distance_km" binding:"required"`
+	TransDocNo      string `json:"trans_doc_no"`
+	TransDocDate    string `json:"trans_doc_date"` // YYYY-MM-DD
+}
+
+// EWayBillPayload is the NIC e-way bill generation JSON.
+type EWayBillPayload struct {
+	SupplyType       string         `json:"supplyType"`    // O outward
+	SubSupplyType    string         `json:"subSupplyType"` // 1 supply
+	DocType          string         `json:"docType"`       // INV
+	DocNo            string         `json:"docNo"`
+	DocDate          string         `json:"docDate"` // DD/MM/YYYY
+	FromGstin        string         `json:"fromGstin"`
+	FromTrdName      string         `json:"fromTrdName"`
+	FromAddr1        string         `json:"fromAddr1"`
+	FromAddr2        string         `json:"fromAddr2"`
+	FromPlace        string         `json:"fromPlace"`
+	FromPincode      int            `json:"fromPincode"`
+	ActFromStateCode int            `json:"actFromStateCode"`
+	FromStateCode    int            `json:"fromStateCode"`
+	ToGstin          string         `json:"toGstin"` // URP for unregistered buyers
+	ToTrdName        string         `json:"toTrdName"`
+	ToAddr1          string         `json:"toAddr1"`
+	ToAddr2          string         `json:"toAddr2"`
+	ToPlace          string         `json:"toPlace"`
+	ToPincode        int            `json:"toPincode"`
+	ActToStateCode   int            `json:"actToStateCode"`
+	ToStateCode      int            `json:"toStateCode"`
+	TransactionType  int            `json:"transactionType"` // 1 regular, 2 bill-to ship-to
+	TotalValue       money.Money    `json:"totalValue"`
+	CgstValue        money.Money    `json:"cgstValue"`
+	SgstValue        money.Money    `json:"sgstValue"`
+	IgstValue        money.Money    `json:"igstValue"`
+	CessValue        money.Money    `json:"cessValue"`
+	TotInvValue      money.Money    `json:"totInvValue"`
+	TransporterID    string         `json:"transporterId"`
+	TransporterName  string         `json:"transporterName"`
+	TransDocNo       string         `json:"transDocNo"`
+	TransMode        string         `json:"transMode"`
+	TransDistance    string         `json:"transDistance"`
+	TransDocDate     string         `json:"transDocDate"`
+	VehicleNo        string         `json:"vehicleNo"`
+	VehicleType      string         `json:"vehicleType"`
+	ItemList         []EWayBillItem `json:"itemList"`
+}
+
+type EWayBillItem struct {
+	ProductName   string      `json:"productName"`
+	ProductDesc   string      `json:"productDesc"`
+	HsnCode       int         `json:"hsnCode"`
+	Quantity      float64     `json:"quantity"`
+	QtyUnit       string      `json:"qtyUnit"`
+	CgstRate      float64     `json:"cgstRate"`
+	SgstRate      float64     `json:"sgstRate"`
+	IgstRate      float64     `json:"igstRate"`
+	CessRate      float64     `json:"cessRate"`
+	TaxableAmount money.Money `json:"taxableAmount"`
+}
+
+// EWayBillResult is what the e-way bill system returns.
+type EWayBillResult struct {
+	EWBNo      string     `json:"ewb_no"`
+	EWBDate    time.Time  `json:"ewb_date"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+type EWayBill struct {
+	ID              int             `json:"id"`
+	InvoiceID       int             `json:"invoice_id"`
+	EWBNo           string          `json:"ewb_no"`
+	EWBDate         time.Time       `json:"ewb_date"`
+	ValidUntil      *time.Time      `json:"valid_until"`
+	TransMode       string          `json:"trans_mode"`
+	TransporterID   string          `json:"transporter_id"`
+	TransporterName string          `json:"transporter_name"`
+	VehicleNo       string          `json:"vehicle_no"`
+	DistanceKm      int             `json:"distance_km"`
+	Payload         EWayBillPayload `json:"payload"`
+	CreatedAt       time.Time       `json:"created_at"`
+}
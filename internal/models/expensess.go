@@ -8,6 +8,15 @@ type Expensess struct {
 	Amount      float64 `json:"amount"`
 	Description string  `json:"description"`
 	Date        string  `json:"date"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+
+	// GST charged by the vendor; Amount stays the total paid.
+	VendorGSTIN  string  `json:"vendor_gstin"`
+	TaxableValue float64 `json:"taxable_value"`
+	CGST         float64 `json:"cgst"`
+	SGST         float64 `json:"sgst"`
+	IGST         float64 `json:"igst"`
+	ITCEligible  bool    `json:"itc_eligible"`
+
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
 }
@@ -0,0 +1,112 @@
+package models
+
+import "invo-server/internal/money"
+
+// GSTR1Return mirrors the JSON accepted by the GST offline tool.
+type GSTR1Return struct {
+	GSTIN   string      `json:"gstin"`
+	FP      string      `json:"fp"` // return period, MMYYYY
+	Version string      `json:"version"`
+	Hash    string      `json:"hash"`
+	B2B     []GSTR1B2B  `json:"b2b,omitempty"`
+	B2CL    []GSTR1B2CL `json:"b2cl,omitempty"`
+	B2CS    []GSTR1B2CS `json:"b2cs,omitempty"`
+	CDNR    []GSTR1CDNR `json:"cdnr,omitempty"`
+	HSN     *GSTR1HSN   `json:"hsn,omitempty"`
+}
+
+type GSTR1ItemDetail struct {
+	TaxableValue money.Money `json:"txval"`
+	Rate         float64     `json:"rt"`
+	IGST         money.Money `json:"iamt,omitempty"`
+	CGST         money.Money `json:"camt,omitempty"`
+	SGST         money.Money `json:"samt,omitempty"`
+	Cess         money.Money `json:"csamt"`
+}
+
+type GSTR1Item struct {
+	Num    int             `json:"num"`
+	Detail GSTR1ItemDetail `json:"itm_det"`
+}
+
+type GSTR1Invoice struct {
+	Number        string      `json:"inum"`
+	Date          string      `json:"idt"` // DD-MM-YYYY
+	Value         money.Money `json:"val"`
+	POS           string      `json:"pos,omitempty"`
+	ReverseCharge string      `json:"rchrg,omitempty"`
+	InvoiceType   string      `json:"inv_typ,omitempty"`
+	Items         []GSTR1Item `json:"itms"`
+}
+
+type GSTR1B2B struct {
+	CTIN     string         `json:"ctin"`
+	Invoices []GSTR1Invoice `json:"inv"`
+}
+
+type GSTR1B2CL struct {
+	POS      string         `json:"pos"`
+	Invoices []GSTR1Invoice `json:"inv"`
+}
+
+type GSTR1B2CS struct {
+	SupplyType   string      `json:"sply_ty"` // INTRA | INTER
+	Rate         float64     `json:"rt"`
+	Type         string      `json:"typ"`
+	POS          string      `json:"pos"`
+	TaxableValue money.Money `json:"txval"`
+	IGST         money.Money `json:"iamt,omitempty"`
+	CGST         money.Money `json:"camt,omitempty"`
+	SGST         money.Money `json:"samt,omitempty"`
+	Cess         money.Money `json:"csamt"`
+}
+
+type GSTR1Note struct {
+	Type          string      `json:"ntty"` // C = credit, D = debit
+	Number        string      `json:"nt_num"`
+	Date          string      `json:"nt_dt"`
+	Value         money.Money `json:"val"`
+	POS           string      `json:"pos"`
+	ReverseCharge string      `json:"rchrg"`
+	InvoiceType   string      `json:"inv_typ"`
+	Items         []GSTR1Item `json:"itms"`
+}
+
+type GSTR1CDNR struct {
+	CTIN  string      `json:"ctin"`
+	Notes []GSTR1Note `json:"nt"`
+}
+
+type GSTR1HSN struct {
+	Data []GSTR1HSNRow `json:"data"`
+}
+
+type GSTR1HSNRow struct {
+	Num          int         `json:"num"`
+	HSNCode      string      `json:"hsn_sc"`
+	Description  string      `json:"desc"`
+	UQC          string      `json:"uqc"`
+	Quantity     float64     `json:"qty"`
+	Value        money.Money `json:"val"`
+	TaxableValue money.Money `json:"txval"`
+	Rate         float64     `json:"rt"`
+	IGST         money.Money `json:"iamt"`
+	CGST         money.Money `json:"camt"`
+	SGST         money.Money `json:"samt"`
+	Cess         money.Money `json:"csamt"`
+}
+
+// GSTR1Issue is a problem found while building the return that the
+// accountant should fix (or at least review) before filing.
+type GSTR1Issue struct {
+	DocumentType   string `json:"document_type"` // company | invoice | credit_note
+	DocumentID     int64  `json:"document_id,omitempty"`
+	DocumentNumber string `json:"document_number,omitempty"`
+	Field          string `json:"field"` // gstin | hsn | place_of_supply
+	Message        string `json:"message"`
+}
+
+type GSTR1Export struct {
+	Return GSTR1Return  `json:"gstr1"`
+	Issues []GSTR1Issue `json:"issues"`
+}
@@ -1,13 +1,17 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
 
 type InvoiceItemRequest struct {
-	ItemID   int     `json:"item_id"`
-	Qty      int     `json:"qty"`
-	Rate     float64 `json:"rate"`
-	Discount float64 `json:"discount"`
-	TaxRate  float64 `json:"tax_rate"`
+	ItemID   int         `json:"item_id"`
+	Qty      int         `json:"qty"`
+	Rate     money.Money `json:"rate"`
+	Discount money.Money `json:"discount"`
+	TaxRate  float64     `json:"tax_rate"`
 }
 
 type InvoiceRequestDTO struct {
@@ -20,28 +24,28 @@ type InvoiceRequestDTO struct {
 }
 
 type Invoice struct {
-	ID              int     `json:"id"`
-	InvoiceNumber   string  `json:"invoice_number"`
-	ClientID        int     `json:"client_id"`
-	InvoiceDate     string  `json:"invoice_date"`
-	DueDate         string  `json:"due_date"`
-	Status          string  `json:"status"`
-	Subtotal        float64 `json:"subtotal"`
-	Tax             float64 `json:"tax"`
-	Total           float64 `json:"total"`
-	PaidAmount      float64 `json:"paid_amount"`
-	RemainingAmount float64 `json:"remaining_amount"`
-	CreatedAt       string  `json:"created_at"`
+	ID              int         `json:"id"`
+	InvoiceNumber   string      `json:"invoice_number"`
+	ClientID        int         `json:"client_id"`
+	InvoiceDate     string      `json:"invoice_date"`
+	DueDate         string      `json:"due_date"`
+	Status          string      `json:"status"`
+	Subtotal        money.Money `json:"subtotal"`
+	Tax             money.Money `json:"tax"`
+	Total           money.Money `json:"total"`
+	PaidAmount      money.Money `json:"paid_amount"`
+	RemainingAmount money.Money `json:"remaining_amount"`
+	CreatedAt       string      `json:"created_at"`
 }
 
 type InvoiceSummary struct {
-	ID              int64     `json:"id"`
-	InvoiceNumber   string    `json:"invoice_number"`
-	Total           float64   `json:"total"`
-	PaidAmount      float64   `json:"paid_amount"`
-	RemainingAmount float64   `json:"remaining_amount"`
-	InvoiceDate     time.Time `json:"invoice_date"`
-	Status          string    `json:"status"` // draft, sent, unpaid, partial, paid, overdue, cancelled
+	ID              int64       `json:"id"`
+	InvoiceNumber   string      `json:"invoice_number"`
+	Total           money.Money `json:"total"`
+	PaidAmount      money.Money `json:"paid_amount"`
+	RemainingAmount money.Money `json:"remaining_amount"`
+	InvoiceDate     time.Time   `json:"invoice_date"`
+	Status          string      `json:"status"` // draft, sent, unpaid, partial, paid, overdue, cancelled
 }
 
 type UpdateInvoiceRequestDTO struct {
@@ -50,3 +54,24 @@ type UpdateInvoiceRequestDTO struct {
 	DueDate     string               `json:"due_date"`
 	Items       []InvoiceItemRequest `json:"items"`
 }
+
+type CancelInvoiceRequestDTO struct {
+	Reason string `json:"reason"`
+
+	// Remove payment and credit note allocations from the invoice; the
+	// payments become unapplied client credit and the credit notes get
+	// their balance back.
+	UnapplyPayments bool `json:"unapply_payments"`
+}
+
+type IssueInvoiceRequestDTO struct {
+	// Use the client's unapplied payments (advances, overpayments) against
+	// the invoice; defaults to true.
+	ApplyCredit *bool `json:"apply_credit"`
+}
+
+type CreatedInvoice struct {
+	ID            int    `json:"invoice_id"`
+	InvoiceNumber string `json:"invoice_number"`
+	FinancialYear string `json:"financial_year"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Notification struct {
+	ID        int64      `json:"id"`
+	CompanyID int64      `json:"company_id"`
+	Type      string     `json:"type"` // low_stock
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	ItemID    *int64     `json:"item_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+type NumberingScheme struct {
+	DocumentType string `json:"document_type"` // invoice | credit_note | quote | receipt | purchase_order | refund | debit_note
+	Template     string `json:"template"`      // e.g. INV/{FY}/{SEQ:4}
+	ResetPolicy  string `json:"reset_policy"`  // never | yearly | fy | monthly
+	StartNumber  int    `json:"start_number"`
+	IsDefault    bool   `json:"is_default"` // no scheme saved; built-in one in use
+}
+
+type NumberingSchemeRequestDTO struct {
+	Template    string `json:"template" binding:"required"`
+	ResetPolicy string `json:"reset_policy" binding:"required"`
+
+	// First number to issue in each period, e.g. to continue a series
+	// from another system. Defaults to 1.
+	StartNumber int `json:"start_number"`
+}
@@ -1,30 +1,123 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
 
 type Payment struct {
-	ID            int64     `json:"id"`
-	CompanyID     int64     `json:"company_id"`
-	ClientID      int64     `json:"client_id"`
-	InvoiceID     int64     `json:"invoice_id"`
-	Amount        float64   `json:"amount"`
-	PaymentMethod string    `json:"payment_method"`
-	Reference     string    `json:"reference"`
-	Notes         string    `json:"notes"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID            int64                       `json:"id"`
+	CompanyID     int64                       `json:"company_id"`
+	ClientID      int64                       `json:"client_id"`
+	ClientName    string                      `json:"client_name"`
+	ReceiptNumber *string                     `json:"receipt_number"`
+	Amount        money.Money                 `json:"amount"`
+	PaymentMethod string                      `json:"payment_method"`
+	Reference     string                      `json:"reference"`
+	Notes         string                      `json:"notes"`
+	Status        string                      `json:"status"` // recorded | voided
+	VoidedAt      *time.Time                  `json:"voided_at,omitempty"`
+	VoidReason    string                      `json:"void_reason,omitempty"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	Allocations   []PaymentAllocationResponse `json:"allocations,omitempty"`
+}
+
+type PaymentAllocationResponse struct {
+	ID            int64       `json:"id"`
+	InvoiceID     int64       `json:"invoice_id"`
+	InvoiceNumber string      `json:"invoice_number"`
+	Amount        money.Money `json:"amount"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// PaymentFilter narrows the payment list; zero values don't filter.
+type PaymentFilter struct {
+	ClientID      int64
+	Status        string
+	PaymentMethod string
+	From, To      *time.Time
+}
+
+type VoidPaymentRequestDTO struct {
+	Reason string `json:"reason" binding:"required"`
 }
+
 type PaymentRequestDTO struct {
-	ClientID      int64   `json:"client_id" binding:"required"`
-	Amount        float64 `json:"amount" binding:"required,gt=0"`
-	PaymentMethod string  `json:"payment_method" binding:"required"`
-	Reference     string  `json:"reference"`
-	Notes         string  `json:"notes"`
+	ClientID      int64       `json:"client_id" binding:"required"`
+	Amount        money.Money `json:"amount" binding:"required,gt=0"`
+	PaymentMethod string      `json:"payment_method" binding:"required"`
+	Reference     string      `json:"reference"`
+	Notes         string      `json:"notes"`
 
 	// OPTIONAL: manual allocation (advanced users only)
-	Allocations []PaymentAllocationDTO `json:"allocations,omitempty"`
+	Allocations []PaymentAllocationDTO `json:"allocations,omitempty" binding:"dive"`
+
+	// Used when Allocations is empty; defaults to the company's strategy
+	AllocationStrategy string `json:"allocation_strategy"`
+
+	// Email the receipt PDF to the client once recorded
+	EmailReceipt bool `json:"email_receipt"`
 }
 
 type PaymentAllocationDTO struct {
-	InvoiceID int64   `json:"invoice_id" binding:"required"`
-	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	InvoiceID int64       `json:"invoice_id" binding:"required"`
+	Amount    money.Money `json:"amount" binding:"required,gt=0"`
+}
+
+// UnappliedPayment is a payment with credit not yet allocated to invoices.
+type UnappliedPayment struct {
+	PaymentID     int64       `json:"payment_id"`
+	ReceiptNumber *string     `json:"receipt_number"`
+	Amount        money.Money `json:"amount"`
+	Unapplied     money.Money `json:"unapplied"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// ClientCredit is what a client holds with the company: advances and
+// overpayments, plus credit note balances.
+type ClientCredit struct {
+	ClientID          int64              `json:"client_id"`
+	UnappliedPayments money.Money        `json:"unapplied_payments"`
+	CreditNoteBalance money.Money        `json:"credit_note_balance"`
+	Total             money.Money        `json:"total"`
+	Payments          []UnappliedPayment `json:"payments"`
+}
+
+// ApplyPaymentRequestDTO applies a payment's unapplied credit. Without
+// allocations it is spread over open invoices by allocation_strategy, or
+// the company's default strategy when that is empty.
+type ApplyPaymentRequestDTO struct {
+	Allocations        []PaymentAllocationDTO `json:"allocations,omitempty" binding:"dive"`
+	AllocationStrategy string                 `json:"allocation_strategy"`
+}
+
+// AllocationPreviewRequestDTO asks how a payment would be split without
+// recording it.
+type AllocationPreviewRequestDTO struct {
+	ClientID           int64       `json:"client_id" binding:"required"`
+	Amount             money.Money `json:"amount" binding:"required,gt=0"`
+	AllocationStrategy string      `json:"allocation_strategy"`
+}
+
+type AllocationPreviewLine struct {
+	InvoiceID     int64       `json:"invoice_id"`
+	InvoiceNumber string      `json:"invoice_number"`
+	InvoiceDate   time.Time   `json:"invoice_date"`
+	DueDate       *time.Time  `json:"due_date"`
+	Remaining     money.Money `json:"remaining_amount"`
+	Amount        money.Money `json:"amount"`
+}
+
+type AllocationPreview struct {
+	Strategy    string                  `json:"allocation_strategy"`
+	Amount      money.Money             `json:"amount"`
+	Allocated   money.Money             `json:"allocated"`
+	Unapplied   money.Money             `json:"unapplied"`
+	Allocations []AllocationPreviewLine `json:"allocations"`
+}
+
+// AllocationStrategyDTO is a company's default payment allocation strategy.
+type AllocationStrategyDTO struct {
+	Strategy string `json:"allocation_strategy" binding:"required"` // due_date | invoice_date | smallest_balance | proportional
 }
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
+
+type PurchaseBillItemRequest struct {
+	ItemID      int         `json:"item_id"` // 0 for lines not in the item catalogue
+	Description string      `json:"description"`
+	HSNCode     string      `json:"hsn_code"`
+	Qty         int         `json:"qty"`
+	Rate        money.Money `json:"rate"`
+	Discount    money.Money `json:"discount"`
+	TaxRate     float64     `json:"tax_rate"`
+}
+
+type PurchaseBillRequestDTO struct {
+	VendorID   int64                     `json:"vendor_id" binding:"required"`
+	BillNumber string                    `json:"bill_number" binding:"required"`
+	BillDate   string                    `json:"bill_date" binding:"required"` // YYYY-MM-DD
+	DueDate    string                    `json:"due_date" binding:"required"`
+	Notes      *string                   `json:"notes"`
+	Items      []PurchaseBillItemRequest `json:"items"`
+
+	// Whether the GST on this bill can be claimed as input tax credit;
+	// defaults to true.
+	ITCEligible *bool `json:"itc_eligible"`
+
+	// Set when the bill is for goods ordered on a purchase order. Those
+	// goods came into stock with the receipts, so the bill doesn't add them.
+	PurchaseOrderID *int64 `json:"purchase_order_id"`
+}
+
+type PurchaseBillItem struct {
+	ID          int64       `json:"id"`
+	ItemID      *int64      `json:"item_id"`
+	Description string      `json:"description"`
+	HSNCode     string      `json:"hsn_code"`
+	Qty         int         `json:"qty"`
+	Rate        money.Money `json:"rate"`
+	Discount    money.Money `json:"discount"`
+	TaxRate     float64     `json:"tax_rate"`
+	CGST        money.Money `json:"cgst"`
+	SGST        money.Money `json:"sgst"`
+	IGST        money.Money `json:"igst"`
+	Total       money.Money `json:"total"`
+}
+
+type PurchaseBill struct {
+	ID              int64              `json:"id"`
+	CompanyID       int64              `json:"company_id"`
+	VendorID        int64              `json:"vendor_id"`
+	VendorName      string             `json:"vendor_name"`
+	BillNumber      string             `json:"bill_number"`
+	BillDate        time.Time          `json:"bill_date"`
+	DueDate         time.Time          `json:"due_date"`
+	Subtotal        money.Money        `json:"subtotal"`
+	CGST            money.Money        `json:"cgst"`
+	SGST            money.Money        `json:"sgst"`
+	IGST            money.Money        `json:"igst"`
+	Tax             money.Money        `json:"tax"`
+	Total           money.Money        `json:"total"`
+	PaidAmount      money.Money        `json:"paid_amount"`
+	RemainingAmount money.Money        `json:"remaining_amount"`
+	ITCEligible     bool               `json:"itc_eligible"`
+	Status          string             `json:"status"` // unpaid | partial | paid
+	PurchaseOrderID *int64             `json:"purchase_order_id"`
+	Notes           string             `json:"notes"`
+	Items           []PurchaseBillItem `json:"items,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+}
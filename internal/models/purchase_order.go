@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
+
+type PurchaseOrderItemRequest struct {
+	ItemID      int         `json:"item_id"` // 0 for lines not in the item catalogue
+	Description string      `json:"description"`
+	Qty         int         `json:"qty"`
+	Rate        money.Money `json:"rate"`
+	Discount    money.Money `json:"discount"`
+	TaxRate     float64     `json:"tax_rate"`
+}
+
+type PurchaseOrderRequestDTO struct {
+	VendorID     int64                      `json:"vendor_id" binding:"required"`
+	PODate       string                     `json:"po_date" binding:"required"` // YYYY-MM-DD
+	ExpectedDate string                     `json:"expected_date"`              // optional, YYYY-MM-DD
+	Notes        *string                    `json:"notes"`
+	Items        []PurchaseOrderItemRequest `json:"items"`
+}
+
+type PurchaseOrderStatusRequestDTO struct {
+	Status string `json:"status" binding:"required"` // sent | closed
+}
+
+type ReceiveLineDTO struct {
+	POItemID int64 `json:"po_item_id" binding:"required"`
+	Qty      int   `json:"qty" binding:"required,gt=0"`
+}
+
+type ReceivePurchaseOrderDTO struct {
+	ReceivedDate string           `json:"received_date"` // defaults to today
+	Note         string           `json:"note"`
+	Lines        []ReceiveLineDTO `json:"lines" binding:"required,min=1,dive"`
+}
+
+type PurchaseOrderReceipt struct {
+	ID           int64     `json:"id"`
+	POItemID     int64     `json:"po_item_id"`
+	Qty          int       `json:"qty"`
+	ReceivedDate time.Time `json:"received_date"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PurchaseOrderItem struct {
+	ID          int64                  `json:"id"`
+	ItemID      *int64                 `json:"item_id"`
+	ItemName    string                 `json:"item_name"`
+	Description string                 `json:"description"`
+	Qty         int                    `json:"qty"`
+	ReceivedQty int                    `json:"received_qty"`
+	Rate        money.Money            `json:"rate"`
+	Discount    money.Money            `json:"discount"`
+	TaxRate     float64                `json:"tax_rate"`
+	Total       money.Money            `json:"total"`
+	Receipts    []PurchaseOrderReceipt `json:"receipts"`
+}
+
+type PurchaseOrder struct {
+	ID           int64               `json:"id"`
+	CompanyID    int64               `json:"company_id"`
+	VendorID     int64               `json:"vendor_id"`
+	VendorName   string              `json:"vendor_name"`
+	PONumber     string              `json:"po_number"`
+	PODate       time.Time           `json:"po_date"`
+	ExpectedDate *time.Time          `json:"expected_date"`
+	Subtotal     money.Money         `json:"subtotal"`
+	Tax          money.Money         `json:"tax"`
+	Total        money.Money         `json:"total"`
+	Status       string              `json:"status"` // draft | sent | partially_received | closed
+	Notes        string              `json:"notes"`
+	Items        []PurchaseOrderItem `json:"items,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
+
+type QuoteRequestDTO struct {
+	CompanyID  int                  `json:"company_id" binding:"required"`
+	ClientID   int                  `json:"client_id" binding:"required"`
+	QuoteDate  string               `json:"quote_date" binding:"required"`  // YYYY-MM-DD
+	ValidUntil string               `json:"valid_until" binding:"required"` // YYYY-MM-DD
+	Notes      *string              `json:"notes"`
+	Items      []InvoiceItemRequest `json:"items"`
+}
+
+type QuoteStatusRequestDTO struct {
+	Status string `json:"status" binding:"required"` // sent | accepted | rejected | expired
+}
+
+type ConvertQuoteRequestDTO struct {
+	InvoiceDate string `json:"invoice_date"` // defaults to today
+	DueDate     string `json:"due_date"`     // defaults to invoice date + 30 days
+}
+
+type QuoteListDTO struct {
+	ID          int64       `json:"id"`
+	QuoteNumber string      `json:"quote_number"`
+	ClientID    int         `json:"client_id"`
+	ClientName  string      `json:"client_name"`
+	QuoteDate   time.Time   `json:"quote_date"`
+	ValidUntil  time.Time   `json:"valid_until"`
+	Total       money.Money `json:"total"`
+	Status      string      `json:"status"` // draft, sent, accepted, rejected, expired
+	InvoiceID   *int        `json:"invoice_id"`
+}
+
+type QuoteItemResponse struct {
+	ID       int64       `json:"id"`
+	ItemID   *int        `json:"item_id"`
+	ItemName *string     `json:"item_name"`
+	Qty      int         `json:"qty"`
+	Rate     money.Money `json:"rate"`
+	Discount money.Money `json:"discount"`
+	TaxRate  float64     `json:"tax_rate"`
+	Total    money.Money `json:"total"`
+}
+
+type QuoteDetailResponse struct {
+	ID          int64  `json:"id"`
+	CompanyID   int    `json:"company_id"`
+	QuoteNumber string `json:"quote_number"`
+
+	ClientID   int    `json:"client_id"`
+	ClientName string `json:"client_name"`
+
+	QuoteDate  time.Time `json:"quote_date"`
+	ValidUntil time.Time `json:"valid_until"`
+
+	Subtotal money.Money `json:"subtotal"`
+	Tax      money.Money `json:"tax"`
+	Total    money.Money `json:"total"`
+
+	Status string  `json:"status"`
+	Notes  *string `json:"notes"`
+
+	InvoiceID     *int       `json:"invoice_id"`
+	InvoiceNumber *string    `json:"invoice_number"`
+	ConvertedAt   *time.Time `json:"converted_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	Items []QuoteItemResponse `json:"items"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type RecurringInvoiceRequestDTO struct {
+	CompanyID int                  `json:"company_id" binding:"required"`
+	ClientID  int                  `json:"client_id" binding:"required"`
+	Name      string               `json:"name"`
+	Frequency string               `json:"frequency" binding:"required"`  // weekly | monthly | quarterly | yearly
+	StartDate string               `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   *string              `json:"end_date"`
+	DueInDays int                  `json:"due_in_days"`
+	AutoIssue bool                 `json:"auto_issue"`
+	AutoEmail bool                 `json:"auto_email"`
+	Notes     *string              `json:"notes"`
+	Items     []InvoiceItemRequest `json:"items"`
+}
+
+type RecurringInvoiceProfile struct {
+	ID            int64      `json:"id"`
+	CompanyID     int        `json:"company_id"`
+	ClientID      int        `json:"client_id"`
+	ClientName    string     `json:"client_name"`
+	Name          *string    `json:"name"`
+	Frequency     string     `json:"frequency"`
+	StartDate     time.Time  `json:"start_date"`
+	EndDate       *time.Time `json:"end_date"`
+	NextRunDate   time.Time  `json:"next_run_date"`
+	RunsCompleted int        `json:"runs_completed"`
+	DueInDays     int        `json:"due_in_days"`
+	AutoIssue     bool       `json:"auto_issue"`
+	AutoEmail     bool       `json:"auto_email"`
+	Notes         *string    `json:"notes"`
+	Status        string     `json:"status"` // active | paused | completed
+	LastError     *string    `json:"last_error"`
+	LastRunAt     *time.Time `json:"last_run_at"`
+
+	Items []InvoiceItemRequest     `json:"items"`
+	Runs  []RecurringInvoiceRunDTO `json:"runs,omitempty"`
+}
+
+type RecurringInvoiceRunDTO struct {
+	RunDate       time.Time  `json:"run_date"`
+	InvoiceID     *int       `json:"invoice_id"`
+	InvoiceNumber *string    `json:"invoice_number"`
+	EmailedAt     *time.Time `json:"emailed_at"`
+}
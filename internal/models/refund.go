@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
+
+// RefundRequestDTO pays money back to a client. Exactly one of
+// CreditNoteID and PaymentID names where the refund is drawn from.
+type RefundRequestDTO struct {
+	CreditNoteID *int64      `json:"credit_note_id"`
+	PaymentID    *int64      `json:"payment_id"`
+	Amount       money.Money `json:"amount" binding:"required,gt=0"`
+	RefundDate   string      `json:"refund_date"` // defaults to today
+	RefundMethod string      `json:"refund_method" binding:"required"`
+	Reference    string      `json:"reference"`
+	Notes        string      `json:"notes"`
+}
+
+type Refund struct {
+	ID            int64       `json:"id"`
+	CompanyID     int64       `json:"company_id"`
+	ClientID      int64       `json:"client_id"`
+	ClientName    string      `json:"client_name"`
+	RefundNumber  string      `json:"refund_number"`
+	CreditNoteID  *int64      `json:"credit_note_id"`
+	CreditNumber  *string     `json:"credit_number"`
+	PaymentID     *int64      `json:"payment_id"`
+	ReceiptNumber *string     `json:"receipt_number"`
+	Amount        money.Money `json:"amount"`
+	RefundDate    time.Time   `json:"refund_date"`
+	RefundMethod  string      `json:"refund_method"`
+	Reference     string      `json:"reference"`
+	Notes         string      `json:"notes"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
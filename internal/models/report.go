@@ -0,0 +1,76 @@
+package models
+
+import "invo-server/internal/money"
+
+// HSNSummaryRow is one HSN/SAC code at one tax rate, as reported in the
+// HSN table of GSTR-1.
+type HSNSummaryRow struct {
+	HSNCode      string      `json:"hsn_code"`
+	UQC          string      `json:"uqc"` // unit of the item, e.g. NOS
+	TaxRate      float64     `json:"tax_rate"`
+	Quantity     int         `json:"quantity"`
+	TaxableValue money.Money `json:"taxable_value"`
+	CGST         money.Money `json:"cgst"`
+	SGST         money.Money `json:"sgst"`
+	IGST         money.Money `json:"igst"`
+	TotalTax     money.Money `json:"total_tax"`
+	TotalValue   money.Money `json:"total_value"`
+}
+
+type HSNSummaryReport struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Rows   []HSNSummaryRow `json:"data"`
+	Totals HSNSummaryRow   `json:"totals"`
+}
+
+// GSTR3BAmounts is one row of the 3B tables.
+type GSTR3BAmounts struct {
+	TaxableValue money.Money `json:"taxable_value"`
+	IGST         money.Money `json:"igst"`
+	CGST         money.Money `json:"cgst"`
+	SGST         money.Money `json:"sgst"`
+	Cess         money.Money `json:"cess"`
+}
+
+type GSTR3BReport struct {
+	GSTIN  string `json:"gstin"`
+	Period string `json:"period"` // YYYY-MM
+
+	// 3.1 Outward supplies, plus debit notes and net of credit notes
+	OutwardTaxable  GSTR3BAmounts `json:"outward_taxable"`   // 3.1(a)
+	OutwardNilRated GSTR3BAmounts `json:"outward_nil_rated"` // 3.1(c)
+
+	// How 3.1 was arrived at
+	Invoices    GSTR3BAmounts `json:"invoices"`
+	DebitNotes  GSTR3BAmounts `json:"debit_notes"`
+	CreditNotes GSTR3BAmounts `json:"credit_notes"`
+
+	// 4. Input tax credit
+	EligibleITC   GSTR3BAmounts `json:"eligible_itc"`   // 4(A)(5)
+	IneligibleITC GSTR3BAmounts `json:"ineligible_itc"` // 4(D)
+
+	// 6.1 Payment of tax
+	Liability      GSTR3BAmounts `json:"liability"`
+	PaidThroughITC GSTR3BAmounts `json:"paid_through_itc"`
+	PayableInCash  GSTR3BAmounts `json:"payable_in_cash"`
+}
+
+// ReorderRow is an item whose stock is below its low-stock threshold.
+type ReorderRow struct {
+	ItemID        int64    `json:"item_id"`
+	Name          string   `json:"name"`
+	SKU           string   `json:"sku"`
+	Unit          string   `json:"unit"`
+	Quantity      int      `json:"quantity"`
+	LowStockAlert int      `json:"low_stock_alert"`
+	Shortfall     int      `json:"shortfall"` // low_stock_alert - quantity
+	SoldQty       int      `json:"sold_qty"`  // over the lookback window
+	AvgDailySales float64  `json:"avg_daily_sales"`
+	DaysOfStock   *float64 `json:"days_of_stock"` // nil when nothing sold
+}
+
+type ReorderReport struct {
+	Days int          `json:"days"` // lookback window for sales
+	Rows []ReorderRow `json:"data"`
+}
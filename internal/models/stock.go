@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type StockMovement struct {
+	ID           int64     `json:"id"`
+	ItemID       int64     `json:"item_id"`
+	SourceType   string    `json:"source_type"` // OPENING | INVOICE | CREDIT_NOTE | ADJUSTMENT | PURCHASE
+	SourceID     *int64    `json:"source_id"`
+	Quantity     int       `json:"quantity"` // + inward, - outward
+	BalanceAfter int       `json:"balance_after"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type StockAdjustmentRequestDTO struct {
+	// Signed change: positive adds stock, negative removes it
+	Quantity int    `json:"quantity" binding:"required"`
+	Note     string `json:"note" binding:"required"`
+}
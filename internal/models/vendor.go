@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"invo-server/internal/money"
+)
+
+type Vendor struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	CompanyID int       `json:"company_id" binding:"required"`
+	Name      string    `json:"name" binding:"required"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	GSTIN     string    `json:"gstin"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type VendorLedgerEntry struct {
+	ID          int64       `json:"id"`
+	CompanyID   int64       `json:"company_id"`
+	VendorID    int64       `json:"vendor_id"`
+	VendorName  string      `json:"vendor_name"`
+	SourceType  string      `json:"source_type"` // BILL | PAYMENT
+	SourceID    int64       `json:"source_id"`
+	Debit       money.Money `json:"debit"`
+	Credit      money.Money `json:"credit"`
+	Balance     money.Money `json:"balance"` // amount payable to the vendor
+	Description string      `json:"description"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type VendorPaymentRequestDTO struct {
+	VendorID      int64       `json:"vendor_id" binding:"required"`
+	Amount        money.Money `json:"amount" binding:"required,gt=0"`
+	PaymentDate   string      `json:"payment_date"` // YYYY-MM-DD, defaults to today
+	PaymentMethod string      `json:"payment_method" binding:"required"`
+	Reference     string      `json:"reference"`
+	Notes         string      `json:"notes"`
+
+	// OPTIONAL: pay specific bills; otherwise oldest bills are paid first
+	Allocations []VendorPaymentAllocationDTO `json:"allocations,omitempty" binding:"dive"`
+}
+
+type VendorPaymentAllocationDTO struct {
+	BillID int64       `json:"bill_id" binding:"required"`
+	Amount money.Money `json:"amount" binding:"required,gt=0"`
+}
@@ -0,0 +1,214 @@
+// Package money holds rupee amounts as a whole number of paise so sums,
+// balances and allocations are exact.
+//
+// Rounding rules:
+//
+//   - Amounts are rounded to the paisa, half away from zero: 0.005 becomes
+//     0.01 and -0.005 becomes -0.01. This applies when parsing input with
+//     more than two decimals and to every product (rate × qty, amount ×
+//     tax rate).
+//   - Products are worked out exactly on the decimal values and rounded
+//     once, so 1.15 × 3 is 3.45 and never 3.4499999.
+//   - Tax is computed and rounded per line; invoice totals are the sum of
+//     the rounded line amounts, so the lines always add up to the total.
+//   - When an amount is halved (CGST/SGST) the first half is rounded
+//     toward zero and the second half takes the odd paisa.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in paise.
+type Money int64
+
+const (
+	Paisa Money = 1
+	Rupee Money = 100
+)
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// FromPaise wraps a whole number of paise.
+func FromPaise(p int64) Money {
+	return Money(p)
+}
+
+// FromFloat converts a float amount, rounding to the paisa. The float is
+// read as its shortest decimal form, so 1.005 becomes 1.01 rather than
+// 1.00.
+func FromFloat(f float64) Money {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	m, _ := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	return m
+}
+
+// Parse reads a decimal string such as "1234.5" or "-0.05".
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Contains(s, "/") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	return fromRat(r.Mul(r, big.NewRat(100, 1)))
+}
+
+// fromRat rounds a value already expressed in paise, half away from zero.
+func fromRat(r *big.Rat) (Money, error) {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+
+	neg := num.Sign() < 0
+	num.Abs(num)
+
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: out of range", ErrInvalidAmount)
+	}
+
+	p := q.Int64()
+	if neg {
+		p = -p
+	}
+	return Money(p), nil
+}
+
+func ratOf(f float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+// Paise returns the amount as a whole number of paise.
+func (m Money) Paise() int64 {
+	return int64(m)
+}
+
+// Float64 is for display and for APIs that need a plain number; don't do
+// arithmetic on the result.
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String formats the amount with exactly two decimals: "1234.50".
+func (m Money) String() string {
+	p := int64(m)
+	sign := ""
+	if p < 0 {
+		sign = "-"
+		p = -p
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, p/100, p%100)
+}
+
+// Mul multiplies by a quantity, rounding once to the paisa.
+func (m Money) Mul(qty float64) Money {
+	r := new(big.Rat).SetInt64(int64(m))
+	out, _ := fromRat(r.Mul(r, ratOf(qty)))
+	return out
+}
+
+// Percent returns rate% of the amount, rounded to the paisa; 18 means 18%.
+func (m Money) Percent(rate float64) Money {
+	r := new(big.Rat).SetInt64(int64(m))
+	r.Mul(r, ratOf(rate))
+	out, _ := fromRat(r.Quo(r, big.NewRat(100, 1)))
+	return out
+}
+
+// Halve splits the amount into two parts that add back up exactly; the
+// second part gets the odd paisa.
+func (m Money) Halve() (first, second Money) {
+	first = m / 2
+	return first, m - first
+}
+
+// Min returns the smaller of a and b.
+func Min(a, b Money) Money {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Sum adds amounts.
+func Sum(amounts ...Money) Money {
+	var total Money
+	for _, a := range amounts {
+		total += a
+	}
+	return total
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimals.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string. The digits are
+// read as written, never through a float.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" {
+		*m = 0
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Scan reads NUMERIC columns, which the driver hands over as text.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = Money(v) * Rupee
+	case float64:
+		*m = FromFloat(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value stores the amount as an exact decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
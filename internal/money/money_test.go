@@ -0,0 +1,246 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "1234.5", want: 123450},
+		{in: " 7.1 ", want: 710},
+		{in: "10", want: 1000},
+		{in: "0.005", want: 1},
+		{in: "-0.005", want: -1},
+		{in: "0.0049", want: 0},
+		{in: "-0.0049", want: 0},
+		{in: "1.015", want: 102},
+		{in: "2.675", want: 268},
+		{in: "-2.675", want: -268},
+		{in: "2.674999", want: 267},
+		{in: "", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1/3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %d, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want Money
+	}{
+		{in: 1.005, want: 101},
+		{in: -1.005, want: -101},
+		{in: 2.675, want: 268},
+		{in: 1.004999, want: 100},
+		{in: 0.1 + 0.2, want: 30},
+		{in: 0.015, want: 2},
+		{in: -0.015, want: -2},
+		{in: math.NaN(), want: 0},
+		{in: math.Inf(1), want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := FromFloat(tt.in); got != tt.want {
+			t.Errorf("FromFloat(%v) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		m    Money
+		qty  float64
+		want Money
+	}{
+		{m: 115, qty: 3, want: 345},
+		{m: 101, qty: 0.5, want: 51},
+		{m: -101, qty: 0.5, want: -51},
+		{m: 333, qty: 1.5, want: 500},
+		{m: 100, qty: 0.333, want: 33},
+		{m: 999, qty: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Mul(tt.qty); got != tt.want {
+			t.Errorf("Money(%d).Mul(%v) = %d, want %d", tt.m, tt.qty, got, tt.want)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		m    Money
+		rate float64
+		want Money
+	}{
+		{m: 1000, rate: 18, want: 180},
+		{m: 5, rate: 18, want: 1},
+		{m: 2, rate: 25, want: 1},
+		{m: -2, rate: 25, want: -1},
+		{m: 12345, rate: 12.5, want: 1543},
+		{m: 3015, rate: 18, want: 543},
+		{m: 100, rate: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Percent(tt.rate); got != tt.want {
+			t.Errorf("Money(%d).Percent(%v) = %d, want %d", tt.m, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestHalve(t *testing.T) {
+	tests := []struct {
+		m             Money
+		first, second Money
+	}{
+		{m: 100, first: 50, second: 50},
+		{m: 101, first: 50, second: 51},
+		{m: 1, first: 0, second: 1},
+		{m: -101, first: -50, second: -51},
+		{m: 0, first: 0, second: 0},
+	}
+
+	for _, tt := range tests {
+		first, second := tt.m.Halve()
+		if first != tt.first || second != tt.second {
+			t.Errorf("Money(%d).Halve() = %d, %d, want %d, %d",
+				tt.m, first, second, tt.first, tt.second)
+		}
+		if first+second != tt.m {
+			t.Errorf("Money(%d).Halve() parts add up to %d", tt.m, first+second)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{m: 123450, want: "1234.50"},
+		{m: 5, want: "0.05"},
+		{m: -5, want: "-0.05"},
+		{m: 0, want: "0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	got, err := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{Amount: 123450})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":1234.50}`; string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: `12.5`, want: 1250},
+		{in: `"12.345"`, want: 1235},
+		{in: `-0.005`, want: -1},
+		{in: `"1234.50"`, want: 123450},
+		{in: `null`, want: 0},
+		{in: `"abc"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %d, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    Money
+		wantErr bool
+	}{
+		{src: []byte("1234.5600"), want: 123456},
+		{src: []byte("-0.01"), want: -1},
+		{src: "99.995", want: 10000},
+		{src: "0", want: 0},
+		{src: nil, want: 0},
+		{src: int64(5), want: 500},
+		{src: 1.005, want: 101},
+		{src: []byte("x"), wantErr: true},
+		{src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got := Money(-999)
+		err := got.Scan(tt.src)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Scan(%#v) = %d, want error", tt.src, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Scan(%#v) error: %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Scan(%#v) = %d, want %d", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	v, err := Money(123456).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "1234.56" {
+		t.Errorf("Value() = %v, want 1234.56", v)
+	}
+}
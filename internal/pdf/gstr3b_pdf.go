@@ -0,0 +1,156 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"invo-server/internal/money"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+type GSTR3BPDFData struct {
+	CompanyName string
+	GSTIN       string
+	Period      string // YYYY-MM
+	Sections    []GSTR3BSection
+}
+
+type GSTR3BSection struct {
+	Title string
+	Rows  []GSTR3BRow
+}
+
+type GSTR3BRow struct {
+	Label        string
+	TaxableValue money.Money
+	IGST         money.Money
+	CGST         money.Money
+	SGST         money.Money
+	Cess         money.Money
+}
+
+// GenerateGSTR3BPDF renders the 3B summary in the same black-header style
+// as the tax invoice.
+func GenerateGSTR3BPDF(data GSTR3BPDFData) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(true, 15)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "I", 7)
+		pdf.SetTextColor(150, 150, 150)
+		pdf.CellFormat(pageW, 5,
+			fmt.Sprintf("Page %d — %s", pdf.PageNo(), data.CompanyName),
+			"", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+	y := marginT
+
+	// Header
+	pdf.SetFillColor(20, 20, 20)
+	pdf.Rect(marginL, y, pageW, 10, "F")
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(marginL, y)
+	pdf.CellFormat(pageW, 10, "GSTR-3B SUMMARY", "", 0, "C", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	y += 10
+
+	// Company + period
+	period := data.Period
+	if t, err := time.Parse("2006-01", data.Period); err == nil {
+		period = t.Format("January 2006")
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetXY(marginL+2, y+3)
+	pdf.Cell(pageW/2-4, 5, data.CompanyName)
+
+	details := [][2]string{{"GSTIN", data.GSTIN}, {"Period", period}}
+	for i, d := range details {
+		pdf.SetFont("Helvetica", "", 7.5)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.SetXY(marginL+pageW/2+3, y+3+float64(i)*6)
+		pdf.Cell(22, 4, d[0]+":")
+		pdf.SetFont("Helvetica", "B", 7.5)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Cell(55, 4, d[1])
+	}
+	y += 16
+	pdf.Line(marginL, y, marginL+pageW, y)
+
+	// Sections
+	wDesc := 60.0
+	wAmt := 26.0
+	rowH := 6.5
+
+	for _, section := range data.Sections {
+		y += 4
+		if y+7+rowH*float64(len(section.Rows)+1) > 270 {
+			pdf.AddPage()
+			y = marginT
+		}
+
+		pdf.SetFillColor(240, 240, 240)
+		pdf.Rect(marginL, y, pageW, 6, "F")
+		pdf.SetFont("Helvetica", "B", 7.5)
+		pdf.SetXY(marginL+2, y+1)
+		pdf.Cell(pageW-4, 4, section.Title)
+		y += 6
+
+		pdf.SetFillColor(20, 20, 20)
+		pdf.SetTextColor(255, 255, 255)
+		pdf.SetFont("Helvetica", "B", 7)
+		pdf.SetXY(marginL, y)
+		pdf.CellFormat(wDesc, rowH, "DESCRIPTION", "R", 0, "L", true, 0, "")
+		for i, h := range []string{"TAXABLE VALUE", "IGST", "CGST", "SGST", "CESS"} {
+			border, ln := "R", 0
+			if i == 4 {
+				border, ln = "", 1
+			}
+			pdf.CellFormat(wAmt, rowH, h, border, ln, "R", true, 0, "")
+		}
+		pdf.SetTextColor(0, 0, 0)
+
+		pdf.SetFont("Helvetica", "", 8)
+		for i, r := range section.Rows {
+			rowY := pdf.GetY()
+			if i%2 == 1 {
+				pdf.SetFillColor(248, 248, 248)
+				pdf.Rect(marginL, rowY, pageW, rowH, "F")
+			}
+			pdf.SetXY(marginL, rowY)
+			pdf.CellFormat(wDesc, rowH, r.Label, "R", 0, "L", false, 0, "")
+			pdf.CellFormat(wAmt, rowH, r.TaxableValue.String(), "R", 0, "R", false, 0, "")
+			pdf.CellFormat(wAmt, rowH, r.IGST.String(), "R", 0, "R", false, 0, "")
+			pdf.CellFormat(wAmt, rowH, r.CGST.String(), "R", 0, "R", false, 0, "")
+			pdf.CellFormat(wAmt, rowH, r.SGST.String(), "R", 0, "R", false, 0, "")
+			pdf.CellFormat(wAmt, rowH, r.Cess.String(), "", 1, "R", false, 0, "")
+		}
+
+		y = pdf.GetY()
+		pdf.Line(marginL, y, marginL+pageW, y)
+	}
+
+	// Note
+	pdf.SetFont("Helvetica", "I", 6.5)
+	pdf.SetTextColor(110, 110, 110)
+	pdf.SetXY(marginL+2, y+4)
+	pdf.MultiCell(pageW-4, 3.5,
+		"Computed from issued invoices, debit notes, credit notes and expenses recorded for the period. "+
+			"Verify against GSTR-2B before claiming input tax credit. "+
+			"Generated on "+time.Now().Format("02-01-2006")+".",
+		"", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -4,14 +4,20 @@ import (
 	"bytes"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 
+	"invo-server/internal/money"
+	utils "invo-server/internal/util"
+
 	"github.com/jung-kurt/gofpdf"
+	"github.com/skip2/go-qrcode"
 )
 
 type TallyInvoiceGenerator struct {
 	pdf      *gofpdf.Fpdf
 	data     InvoicePDFData
+	labels   DocumentLabels
 	copyType string
 }
 
@@ -22,10 +28,38 @@ func NewTallyInvoiceGenerator(data InvoicePDFData, copyType string) *TallyInvoic
 	return &TallyInvoiceGenerator{
 		pdf:      pdf,
 		data:     data,
+		labels:   withDefaultLabels(data.Labels),
 		copyType: strings.ToUpper(copyType),
 	}
 }
 
+func withDefaultLabels(l DocumentLabels) DocumentLabels {
+	if l.Title == "" {
+		l.Title = "TAX INVOICE"
+	}
+	if l.NumberLabel == "" {
+		l.NumberLabel = "Invoice No."
+	}
+	if l.DueLabel == "" {
+		l.DueLabel = "Due Date"
+	}
+	if l.SummaryLabel == "" {
+		l.SummaryLabel = "INVOICE SUMMARY"
+	}
+	if l.PartyLabel == "" {
+		l.PartyLabel = "BILL TO"
+	}
+	if l.ShipLabel == "" {
+		l.ShipLabel = "SHIP TO"
+	}
+	if l.Declaration == "" {
+		l.Declaration = "Declaration: We declare that this invoice shows the actual price of the goods " +
+			"described and that all particulars are true and correct. " +
+			"Goods once sold will not be taken back."
+	}
+	return l
+}
+
 const (
 	marginL = 10.0
 	marginT = 10.0
@@ -34,7 +68,7 @@ const (
 )
 
 // ─── Amount To Words ─────────────────────────────────────────────────────────
-func AmountToWords(n float64) string {
+func AmountToWords(amount money.Money) string {
 	ones := []string{"", "One", "Two", "Three", "Four", "Five", "Six", "Seven",
 		"Eight", "Nine", "Ten", "Eleven", "Twelve", "Thirteen", "Fourteen",
 		"Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen"}
@@ -61,8 +95,12 @@ func AmountToWords(n float64) string {
 		}
 	}
 
-	intPart := int(math.Abs(n))
-	fracPart := int(math.Round((math.Abs(n) - float64(intPart)) * 100))
+	paise := amount.Paise()
+	if paise < 0 {
+		paise = -paise
+	}
+	intPart := int(paise / 100)
+	fracPart := int(paise % 100)
 	if intPart == 0 {
 		return "Zero Rupees Only"
 	}
@@ -85,7 +123,7 @@ func (g *TallyInvoiceGenerator) Generate() ([]byte, error) {
 			g.pdf.Rect(marginL, marginT, pageW, 8, "F")
 			g.pdf.SetXY(marginL, marginT)
 			g.pdf.CellFormat(pageW, 8,
-				fmt.Sprintf("TAX INVOICE - %s (Continued...)", g.data.Invoice.InvoiceNumber),
+				fmt.Sprintf("%s - %s (Continued...)", g.labels.Title, g.data.Invoice.InvoiceNumber),
 				"", 1, "C", false, 0, "")
 			g.pdf.SetTextColor(0, 0, 0)
 		}
@@ -106,9 +144,11 @@ func (g *TallyInvoiceGenerator) Generate() ([]byte, error) {
 
 	y := marginT
 	y = g.drawHeader(y)
+	y = g.drawEInvoice(y)
 	y = g.drawCompanyAndInvoice(y)
 	y = g.drawPartySection(y)
 	y = g.drawItemsTable(y)
+	y = g.drawHSNSummary(y)
 	y = g.drawTotalsSection(y)
 	g.drawFooter(y)
 
@@ -130,23 +170,93 @@ func (g *TallyInvoiceGenerator) drawHeader(y float64) float64 {
 	pdf.SetFont("Helvetica", "B", 12)
 	pdf.SetTextColor(255, 255, 255)
 	pdf.SetXY(marginL, y)
-	pdf.CellFormat(pageW, h, "TAX INVOICE", "", 0, "C", false, 0, "")
+	pdf.CellFormat(pageW, h, g.labels.Title, "", 0, "C", false, 0, "")
 
-	pdf.SetFont("Helvetica", "", 7)
-	pdf.SetTextColor(180, 180, 180)
-	pdf.SetXY(marginL, y+1)
-	pdf.CellFormat(pageW-3, h-2, g.copyType+" COPY", "", 0, "R", false, 0, "")
+	if g.copyType != "" {
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.SetTextColor(180, 180, 180)
+		pdf.SetXY(marginL, y+1)
+		pdf.CellFormat(pageW-3, h-2, g.copyType+" COPY", "", 0, "R", false, 0, "")
+	}
 
 	pdf.SetTextColor(0, 0, 0)
 	return y + h
 }
 
+// ─── E-Invoice (IRN + signed QR) ─────────────────────────────────────────────
+func (g *TallyInvoiceGenerator) drawEInvoice(y float64) float64 {
+	inv := g.data.Invoice
+	if inv.IRN == "" {
+		return y
+	}
+
+	pdf := g.pdf
+	qrSize := 28.0
+	h := qrSize + 4
+
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetXY(marginL+2, y+3)
+	pdf.Cell(60, 4, "e-Invoice")
+
+	rows := [][2]string{
+		{"IRN", inv.IRN},
+		{"Ack No.", inv.AckNo},
+		{"Ack Date", inv.AckDate},
+	}
+	for i, r := range rows {
+		g.labelValue(marginL+2, y+9+float64(i)*6, r[0], r[1])
+	}
+
+	// Signed QR from the IRP, scanned by tax officers to verify the IRN
+	if inv.SignedQR != "" {
+		png, err := qrcode.Encode(inv.SignedQR, qrcode.Low, 512)
+		if err != nil {
+			pdf.SetError(fmt.Errorf("irn qr: %w", err))
+			return y + h
+		}
+		opts := gofpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader("irn-qr", opts, bytes.NewReader(png))
+		pdf.ImageOptions("irn-qr", marginL+pageW-qrSize-2, y+2, qrSize, qrSize, false, opts, 0, "")
+	}
+
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Line(marginL, y+h, marginL+pageW, y+h)
+
+	return y + h
+}
+
 // ─── Company + Invoice Details ───────────────────────────────────────────────
 func (g *TallyInvoiceGenerator) drawCompanyAndInvoice(y float64) float64 {
 	pdf := g.pdf
-	h := 32.0
 	mid := marginL + pageW/2
 
+	details := [][2]string{
+		{g.labels.NumberLabel, g.data.Invoice.InvoiceNumber},
+		{"Date", g.data.Invoice.InvoiceDate},
+	}
+	if g.data.Invoice.DueDate != "" {
+		details = append(details, [2]string{g.labels.DueLabel, g.data.Invoice.DueDate})
+	}
+	if g.data.Invoice.AgainstNumber != "" {
+		details = append(details, [2]string{"Against Inv.", g.data.Invoice.AgainstNumber})
+		if g.data.Invoice.AgainstDate != "" {
+			details = append(details, [2]string{"Invoice Date", g.data.Invoice.AgainstDate})
+		}
+	}
+	if g.data.Invoice.PlaceOfSupply != "" {
+		details = append(details, [2]string{"Place of Supply", g.data.Invoice.PlaceOfSupply})
+	}
+	if g.data.Invoice.PONumber != "" {
+		details = append(details, [2]string{"PO Number", g.data.Invoice.PONumber})
+	}
+	if g.data.Invoice.EWayBillNo != "" {
+		details = append(details, [2]string{"E-Way Bill No.", g.data.Invoice.EWayBillNo})
+		details = append(details, [2]string{"E-Way Bill Date", g.data.Invoice.EWayBillDate})
+	}
+
+	rowH := 6.0
+	h := math.Max(32, 6+float64(len(details))*rowH)
+
 	// Company name
 	pdf.SetFont("Helvetica", "B", 10)
 	pdf.SetXY(marginL+2, y+3)
@@ -178,11 +288,8 @@ func (g *TallyInvoiceGenerator) drawCompanyAndInvoice(y float64) float64 {
 	pdf.SetDrawColor(0, 0, 0)
 
 	// Invoice details
-	g.labelValue(mid+3, y+4, "Invoice No.", g.data.Invoice.InvoiceNumber)
-	g.labelValue(mid+3, y+11, "Date", g.data.Invoice.InvoiceDate)
-	g.labelValue(mid+3, y+18, "Due Date", g.data.Invoice.DueDate)
-	if g.data.Invoice.PONumber != "" {
-		g.labelValue(mid+3, y+25, "PO Number", g.data.Invoice.PONumber)
+	for i, d := range details {
+		g.labelValue(mid+3, y+4+float64(i)*rowH, d[0], d[1])
 	}
 
 	// Bottom border
@@ -203,7 +310,7 @@ func (g *TallyInvoiceGenerator) drawPartySection(y float64) float64 {
 	pdf.Rect(marginL, y, pageW/2, 6, "F")
 	pdf.SetFont("Helvetica", "B", 7.5)
 	pdf.SetXY(marginL+2, y+1)
-	pdf.Cell(40, 4, "BILL TO")
+	pdf.Cell(40, 4, g.labels.PartyLabel)
 
 	pdf.SetFont("Helvetica", "B", 8.5)
 	pdf.SetXY(marginL+2, y+8)
@@ -231,7 +338,7 @@ func (g *TallyInvoiceGenerator) drawPartySection(y float64) float64 {
 	pdf.Rect(mid, y, pageW/2, 6, "F")
 	pdf.SetFont("Helvetica", "B", 7.5)
 	pdf.SetXY(mid+3, y+1)
-	pdf.Cell(40, 4, "SHIP TO")
+	pdf.Cell(40, 4, g.labels.ShipLabel)
 
 	if g.data.ClientShipping != nil {
 		pdf.SetFont("Helvetica", "B", 8.5)
@@ -316,9 +423,9 @@ func (g *TallyInvoiceGenerator) drawItemsTable(y float64) float64 {
 		pdf.CellFormat(wDesc, rowH, item.Name, "R", 0, "L", false, 0, "")
 		pdf.CellFormat(wHSN, rowH, item.HSNCode, "R", 0, "C", false, 0, "")
 		pdf.CellFormat(wQty, rowH, fmt.Sprintf("%d", item.Qty), "R", 0, "C", false, 0, "")
-		pdf.CellFormat(wRate, rowH, fmt.Sprintf("%.2f", item.Rate), "R", 0, "R", false, 0, "")
+		pdf.CellFormat(wRate, rowH, item.Rate.String(), "R", 0, "R", false, 0, "")
 		pdf.CellFormat(wTax, rowH, fmt.Sprintf("%.1f%%", item.TaxRate), "R", 0, "C", false, 0, "")
-		pdf.CellFormat(wAmt, rowH, fmt.Sprintf("%.2f", item.Total), "", 1, "R", false, 0, "")
+		pdf.CellFormat(wAmt, rowH, item.Total.String(), "", 1, "R", false, 0, "")
 	}
 
 	endY := pdf.GetY()
@@ -328,17 +435,101 @@ func (g *TallyInvoiceGenerator) drawItemsTable(y float64) float64 {
 	return endY
 }
 
+// ─── HSN/SAC Summary ─────────────────────────────────────────────────────────
+func (g *TallyInvoiceGenerator) drawHSNSummary(y float64) float64 {
+	if len(g.data.HSNSummary) == 0 {
+		return y
+	}
+
+	pdf := g.pdf
+
+	wHSN := 30.0
+	wTaxable := 36.0
+	wRate := 18.0
+	wHead := 27.0 // CGST, SGST, IGST
+	wTotal := 25.0
+	rowH := 5.5
+
+	if y+6+rowH*float64(len(g.data.HSNSummary)+2) > 250 {
+		pdf.AddPage()
+		y = marginT + 10
+	}
+
+	pdf.SetFillColor(240, 240, 240)
+	pdf.Rect(marginL, y, pageW, 6, "F")
+	pdf.SetFont("Helvetica", "B", 7.5)
+	pdf.SetXY(marginL+2, y+1)
+	pdf.Cell(60, 4, "HSN/SAC SUMMARY")
+	y += 6
+
+	pdf.SetFont("Helvetica", "B", 7)
+	pdf.SetXY(marginL, y)
+	pdf.CellFormat(wHSN, rowH, "HSN/SAC", "R", 0, "C", false, 0, "")
+	pdf.CellFormat(wTaxable, rowH, "TAXABLE VALUE", "R", 0, "R", false, 0, "")
+	pdf.CellFormat(wRate, rowH, "RATE", "R", 0, "C", false, 0, "")
+	pdf.CellFormat(wHead, rowH, "CGST", "R", 0, "R", false, 0, "")
+	pdf.CellFormat(wHead, rowH, "SGST", "R", 0, "R", false, 0, "")
+	pdf.CellFormat(wHead, rowH, "IGST", "R", 0, "R", false, 0, "")
+	pdf.CellFormat(wTotal, rowH, "TOTAL TAX", "", 1, "R", false, 0, "")
+
+	var total HSNRow
+	pdf.SetFont("Helvetica", "", 7.5)
+
+	for _, r := range g.data.HSNSummary {
+		pdf.SetX(marginL)
+		pdf.CellFormat(wHSN, rowH, r.HSNCode, "TR", 0, "C", false, 0, "")
+		pdf.CellFormat(wTaxable, rowH, r.TaxableValue.String(), "TR", 0, "R", false, 0, "")
+		pdf.CellFormat(wRate, rowH, formatRate(r.TaxRate)+"%", "TR", 0, "C", false, 0, "")
+		pdf.CellFormat(wHead, rowH, r.CGST.String(), "TR", 0, "R", false, 0, "")
+		pdf.CellFormat(wHead, rowH, r.SGST.String(), "TR", 0, "R", false, 0, "")
+		pdf.CellFormat(wHead, rowH, r.IGST.String(), "TR", 0, "R", false, 0, "")
+		pdf.CellFormat(wTotal, rowH, r.TotalTax.String(), "T", 1, "R", false, 0, "")
+
+		total.TaxableValue += r.TaxableValue
+		total.CGST += r.CGST
+		total.SGST += r.SGST
+		total.IGST += r.IGST
+		total.TotalTax += r.TotalTax
+	}
+
+	pdf.SetFont("Helvetica", "B", 7.5)
+	pdf.SetX(marginL)
+	pdf.CellFormat(wHSN, rowH, "TOTAL", "TR", 0, "C", false, 0, "")
+	pdf.CellFormat(wTaxable, rowH, total.TaxableValue.String(), "TR", 0, "R", false, 0, "")
+	pdf.CellFormat(wRate, rowH, "", "TR", 0, "C", false, 0, "")
+	pdf.CellFormat(wHead, rowH, total.CGST.String(), "TR", 0, "R", false, 0, "")
+	pdf.CellFormat(wHead, rowH, total.SGST.String(), "TR", 0, "R", false, 0, "")
+	pdf.CellFormat(wHead, rowH, total.IGST.String(), "TR", 0, "R", false, 0, "")
+	pdf.CellFormat(wTotal, rowH, total.TotalTax.String(), "T", 1, "R", false, 0, "")
+
+	endY := pdf.GetY()
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Line(marginL, endY, marginL+pageW, endY)
+
+	return endY
+}
+
 // ─── Totals Section ──────────────────────────────────────────────────────────
 func (g *TallyInvoiceGenerator) drawTotalsSection(y float64) float64 {
 	pdf := g.pdf
-	h := 38.0
 	mid := marginL + pageW/2
 
-	cgst := g.data.Invoice.Tax / 2
-	sgst := g.data.Invoice.Tax / 2
+	lines := make([]utils.TaxLine, 0, len(g.data.Items))
+	for _, item := range g.data.Items {
+		lines = append(lines, utils.TaxLine{
+			TaxRate: item.TaxRate,
+			CGST:    item.CGST,
+			SGST:    item.SGST,
+			IGST:    item.IGST,
+		})
+	}
+	heads := utils.SummarizeTaxHeads(lines)
+
+	// Taxable + one row per head + total, never shorter than the right side
+	h := math.Max(38, 8+float64(len(heads)+2)*6+4)
 
 	// If not enough space for totals add new page
-	if y > 220 {
+	if y+h > 258 {
 		pdf.AddPage()
 		y = marginT + 10
 	}
@@ -350,10 +541,13 @@ func (g *TallyInvoiceGenerator) drawTotalsSection(y float64) float64 {
 	pdf.SetXY(marginL+2, y+1)
 	pdf.Cell(40, 4, "GST SUMMARY")
 
-	g.taxRow(marginL+2, y+8, "Taxable Amount", g.data.Invoice.Subtotal)
-	g.taxRow(marginL+2, y+14, "CGST @ 9.0%", cgst)
-	g.taxRow(marginL+2, y+20, "SGST @ 9.0%", sgst)
-	g.taxRow(marginL+2, y+26, "Total Tax", g.data.Invoice.Tax)
+	rowY := y + 8
+	g.taxRow(marginL+2, rowY, "Taxable Amount", g.data.Invoice.Subtotal)
+	for _, head := range heads {
+		rowY += 6
+		g.taxRow(marginL+2, rowY, fmt.Sprintf("%s @ %s%%", head.Head, formatRate(head.Rate)), head.Amount)
+	}
+	g.taxRow(marginL+2, rowY+6, "Total Tax", g.data.Invoice.Tax)
 
 	// Vertical divider
 	pdf.SetDrawColor(200, 200, 200)
@@ -365,7 +559,7 @@ func (g *TallyInvoiceGenerator) drawTotalsSection(y float64) float64 {
 	pdf.Rect(mid, y, pageW/2, 6, "F")
 	pdf.SetFont("Helvetica", "B", 7.5)
 	pdf.SetXY(mid+3, y+1)
-	pdf.Cell(40, 4, "INVOICE SUMMARY")
+	pdf.Cell(40, 4, g.labels.SummaryLabel)
 
 	g.taxRow(mid+3, y+8, "Subtotal", g.data.Invoice.Subtotal)
 	g.taxRow(mid+3, y+14, "Total Tax", g.data.Invoice.Tax)
@@ -379,7 +573,7 @@ func (g *TallyInvoiceGenerator) drawTotalsSection(y float64) float64 {
 	pdf.Cell(30, 7, "GRAND TOTAL")
 	pdf.SetXY(mid+3, y+23)
 	pdf.CellFormat(pageW/2-6, 7,
-		fmt.Sprintf("INR %.2f", g.data.Invoice.Total),
+		"INR "+g.data.Invoice.Total.String(),
 		"", 0, "R", false, 0, "")
 	pdf.SetTextColor(0, 0, 0)
 
@@ -454,11 +648,7 @@ func (g *TallyInvoiceGenerator) drawFooter(y float64) float64 {
 	pdf.SetFont("Helvetica", "I", 6.5)
 	pdf.SetTextColor(110, 110, 110)
 	pdf.SetXY(marginL+2, y2)
-	pdf.MultiCell(pageW-4, 3.5,
-		"Declaration: We declare that this invoice shows the actual price of the goods "+
-			"described and that all particulars are true and correct. "+
-			"Goods once sold will not be taken back.",
-		"", "L", false)
+	pdf.MultiCell(pageW-4, 3.5, g.labels.Declaration, "", "L", false)
 	pdf.SetTextColor(0, 0, 0)
 
 	return y2 + 8
@@ -476,7 +666,7 @@ func (g *TallyInvoiceGenerator) labelValue(x, y float64, label, value string) {
 	pdf.Cell(55, 4, value)
 }
 
-func (g *TallyInvoiceGenerator) taxRow(x, y float64, label string, value float64) {
+func (g *TallyInvoiceGenerator) taxRow(x, y float64, label string, value money.Money) {
 	pdf := g.pdf
 	pdf.SetFont("Helvetica", "", 7.5)
 	pdf.SetTextColor(70, 70, 70)
@@ -485,7 +675,12 @@ func (g *TallyInvoiceGenerator) taxRow(x, y float64, label string, value float64
 	pdf.SetFont("Helvetica", "B", 7.5)
 	pdf.SetTextColor(0, 0, 0)
 	pdf.SetXY(x+38, y)
-	pdf.CellFormat(42, 5, fmt.Sprintf("%.2f", value), "", 0, "R", false, 0, "")
+	pdf.CellFormat(42, 5, value.String(), "", 0, "R", false, 0, "")
+}
+
+// formatRate prints 9 as "9" and 2.5 as "2.5".
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
 }
 
 func (g *TallyInvoiceGenerator) bankRow(y float64, label, value string) {
@@ -504,3 +699,78 @@ func GenerateTallyInvoicePDF(data InvoicePDFData, copyType string) ([]byte, erro
 	generator := NewTallyInvoiceGenerator(data, copyType)
 	return generator.Generate()
 }
+
+// GenerateQuotePDF renders a quotation on the invoice layout; DueDate
+// carries the quote's validity date.
+func GenerateQuotePDF(data InvoicePDFData) ([]byte, error) {
+	data.Labels = DocumentLabels{
+		Title:        "QUOTATION",
+		NumberLabel:  "Quote No.",
+		DueLabel:     "Valid Until",
+		SummaryLabel: "QUOTE SUMMARY",
+		Declaration: "This is a quotation and not a demand for payment. " +
+			"Prices and taxes are valid until the date shown above.",
+	}
+	generator := NewTallyInvoiceGenerator(data, "")
+	return generator.Generate()
+}
+
+// GeneratePurchaseOrderPDF renders a purchase order on the invoice layout.
+// The vendor fills the party slot, ClientShipping carries the delivery
+// address and DueDate the expected delivery date.
+func GeneratePurchaseOrderPDF(data InvoicePDFData) ([]byte, error) {
+	data.Labels = DocumentLabels{
+		Title:        "PURCHASE ORDER",
+		NumberLabel:  "PO No.",
+		DueLabel:     "Expected By",
+		SummaryLabel: "ORDER SUMMARY",
+		PartyLabel:   "SUPPLIER",
+		ShipLabel:    "DELIVER TO",
+		Declaration: "Please supply the goods listed above at the rates shown and quote " +
+			"the PO number on your invoice and delivery challan.",
+	}
+	generator := NewTallyInvoiceGenerator(data, "")
+	return generator.Generate()
+}
+
+// GenerateCreditNotePDF renders a credit note on the invoice layout. Return
+// notes list the goods taken back; adjustment and discount notes carry a
+// single line for the credited amount.
+func GenerateCreditNotePDF(data InvoicePDFData, reason string) ([]byte, error) {
+	declaration := "This credit note reduces the amount payable by the recipient"
+	if data.Invoice.AgainstNumber != "" {
+		declaration += " against invoice " + data.Invoice.AgainstNumber
+	}
+	declaration += "."
+	if reason != "" {
+		declaration = "Reason: " + reason + ". " + declaration
+	}
+
+	data.Labels = DocumentLabels{
+		Title:        "CREDIT NOTE",
+		NumberLabel:  "Credit Note No.",
+		SummaryLabel: "CREDIT SUMMARY",
+		Declaration:  declaration,
+	}
+	generator := NewTallyInvoiceGenerator(data, "")
+	return generator.Generate()
+}
+
+// GenerateDebitNotePDF renders a debit note on the invoice layout: the extra
+// amount billed against the original invoice, itemised or as one line.
+func GenerateDebitNotePDF(data InvoicePDFData, reason string) ([]byte, error) {
+	declaration := "This debit note increases the amount payable by the recipient against invoice " +
+		data.Invoice.AgainstNumber + "."
+	if reason != "" {
+		declaration = "Reason: " + reason + ". " + declaration
+	}
+
+	data.Labels = DocumentLabels{
+		Title:        "DEBIT NOTE",
+		NumberLabel:  "Debit Note No.",
+		SummaryLabel: "DEBIT SUMMARY",
+		Declaration:  declaration,
+	}
+	generator := NewTallyInvoiceGenerator(data, "")
+	return generator.Generate()
+}
@@ -1,5 +1,7 @@
 package pdf
 
+import "invo-server/internal/money"
+
 type InvoicePDFData struct {
 	Company        Company
 	CompanyAddress Address
@@ -7,7 +9,21 @@ type InvoicePDFData struct {
 	ClientShipping *Address
 	Invoice        Invoice
 	Items          []InvoiceItem
+	HSNSummary     []HSNRow
 	Bank           CompanyBankDetails
+	Labels         DocumentLabels
+}
+
+// DocumentLabels lets other documents reuse the tax invoice layout.
+// Empty fields fall back to the invoice wording.
+type DocumentLabels struct {
+	Title        string // header bar, e.g. "TAX INVOICE"
+	NumberLabel  string // "Invoice No."
+	DueLabel     string // "Due Date"
+	SummaryLabel string // "INVOICE SUMMARY"
+	PartyLabel   string // "BILL TO"
+	ShipLabel    string // "SHIP TO"
+	Declaration  string
 }
 
 type Company struct {
@@ -29,27 +45,51 @@ type Invoice struct {
 	InvoiceNumber string
 	InvoiceDate   string
 	DueDate       string
-	Subtotal      float64
-	Tax           float64
-	Total         float64
+	Subtotal      money.Money
+	Tax           money.Money
+	Total         money.Money
 	Notes         string
 	PaymentInfo   string
 	PONumber      string
 	Number        string
 	Terms         string
-	AmountPaid    float64
-	AmountDue     float64
+	AmountPaid    money.Money
+	AmountDue     money.Money
 	TaxRate       float64
-	Discount      float64
+	Discount      money.Money
+	PlaceOfSupply string // display label, e.g. "29-Karnataka"
+	IsInterState  bool
+	IRN           string // set once the invoice is registered on the IRP
+	AckNo         string
+	AckDate       string
+	SignedQR      string
+	EWayBillNo    string
+	EWayBillDate  string
+	AgainstNumber string // original invoice of a credit note
+	AgainstDate   string
 }
 
 type InvoiceItem struct {
 	Name    string
 	Qty     int
 	HSNCode string
-	Rate    float64
+	Rate    money.Money
 	TaxRate float64
-	Total   float64
+	CGST    money.Money
+	SGST    money.Money
+	IGST    money.Money
+	Total   money.Money
+}
+
+// HSNRow is one line of the HSN/SAC-wise tax table.
+type HSNRow struct {
+	HSNCode      string
+	TaxRate      float64
+	TaxableValue money.Money
+	CGST         money.Money
+	SGST         money.Money
+	IGST         money.Money
+	TotalTax     money.Money
 }
 
 type CompanyBankDetails struct {
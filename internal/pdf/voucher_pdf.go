@@ -0,0 +1,278 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"invo-server/internal/money"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// VoucherPDFData is a single-amount document such as a refund voucher or a
+// payment receipt.
+type VoucherPDFData struct {
+	Company        Company
+	CompanyAddress Address
+	CompanyGSTIN   string
+	Party          Address
+	Number         string
+	Date           string
+	Details        [][2]string // extra label/value rows, e.g. mode and reference
+	Lines          []VoucherLine
+	Amount         money.Money
+	Notes          string
+}
+
+// VoucherLine is a document the voucher amount was set against, such as an
+// invoice settled by a payment.
+type VoucherLine struct {
+	Number string
+	Date   string
+	Amount money.Money
+}
+
+// voucherLabels is the wording that differs between voucher types.
+type voucherLabels struct {
+	Title       string
+	NumberLabel string
+	PartyLabel  string
+	LinesLabel  string // heading of the Lines table
+	AmountLabel string
+	Declaration string
+}
+
+// generateVoucherPDF renders a voucher in the tax invoice's black-header
+// style: company and voucher details, the party, then the amount.
+func generateVoucherPDF(data VoucherPDFData, labels voucherLabels) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(true, 15)
+
+	// labelValue and taxRow live on the invoice generator
+	g := &TallyInvoiceGenerator{pdf: pdf}
+
+	pdf.AddPage()
+	y := marginT
+	mid := marginL + pageW/2
+
+	// Header
+	pdf.SetFillColor(20, 20, 20)
+	pdf.Rect(marginL, y, pageW, 10, "F")
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetXY(marginL, y)
+	pdf.CellFormat(pageW, 10, labels.Title, "", 0, "C", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	y += 10
+
+	// Company (left) and voucher details (right)
+	details := append([][2]string{
+		{labels.NumberLabel, data.Number},
+		{"Date", data.Date},
+	}, data.Details...)
+	if data.CompanyGSTIN != "" {
+		details = append(details, [2]string{"GSTIN", data.CompanyGSTIN})
+	}
+
+	h := 32.0
+	if rows := 6 + float64(len(details))*6; rows > h {
+		h = rows
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetXY(marginL+2, y+3)
+	pdf.Cell(pageW/2-4, 5, data.Company.Name)
+
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetTextColor(60, 60, 60)
+	pdf.SetXY(marginL+2, y+9)
+	pdf.MultiCell(pageW/2-4, 4,
+		fmt.Sprintf("%s\n%s, %s - %s",
+			data.CompanyAddress.Line1,
+			data.CompanyAddress.City,
+			data.CompanyAddress.State,
+			data.CompanyAddress.Zip,
+		), "", "L", false)
+	if data.Company.Phone != "" {
+		pdf.SetFont("Helvetica", "", 7.5)
+		pdf.SetXY(marginL+2, y+22)
+		pdf.Cell(pageW/2-4, 4, "Ph: "+data.Company.Phone)
+	}
+	pdf.SetTextColor(0, 0, 0)
+
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.Line(mid, y, mid, y+h)
+	pdf.SetDrawColor(0, 0, 0)
+
+	for i, d := range details {
+		g.labelValue(mid+3, y+4+float64(i)*6, d[0], d[1])
+	}
+
+	y += h
+	pdf.Line(marginL, y, marginL+pageW, y)
+
+	// Party
+	pdf.SetFillColor(240, 240, 240)
+	pdf.Rect(marginL, y, pageW, 6, "F")
+	pdf.SetFont("Helvetica", "B", 7.5)
+	pdf.SetXY(marginL+2, y+1)
+	pdf.Cell(40, 4, labels.PartyLabel)
+
+	pdf.SetFont("Helvetica", "B", 8.5)
+	pdf.SetXY(marginL+2, y+8)
+	pdf.Cell(pageW-4, 4, data.Party.Name)
+
+	if data.Party.Line1 != "" {
+		pdf.SetFont("Helvetica", "", 7.5)
+		pdf.SetTextColor(60, 60, 60)
+		pdf.SetXY(marginL+2, y+13)
+		pdf.MultiCell(pageW-4, 3.8,
+			fmt.Sprintf("%s, %s, %s - %s",
+				data.Party.Line1,
+				data.Party.City,
+				data.Party.State,
+				data.Party.Zip,
+			), "", "L", false)
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	y += 24
+	pdf.Line(marginL, y, marginL+pageW, y)
+
+	// Lines
+	if len(data.Lines) > 0 {
+		y = drawVoucherLines(pdf, y, data, labels.LinesLabel)
+	}
+
+	// Amount bar
+	pdf.SetFillColor(20, 20, 20)
+	pdf.Rect(marginL, y, pageW, 9, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetXY(marginL+3, y+1)
+	pdf.Cell(60, 7, labels.AmountLabel)
+	pdf.SetXY(marginL+3, y+1)
+	pdf.CellFormat(pageW-6, 7, "INR "+data.Amount.String(), "", 0, "R", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	y += 9
+
+	pdf.SetFillColor(245, 245, 245)
+	pdf.Rect(marginL, y, pageW, 8, "F")
+	pdf.SetFont("Helvetica", "I", 7.5)
+	pdf.SetTextColor(40, 40, 40)
+	pdf.SetXY(marginL+2, y+2)
+	pdf.Cell(pageW-4, 5, "In Words: "+AmountToWords(data.Amount))
+	pdf.SetTextColor(0, 0, 0)
+	y += 8
+	pdf.Line(marginL, y, marginL+pageW, y)
+
+	// Notes
+	if data.Notes != "" {
+		pdf.SetFont("Helvetica", "", 7.5)
+		pdf.SetXY(marginL+2, y+3)
+		pdf.MultiCell(pageW-4, 4, "Notes: "+data.Notes, "", "L", false)
+		y = pdf.GetY()
+	}
+
+	// Signatures
+	y += 20
+	pdf.SetDrawColor(120, 120, 120)
+	pdf.Line(marginL+5, y, marginL+70, y)
+	pdf.Line(marginL+pageW-70, y, marginL+pageW-5, y)
+	pdf.SetDrawColor(0, 0, 0)
+
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.SetXY(marginL+5, y+1)
+	pdf.CellFormat(65, 4, "Receiver's Signature", "", 0, "C", false, 0, "")
+	pdf.SetXY(marginL+pageW-70, y+1)
+	pdf.CellFormat(65, 4, "For "+strings.ToUpper(data.Company.Name), "", 0, "C", false, 0, "")
+
+	// Declaration
+	pdf.SetFont("Helvetica", "I", 6.5)
+	pdf.SetTextColor(110, 110, 110)
+	pdf.SetXY(marginL+2, y+10)
+	pdf.MultiCell(pageW-4, 3.5, labels.Declaration, "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawVoucherLines lists the documents the amount was set against, with a
+// final row for any part of it that wasn't. Returns the y below the table.
+func drawVoucherLines(pdf *gofpdf.Fpdf, y float64, data VoucherPDFData, title string) float64 {
+	numW, dateW := pageW*0.45, pageW*0.25
+	amtW := pageW - numW - dateW
+
+	pdf.SetFillColor(240, 240, 240)
+	pdf.Rect(marginL, y, pageW, 6, "F")
+	pdf.SetFont("Helvetica", "B", 7.5)
+	pdf.SetXY(marginL+2, y+1)
+	pdf.Cell(60, 4, title)
+	y += 6
+
+	pdf.SetFont("Helvetica", "B", 7.5)
+	pdf.SetXY(marginL+2, y+1)
+	pdf.CellFormat(numW-2, 5, "Document No.", "", 0, "L", false, 0, "")
+	pdf.CellFormat(dateW, 5, "Date", "", 0, "L", false, 0, "")
+	pdf.CellFormat(amtW-3, 5, "Amount (INR)", "", 0, "R", false, 0, "")
+	y += 7
+	pdf.SetDrawColor(200, 200, 200)
+	pdf.Line(marginL, y, marginL+pageW, y)
+
+	pdf.SetFont("Helvetica", "", 8)
+	var applied money.Money
+	row := func(number, date string, amount money.Money) {
+		pdf.SetXY(marginL+2, y+1)
+		pdf.CellFormat(numW-2, 5, number, "", 0, "L", false, 0, "")
+		pdf.CellFormat(dateW, 5, date, "", 0, "L", false, 0, "")
+		pdf.CellFormat(amtW-3, 5, amount.String(), "", 0, "R", false, 0, "")
+		y += 7
+		pdf.Line(marginL, y, marginL+pageW, y)
+	}
+
+	for _, l := range data.Lines {
+		row(l.Number, l.Date, l.Amount)
+		applied += l.Amount
+	}
+	if left := data.Amount - applied; left > 0 {
+		pdf.SetFont("Helvetica", "I", 8)
+		row("On account (unapplied)", "", left)
+	}
+
+	pdf.SetDrawColor(0, 0, 0)
+	return y
+}
+
+// GenerateRefundVoucherPDF renders the voucher for money paid back to a
+// client.
+func GenerateRefundVoucherPDF(data VoucherPDFData) ([]byte, error) {
+	return generateVoucherPDF(data, voucherLabels{
+		Title:       "REFUND VOUCHER",
+		NumberLabel: "Voucher No.",
+		PartyLabel:  "PAID TO",
+		AmountLabel: "AMOUNT REFUNDED",
+		Declaration: "Received the above amount as a refund of credit held with us. " +
+			"This voucher is not a tax invoice.",
+	})
+}
+
+// GeneratePaymentReceiptPDF renders the receipt for money received from a
+// client, with the invoices it settled.
+func GeneratePaymentReceiptPDF(data VoucherPDFData) ([]byte, error) {
+	return generateVoucherPDF(data, voucherLabels{
+		Title:       "PAYMENT RECEIPT",
+		NumberLabel: "Receipt No.",
+		PartyLabel:  "RECEIVED FROM",
+		LinesLabel:  "AGAINST INVOICES",
+		AmountLabel: "AMOUNT RECEIVED",
+		Declaration: "Received with thanks the above amount. Any amount shown on account " +
+			"will be adjusted against future invoices. This receipt is not a tax invoice.",
+	})
+}
@@ -11,14 +11,23 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, db *database.Database, cfg *config.Config) {
+func RegisterRoutes(
+	r *gin.Engine,
+	db *database.Database,
+	cfg *config.Config,
+	ledgerService *services.LedgerService,
+	invoiceService *services.InvoiceService,
+	stockService *services.StockService,
+	notificationService *services.NotificationService,
+	emailService *services.EmailService,
+	recurringInvoiceService *services.RecurringInvoiceService,
+) {
 
 	userHandler := handlers.NewUserHandler(db)
 	companyHandler := handlers.NewCompanyHandler(db)
 	clientHandler := handlers.NewClientHandler(db)
 	itemHandler := handlers.NewItemHandler(db)
 	categoryHandler := handlers.NewCategoryHandler(db)
-	invoiceHandler := handlers.NewInvoiceHandler(db)
 	expenseHandler := handlers.NewExpenseHandler(db) // ← Add this line
 	clientAddressHandler := handlers.NewClientAddressHandler(db)
 	companyAddressHandler := handlers.NewCompanyAddressHandler(db)
@@ -26,22 +35,38 @@ func RegisterRoutes(r *gin.Engine, db *database.Database, cfg *config.Config) {
 	dashboard := handlers.NewDashboardHandler(db)
 	companyBankHandlerss := handlers.NewCompanyBankHandler(db.DB)
 
-	ledgerService := services.NewLedgerService(db.DB)
 	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
-	creditNoteService := services.NewCreditNoteService(db.DB, ledgerService)
+	stockHandler := handlers.NewStockHandler(stockService, db.DB)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, db.DB)
+	vendorLedgerService := services.NewVendorLedgerService(db.DB)
+	vendorHandler := handlers.NewVendorHandler(services.NewVendorService(db.DB), vendorLedgerService, db.DB)
+	purchaseBillService := services.NewPurchaseBillService(db.DB, vendorLedgerService, stockService)
+	purchaseBillHandler := handlers.NewPurchaseBillHandler(purchaseBillService, db.DB)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(services.NewPurchaseOrderService(db.DB, stockService), db.DB)
+	numberingService := services.NewNumberingService(db.DB)
+	numberingHandler := handlers.NewNumberingHandler(numberingService, db.DB)
+	invoiceHandler := handlers.NewInvoiceHandler(db, ledgerService, invoiceService, numberingService)
+	creditNoteService := services.NewCreditNoteService(db.DB, ledgerService, stockService)
 
 	paymentService := services.NewPaymentService(db.DB, ledgerService)
-	paymentHandler := handlers.NewPaymentHandler(db, paymentService)
+	paymentHandler := handlers.NewPaymentHandler(db, paymentService, emailService)
 	creditNoteHandler := handlers.NewCreditNoteHandler(creditNoteService, db.DB) // ← Add this line
-	emailService := services.NewEmailService(
-		cfg.Email.ResendAPIKey,
-		cfg.Email.FromEmail,
-		cfg.Email.FromName,
-	)
+	refundHandler := handlers.NewRefundHandler(services.NewRefundService(db.DB, ledgerService), db.DB)
+	debitNoteHandler := handlers.NewDebitNoteHandler(services.NewDebitNoteService(db.DB, ledgerService), db.DB)
 	authHandler := handlers.NewAuthHandler(db, []byte(cfg.JWT.Secret), emailService)
 	emailHandler := handlers.NewEmailHandler(emailService, db.DB)
 	// Add OTP handler
 	otpHandler := handlers.NewOTPHandler(db, emailService, []byte(cfg.JWT.Secret))
+	recurringInvoiceHandler := handlers.NewRecurringInvoiceHandler(recurringInvoiceService, db.DB)
+	quoteService := services.NewQuoteService(db.DB, invoiceService)
+	quoteHandler := handlers.NewQuoteHandler(quoteService, db.DB)
+	reportService := services.NewReportService(db.DB)
+	reportHandler := handlers.NewReportHandler(reportService, db.DB)
+	irpClient := services.NewStubIRPClient([]byte(cfg.EInvoice.SigningKey))
+	einvoiceService := services.NewEInvoiceService(db.DB, irpClient)
+	einvoiceHandler := handlers.NewEInvoiceHandler(einvoiceService, db.DB)
+	ewayBillService := services.NewEWayBillService(db.DB, services.NewStubEWBProvider())
+	ewayBillHandler := handlers.NewEWayBillHandler(ewayBillService, db.DB)
 
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
@@ -71,6 +96,11 @@ func RegisterRoutes(r *gin.Engine, db *database.Database, cfg *config.Config) {
 		protected.GET("/companies/:companyId/address", companyAddressHandler.GetCompanyAddress)
 		protected.POST("/companies/:companyId/address", companyAddressHandler.SaveCompanyAddress)
 
+		// Document numbering routes
+		protected.GET("/companies/:companyId/numbering-schemes", numberingHandler.List)
+		protected.PUT("/companies/:companyId/numbering-schemes/:documentType", numberingHandler.Upsert)
+		protected.GET("/companies/:companyId/numbering-schemes/:documentType/preview", numberingHandler.Preview)
+
 		// Client routes
 		protected.POST("/clients", clientHandler.CreateClient)
 		protected.GET("/companies/:companyId/clients", clientHandler.GetClients)
@@ -79,10 +109,34 @@ func RegisterRoutes(r *gin.Engine, db *database.Database, cfg *config.Config) {
 		// invoices by client
 		protected.GET("/clients/:clientId/invoices", invoiceHandler.GetInvoicesByClientID)
 
+		// Vendor routes
+		protected.POST("/vendors", vendorHandler.Create)
+		protected.GET("/companies/:companyId/vendors", vendorHandler.List)
+		protected.GET("/vendors/:vendorId", vendorHandler.GetByID)
+		protected.GET("/vendors/:vendorId/address", vendorHandler.GetAddress)
+		protected.POST("/vendors/:vendorId/address", vendorHandler.SaveAddress)
+		protected.GET("/vendors/:vendorId/ledger", vendorHandler.Ledger)
+
+		// Purchase bill routes
+		protected.POST("/purchase-bills", purchaseBillHandler.Create)
+		protected.GET("/companies/:companyId/purchase-bills", purchaseBillHandler.List)
+		protected.GET("/purchase-bills/:id", purchaseBillHandler.GetByID)
+		protected.POST("/vendor-payments", purchaseBillHandler.RecordPayment)
+
+		// Purchase order routes
+		protected.POST("/purchase-orders", purchaseOrderHandler.Create)
+		protected.GET("/companies/:companyId/purchase-orders", purchaseOrderHandler.List)
+		protected.GET("/purchase-orders/:id", purchaseOrderHandler.GetByID)
+		protected.POST("/purchase-orders/:id/status", purchaseOrderHandler.UpdateStatus)
+		protected.POST("/purchase-orders/:id/receive", purchaseOrderHandler.Receive)
+		protected.GET("/purchase-orders/:id/pdf", purchaseOrderHandler.GetPDF)
+
 		// Item routes
 		protected.POST("/items", itemHandler.CreateItem)
-		protected.GET("/items/:companyId/all", itemHandler.GetItems)
+		protected.GET("/items/:id/all", itemHandler.GetItems) // :id is the company id here
 		protected.GET("/item/:itemId/one", itemHandler.GetItemByID)
+		protected.GET("/items/:id/stock-history", stockHandler.History)
+		protected.POST("/items/:id/stock-adjustments", stockHandler.Adjust)
 
 		// Category routes
 		protected.POST("/categories", categoryHandler.CreateCategory)
@@ -95,8 +149,33 @@ func RegisterRoutes(r *gin.Engine, db *database.Database, cfg *config.Config) {
 		protected.GET("/invoices/number-preview", invoiceHandler.GetInvoiceNumberPreview)
 		protected.GET("/clients/:clientId/unpaid-invoices", invoiceHandler.GetUnpaidInvoices)
 		protected.POST("/invoices/:id/issue", invoiceHandler.IssueInvoice)
+		protected.POST("/invoices/:id/cancel", invoiceHandler.CancelInvoice)
 		protected.PUT("/invoices/:id/update", invoiceHandler.UpdateInvoice) // 👈 REQUIRED
 
+		// E-invoice (IRN) routes
+		protected.GET("/invoices/:id/einvoice/payload", einvoiceHandler.Payload)
+		protected.POST("/invoices/:id/einvoice", einvoiceHandler.Generate)
+		protected.POST("/invoices/:id/einvoice/cancel", einvoiceHandler.Cancel)
+
+		// E-way bill routes
+		protected.POST("/invoices/:id/eway-bill", ewayBillHandler.Generate)
+		protected.GET("/invoices/:id/eway-bill", ewayBillHandler.Get)
+
+		// Recurring invoice routes
+		protected.POST("/recurring-invoices", recurringInvoiceHandler.Create)
+		protected.GET("/companies/:companyId/recurring-invoices", recurringInvoiceHandler.List)
+		protected.GET("/recurring-invoices/:id", recurringInvoiceHandler.GetByID)
+		protected.POST("/recurring-invoices/:id/pause", recurringInvoiceHandler.Pause)
+		protected.POST("/recurring-invoices/:id/resume", recurringInvoiceHandler.Resume)
+
+		// Quote routes
+		protected.POST("/quotes", quoteHandler.Create)
+		protected.GET("/quotes", quoteHandler.GetAll)
+		protected.GET("/quotes/:id", quoteHandler.GetByID)
+		protected.POST("/quotes/:id/status", quoteHandler.UpdateStatus)
+		protected.POST("/quotes/:id/convert", quoteHandler.Convert)
+		protected.GET("/quotes/:id/pdf", quoteHandler.GetPDF)
+
 		// Expense routes ← Add these lines
 		protected.POST("/expenses", expenseHandler.CreateExpense)
 		protected.GET("/expenses/:id", expenseHandler.GetExpenseByID)
@@ -113,20 +192,56 @@ func RegisterRoutes(r *gin.Engine, db *database.Database, cfg *config.Config) {
 		protected.GET("/companies/:companyId/ledger", ledgerHandler.GetCompanyLedger)
 
 		protected.POST("/payments", paymentHandler.RecordPayment)
+		protected.POST("/payments/preview-allocation", paymentHandler.PreviewAllocation)
+		protected.GET("/companies/:companyId/payment-allocation-strategy", paymentHandler.GetAllocationStrategy)
+		protected.PUT("/companies/:companyId/payment-allocation-strategy", paymentHandler.SetAllocationStrategy)
+		protected.GET("/companies/:companyId/payments", paymentHandler.List)
+		protected.GET("/payments/:id", paymentHandler.GetByID)
+		protected.GET("/payments/:id/receipt.pdf", paymentHandler.GetReceiptPDF)
+		protected.POST("/payments/:id/void", paymentHandler.Void)
+		protected.POST("/payments/:id/apply", paymentHandler.Apply)
+		protected.GET("/clients/:clientId/credit", paymentHandler.ClientCredit)
 
 		// credit note routes
 		protected.POST("/credit-notes", creditNoteHandler.Create)
 		protected.GET("/credit-notes", creditNoteHandler.GetAll)
 		protected.GET("/credit-notes/:id", creditNoteHandler.GetByID)
+		protected.POST("/credit-notes/:id/apply", creditNoteHandler.Apply)
+		protected.GET("/credit-notes/:id/pdf", creditNoteHandler.GetPDF)
+		protected.GET("/invoices/:id/returnable-items", creditNoteHandler.GetReturnableItems)
+
+		// Refund routes
+		protected.POST("/refunds", refundHandler.Create)
+		protected.GET("/companies/:companyId/refunds", refundHandler.List)
+		protected.GET("/refunds/:id", refundHandler.GetByID)
+		protected.GET("/refunds/:id/pdf", refundHandler.GetPDF)
+
+		// Debit note routes
+		protected.POST("/debit-notes", debitNoteHandler.Create)
+		protected.GET("/companies/:companyId/debit-notes", debitNoteHandler.List)
+		protected.GET("/debit-notes/:id", debitNoteHandler.GetByID)
+		protected.GET("/debit-notes/:id/pdf", debitNoteHandler.GetPDF)
 
 		// Dashboard routes
 		protected.GET("/dashboard", dashboard.GetDashboard)
 
+		// Report routes
+		protected.GET("/companies/:companyId/reports/hsn-summary", reportHandler.HSNSummary)
+		protected.GET("/companies/:companyId/reports/gstr1", reportHandler.GSTR1)
+		protected.GET("/companies/:companyId/reports/gstr3b", reportHandler.GSTR3B)
+		protected.GET("/companies/:companyId/reports/gstr3b/pdf", reportHandler.GSTR3BPDF)
+		protected.GET("/companies/:companyId/reports/reorder", reportHandler.Reorder)
+
+		// Notification routes
+		protected.GET("/companies/:companyId/notifications", notificationHandler.List)
+		protected.POST("/notifications/:id/read", notificationHandler.MarkRead)
+
 		protected.GET("/companies/:companyId/banks", companyBankHandlerss.List)
 		protected.POST("/companies/:companyId/banks", companyBankHandlerss.Create)
 		protected.PUT("/companies/:companyId/banks/:bankId", companyBankHandlerss.Update)
 
 		protected.POST("/invoices/:id/send-email", emailHandler.SendInvoiceEmail)
+		protected.POST("/credit-notes/:id/send-email", emailHandler.SendCreditNoteEmail)
 
 		// Add to public routes (no auth needed)
 		public.POST("/send-otp", otpHandler.SendOTP)
@@ -3,16 +3,28 @@ package services
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"invo-server/internal/models"
+	"invo-server/internal/money"
+	"invo-server/internal/pdf"
+	utils "invo-server/internal/util"
+	"math"
+	"time"
+)
+
+var (
+	ErrCreditNoteNotFound = errors.New("credit note not found")
+	ErrClientNotInCompany = errors.New("client does not belong to the company")
 )
 
 type CreditNoteService struct {
 	db     *sql.DB
 	ledger *LedgerService
+	stock  *StockService
 }
 
-func NewCreditNoteService(db *sql.DB, ledger *LedgerService) *CreditNoteService {
-	return &CreditNoteService{db: db, ledger: ledger}
+func NewCreditNoteService(db *sql.DB, ledger *LedgerService, stock *StockService) *CreditNoteService {
+	return &CreditNoteService{db: db, ledger: ledger, stock: stock}
 }
 
 func (s *CreditNoteService) CreateTx(
@@ -22,11 +34,60 @@ func (s *CreditNoteService) CreateTx(
 ) error {
 
 	// 1️⃣ Validate input
+	creditDate, err := time.Parse("2006-01-02", req.CreditDate)
+	if err != nil {
+		return errors.New("invalid credit_date (YYYY-MM-DD)")
+	}
+
+	var clientExists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM clients WHERE id = $1 AND company_id = $2)
+	`, req.ClientID, companyID).Scan(&clientExists)
+	if err != nil {
+		return err
+	}
+	if !clientExists {
+		return ErrClientNotInCompany
+	}
+
+	if req.InvoiceID != nil {
+		var clientID int64
+		var status string
+		err := tx.QueryRow(`
+			SELECT client_id, status
+			FROM invoices
+			WHERE id = $1 AND company_id = $2
+			FOR UPDATE
+		`, *req.InvoiceID, companyID).Scan(&clientID, &status)
+		if err == sql.ErrNoRows {
+			return ErrInvoiceNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if clientID != req.ClientID {
+			return errors.New("invoice belongs to a different client")
+		}
+		if status == "draft" || status == "cancelled" {
+			return fmt.Errorf("cannot credit a %s invoice", status)
+		}
+	}
+
 	switch req.Type {
 	case "return":
 		if len(req.Items) == 0 {
 			return errors.New("items required for return credit note")
 		}
+		for _, it := range req.Items {
+			// returned goods go back into stock, which is counted in units
+			if it.Qty <= 0 || it.Qty != math.Trunc(it.Qty) {
+				return errors.New("return qty must be a positive whole number")
+			}
+		}
+		req.Items, err = resolveReturnItemsTx(tx, req.InvoiceID, req.Items)
+		if err != nil {
+			return err
+		}
 	case "adjustment", "discount":
 		if req.Amount <= 0 {
 			return errors.New("amount required for credit note")
@@ -35,16 +96,15 @@ func (s *CreditNoteService) CreateTx(
 		return errors.New("invalid credit note type")
 	}
 
-	// 2️⃣ Calculate totals
-	var subtotal, tax, total float64
+	// 2️⃣ Calculate totals (tax rounded per line, like invoices)
+	var subtotal, tax, total money.Money
 
 	if req.Type == "return" {
 		for _, it := range req.Items {
-			lineBase := it.Qty * it.Rate
-			lineTax := lineBase * it.TaxRate / 100
+			lineBase := it.Rate.Mul(it.Qty)
 
 			subtotal += lineBase
-			tax += lineTax
+			tax += lineBase.Percent(*it.TaxRate)
 		}
 		total = subtotal + tax
 	} else {
@@ -53,12 +113,8 @@ func (s *CreditNoteService) CreateTx(
 		total = subtotal
 	}
 
-	// 3️⃣ Generate credit number
-	var creditNumber string
-	err := tx.QueryRow(`
-		SELECT 'CN-' || TO_CHAR(NOW(),'YYYY') || '-' ||
-		       LPAD(nextval('credit_note_seq')::text,5,'0')
-	`).Scan(&creditNumber)
+	// 3️⃣ Generate credit number from the company's numbering scheme
+	creditNumber, err := nextDocumentNumberTx(tx, companyID, DocCreditNote, creditDate)
 	if err != nil {
 		return err
 	}
@@ -93,8 +149,8 @@ func (s *CreditNoteService) CreateTx(
 	// 5️⃣ Insert items (return only)
 	if req.Type == "return" {
 		for _, it := range req.Items {
-			lineBase := it.Qty * it.Rate
-			lineTax := lineBase * it.TaxRate / 100
+			lineBase := it.Rate.Mul(it.Qty)
+			lineTax := lineBase.Percent(*it.TaxRate)
 
 			_, err = tx.Exec(`
 				INSERT INTO credit_note_items
@@ -104,14 +160,18 @@ func (s *CreditNoteService) CreateTx(
 				cnID,
 				it.ItemID,
 				it.Qty,
-				it.Rate,
-				it.TaxRate,
+				*it.Rate,
+				*it.TaxRate,
 				lineBase+lineTax,
 			)
 			if err != nil {
 				return err
 			}
 		}
+
+		if err := s.stock.ReturnTx(tx, companyID, cnID, creditNumber, req.Items); err != nil {
+			return err
+		}
 	}
 
 	// 6️⃣ Ledger entry
@@ -132,8 +192,159 @@ func (s *CreditNoteService) CreateTx(
 	)
 }
 
+// resolveReturnItemsTx fills in and checks the lines of a return. Against
+// an invoice, each item must have been billed on it, rate and tax rate
+// default to the invoiced values, the rate may not exceed what was billed,
+// and the quantity across all returns may not exceed what was invoiced.
+// Without an invoice there is nothing to check against, so rate is required.
+func resolveReturnItemsTx(
+	tx *sql.Tx,
+	invoiceID *int64,
+	items []models.CreditNoteItemDTO,
+) ([]models.CreditNoteItemDTO, error) {
+
+	resolved := make([]models.CreditNoteItemDTO, len(items))
+	copy(resolved, items)
+
+	if invoiceID == nil {
+		for i := range resolved {
+			if resolved[i].Rate == nil {
+				return nil, errors.New("rate required for returns not linked to an invoice")
+			}
+			if resolved[i].TaxRate == nil {
+				resolved[i].TaxRate = new(float64)
+			}
+		}
+	} else {
+		returnable, err := returnableItems(tx, *invoiceID)
+		if err != nil {
+			return nil, err
+		}
+
+		byItem := map[int64]models.ReturnableItem{}
+		for _, r := range returnable {
+			byItem[r.ItemID] = r
+		}
+
+		requested := map[int64]float64{}
+		for i := range resolved {
+			it := &resolved[i]
+
+			billed, ok := byItem[it.ItemID]
+			if !ok {
+				return nil, fmt.Errorf("item %d is not on the invoice", it.ItemID)
+			}
+
+			if it.Rate == nil {
+				rate := billed.Rate
+				it.Rate = &rate
+			} else if *it.Rate > billed.Rate {
+				return nil, fmt.Errorf("rate for %s exceeds the invoiced rate of %s", billed.ItemName, billed.Rate)
+			}
+			if it.TaxRate == nil {
+				taxRate := billed.TaxRate
+				it.TaxRate = &taxRate
+			}
+
+			requested[it.ItemID] += it.Qty
+			if requested[it.ItemID] > billed.ReturnableQty {
+				return nil, fmt.Errorf(
+					"only %v of %s can still be returned against this invoice",
+					billed.ReturnableQty, billed.ItemName,
+				)
+			}
+		}
+	}
+
+	for _, it := range resolved {
+		if *it.Rate < 0 || *it.TaxRate < 0 {
+			return nil, errors.New("rate and tax_rate must not be negative")
+		}
+	}
+
+	return resolved, nil
+}
+
+// returnableItems lists the catalogue items billed on an invoice with the
+// quantity already taken back on return credit notes. An item billed on
+// several lines is merged; its rate is the average net of line discounts
+// and its tax rate that of its first line.
+func returnableItems(q dbQuerier, invoiceID int64) ([]models.ReturnableItem, error) {
+	rows, err := q.Query(`
+		SELECT
+			ii.item_id,
+			it.name,
+			SUM(ii.qty),
+			COALESCE(r.qty, 0),
+			ROUND(SUM(ii.rate * ii.qty - COALESCE(ii.discount, 0)) / SUM(ii.qty), 2),
+			(ARRAY_AGG(COALESCE(ii.tax_rate, 0) ORDER BY ii.id))[1]
+		FROM invoice_items ii
+		JOIN items it ON it.id = ii.item_id
+		LEFT JOIN (
+			SELECT cni.item_id, SUM(cni.qty) AS qty
+			FROM credit_note_items cni
+			JOIN credit_notes cn ON cn.id = cni.credit_note_id
+			WHERE cn.invoice_id = $1 AND cn.type = 'return'
+			GROUP BY cni.item_id
+		) r ON r.item_id = ii.item_id
+		WHERE ii.invoice_id = $1
+		GROUP BY ii.item_id, it.name, r.qty
+		ORDER BY MIN(ii.id)
+	`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ReturnableItem{}
+	for rows.Next() {
+		var r models.ReturnableItem
+		if err := rows.Scan(
+			&r.ItemID,
+			&r.ItemName,
+			&r.InvoicedQty,
+			&r.ReturnedQty,
+			&r.Rate,
+			&r.TaxRate,
+		); err != nil {
+			return nil, err
+		}
+
+		r.ReturnableQty = math.Max(r.InvoicedQty-r.ReturnedQty, 0)
+		items = append(items, r)
+	}
+
+	return items, rows.Err()
+}
+
+// ReturnableItems lists what can still be returned against one of the
+// user's invoices.
+func (s *CreditNoteService) ReturnableItems(userID int, invoiceID int64) ([]models.ReturnableItem, error) {
+	var exists bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM invoices i
+			JOIN companies c ON c.id = i.company_id
+			WHERE i.id = $1 AND c.user_id = $2
+		)
+	`, invoiceID, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrInvoiceNotFound
+	}
+
+	return returnableItems(s.db, invoiceID)
+}
+
+// GetAll lists a company's credit notes, newest first. clientID narrows
+// the list when non-zero.
 func (s *CreditNoteService) GetAll(
-	companyID int64,
+	userID int,
+	companyID, clientID int64,
+	limit, offset int,
 ) ([]models.CreditNoteListDTO, error) {
 
 	rows, err := s.db.Query(`
@@ -148,10 +359,14 @@ func (s *CreditNoteService) GetAll(
 			cn.status,
 			cn.credit_date
 		FROM credit_notes cn
+		JOIN companies c ON c.id = cn.company_id
 		JOIN clients cl ON cl.id = cn.client_id
-		WHERE cn.company_id = $1
+		WHERE c.user_id = $1
+		  AND cn.company_id = $2
+		  AND ($3 = 0 OR cn.client_id = $3)
 		ORDER BY cn.credit_date DESC, cn.id DESC
-	`, companyID)
+		LIMIT $4 OFFSET $5
+	`, userID, companyID, clientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +392,7 @@ func (s *CreditNoteService) GetAll(
 		result = append(result, r)
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
 func (s *CreditNoteService) GetByID(
@@ -270,5 +485,456 @@ func (s *CreditNoteService) GetByID(
 		cn.Items = []models.CreditNoteItemResponse{}
 	}
 
-	return &cn, nil
+	allocRows, err := tx.Query(`
+		SELECT a.id, a.invoice_id, i.invoice_number, a.amount, a.created_at
+		FROM credit_note_allocations a
+		JOIN invoices i ON i.id = a.invoice_id
+		WHERE a.credit_note_id = $1
+		ORDER BY a.id
+	`, creditNoteID)
+	if err != nil {
+		return nil, err
+	}
+	defer allocRows.Close()
+
+	cn.Allocations = []models.CreditNoteAllocationResponse{}
+	for allocRows.Next() {
+		var a models.CreditNoteAllocationResponse
+		if err := allocRows.Scan(
+			&a.ID,
+			&a.InvoiceID,
+			&a.InvoiceNumber,
+			&a.Amount,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		cn.Allocations = append(cn.Allocations, a)
+	}
+
+	return &cn, allocRows.Err()
+}
+
+// ApplyTx uses up credit note balance against the client's open invoices.
+// The credit was already posted to the client ledger when the note was
+// issued, so applying it only moves it onto invoices: no ledger entry.
+func (s *CreditNoteService) ApplyTx(
+	tx *sql.Tx,
+	userID int,
+	creditNoteID int64,
+	req models.CreditNoteApplyRequestDTO,
+) (*models.CreditNoteApplyResponse, error) {
+
+	// 1️⃣ Lock credit note
+	var (
+		companyID, clientID int64
+		linkedInvoiceID     *int64
+		total, balance      money.Money
+	)
+	err := tx.QueryRow(`
+		SELECT cn.company_id, cn.client_id, cn.invoice_id, cn.total, cn.balance
+		FROM credit_notes cn
+		JOIN companies c ON c.id = cn.company_id
+		WHERE cn.id = $1 AND c.user_id = $2
+		FOR UPDATE OF cn
+	`, creditNoteID, userID).Scan(&companyID, &clientID, &linkedInvoiceID, &total, &balance)
+	if err == sql.ErrNoRows {
+		return nil, ErrCreditNoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if balance <= 0 {
+		return nil, errors.New("credit note has no balance left to apply")
+	}
+
+	// 2️⃣ Auto-allocate if allocations not provided
+	if len(req.Allocations) == 0 {
+		if req.Amount < 0 || req.Amount > balance {
+			return nil, errors.New("amount must be between 0 and the credit note balance")
+		}
+
+		allocations, err := s.autoAllocateFIFO(tx, companyID, clientID, linkedInvoiceID, balance, req.Amount)
+		if err != nil {
+			return nil, err
+		}
+		req.Allocations = allocations
+	}
+
+	// 3️⃣ Validate allocation total
+	var applied money.Money
+	for _, a := range req.Allocations {
+		if a.Amount <= 0 {
+			return nil, errors.New("allocation amount must be positive")
+		}
+		applied += a.Amount
+	}
+
+	if applied > balance {
+		return nil, errors.New("allocations exceed credit note balance")
+	}
+
+	// 4️⃣ Apply allocations
+	for _, alloc := range req.Allocations {
+		var remaining money.Money
+		var status string
+		err := tx.QueryRow(`
+			SELECT remaining_amount, status
+			FROM invoices
+			WHERE id = $1 AND company_id = $2 AND client_id = $3
+			FOR UPDATE
+		`, alloc.InvoiceID, companyID, clientID).Scan(&remaining, &status)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invoice %d is not an invoice of this client", alloc.InvoiceID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if status == "draft" || status == "cancelled" {
+			return nil, fmt.Errorf("cannot apply credit to a %s invoice", status)
+		}
+
+		if alloc.Amount > remaining {
+			return nil, errors.New("allocation exceeds invoice balance")
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO credit_note_allocations
+				(credit_note_id, invoice_id, amount)
+			VALUES ($1,$2,$3)
+		`, creditNoteID, alloc.InvoiceID, alloc.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		// same status rule as a payment
+		_, err = tx.Exec(`
+			UPDATE invoices
+			SET
+				remaining_amount = remaining_amount - $1,
+				status = CASE
+					WHEN remaining_amount - $1 <= 0 THEN 'paid'
+					ELSE 'partial'
+				END
+			WHERE id = $2
+		`, alloc.Amount, alloc.InvoiceID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 5️⃣ Decrement credit note balance
+	balance -= applied
+	status := creditNoteStatus(total, balance)
+
+	_, err = tx.Exec(`
+		UPDATE credit_notes SET balance = $1, status = $2 WHERE id = $3
+	`, balance, status, creditNoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreditNoteApplyResponse{
+		CreditNoteID: creditNoteID,
+		Applied:      applied,
+		Balance:      balance,
+		Status:       status,
+		Allocations:  req.Allocations,
+	}, nil
+}
+
+// creditNoteStatus derives the status from how much of the note is left.
+func creditNoteStatus(total, balance money.Money) string {
+	switch {
+	case balance <= 0:
+		return "applied"
+	case balance < total:
+		return "partially_applied"
+	default:
+		return "issued"
+	}
+}
+
+// autoAllocateFIFO spreads amount over the client's open invoices, oldest
+// first, starting with the invoice the note was raised against. A zero
+// amount applies as much of the balance as the invoices can take.
+func (s *CreditNoteService) autoAllocateFIFO(
+	tx *sql.Tx,
+	companyID, clientID int64,
+	linkedInvoiceID *int64,
+	balance, amount money.Money,
+) ([]models.CreditNoteAllocationDTO, error) {
+
+	rows, err := tx.Query(`
+		SELECT id, remaining_amount
+		FROM invoices
+		WHERE company_id = $1
+		  AND client_id = $2
+		  AND remaining_amount > 0
+		  AND status NOT IN ('draft', 'cancelled')
+		ORDER BY id = $3 DESC, invoice_date ASC, id ASC
+		FOR UPDATE
+	`, companyID, clientID, linkedInvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	remaining := amount
+	if amount == 0 {
+		remaining = balance
+	}
+
+	var allocations []models.CreditNoteAllocationDTO
+
+	for rows.Next() && remaining > 0 {
+		var invoiceID int64
+		var due money.Money
+
+		if err := rows.Scan(&invoiceID, &due); err != nil {
+			return nil, err
+		}
+
+		applied := money.Min(due, remaining)
+
+		allocations = append(allocations, models.CreditNoteAllocationDTO{
+			InvoiceID: invoiceID,
+			Amount:    applied,
+		})
+
+		remaining -= applied
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(allocations) == 0 {
+		return nil, errors.New("client has no open invoices to apply credit to")
+	}
+
+	if amount > 0 && remaining > 0 {
+		return nil, errors.New("amount exceeds outstanding balance")
+	}
+
+	return allocations, nil
+}
+
+// FetchCreditNotePDFData loads a credit note onto the invoice layout. The
+// party and tax treatment come from the original invoice when the note is
+// raised against one, otherwise from the client's current billing address.
+// It also returns the note's reason.
+func FetchCreditNotePDFData(
+	db *sql.DB,
+	creditNoteID int64,
+) (pdf.InvoicePDFData, string, error) {
+
+	var data pdf.InvoicePDFData
+	var creditDate time.Time
+	var clientID int64
+	var invoiceID *int64
+	var cnType, reason, companyGSTIN string
+	var invoiceNumber *string
+	var invoiceDate *time.Time
+	var invoicePOS *string
+	var invoiceInter *bool
+
+	/* -----------------------------
+	   1️⃣ Fetch credit note + company
+	------------------------------ */
+	err := db.QueryRow(`
+		SELECT
+			cn.credit_number,
+			cn.credit_date,
+			cn.type,
+			COALESCE(cn.reason, ''),
+			cn.subtotal,
+			cn.tax,
+			cn.total,
+			cn.client_id,
+			cn.invoice_id,
+			i.invoice_number,
+			i.invoice_date,
+			i.place_of_supply,
+			i.is_inter_state,
+			c.name,
+			COALESCE(c.phone, ''),
+			COALESCE(c.address, ''),
+			COALESCE(c.city, ''),
+			COALESCE(c.state, ''),
+			COALESCE(c.pincode, ''),
+			COALESCE(c.gst, '')
+		FROM credit_notes cn
+		JOIN companies c ON c.id = cn.company_id
+		LEFT JOIN invoices i ON i.id = cn.invoice_id
+		WHERE cn.id = $1
+	`, creditNoteID).Scan(
+		&data.Invoice.InvoiceNumber,
+		&creditDate,
+		&cnType,
+		&reason,
+		&data.Invoice.Subtotal,
+		&data.Invoice.Tax,
+		&data.Invoice.Total,
+		&clientID,
+		&invoiceID,
+		&invoiceNumber,
+		&invoiceDate,
+		&invoicePOS,
+		&invoiceInter,
+		&data.Company.Name,
+		&data.Company.Phone,
+		&data.CompanyAddress.Line1,
+		&data.CompanyAddress.City,
+		&data.CompanyAddress.State,
+		&data.CompanyAddress.Zip,
+		&companyGSTIN,
+	)
+	if err != nil {
+		return data, "", fmt.Errorf("fetch credit note: %w", err)
+	}
+
+	data.Invoice.InvoiceDate = creditDate.Format("02-01-2006")
+	data.CompanyAddress.Name = data.Company.Name
+	data.CompanyAddress.Country = "India"
+
+	if invoiceNumber != nil {
+		data.Invoice.AgainstNumber = *invoiceNumber
+	}
+	if invoiceDate != nil {
+		data.Invoice.AgainstDate = invoiceDate.Format("02-01-2006")
+	}
+
+	/* -----------------------------
+	   2️⃣ Party: invoice snapshot, else live client addresses
+	------------------------------ */
+	var rows *sql.Rows
+	if invoiceID != nil {
+		rows, err = db.Query(`
+			SELECT type, COALESCE(name, ''), line1, COALESCE(city, ''),
+			       COALESCE(state, ''), COALESCE(country, ''),
+			       COALESCE(postal_code, ''), COALESCE(gst_number, '')
+			FROM invoice_addresses
+			WHERE invoice_id = $1
+		`, *invoiceID)
+	} else {
+		rows, err = db.Query(`
+			SELECT type, COALESCE(name, ''), line1, COALESCE(city, ''),
+			       COALESCE(state, ''), COALESCE(country, ''),
+			       COALESCE(postal_code, ''), COALESCE(gst_number, '')
+			FROM client_addresses
+			WHERE client_id = $1
+		`, clientID)
+	}
+	if err != nil {
+		return data, "", fmt.Errorf("fetch addresses: %w", err)
+	}
+	defer rows.Close()
+
+	var billingGSTIN string
+	for rows.Next() {
+		var addrType, gstin string
+		var addr pdf.Address
+
+		if err := rows.Scan(
+			&addrType,
+			&addr.Name,
+			&addr.Line1,
+			&addr.City,
+			&addr.State,
+			&addr.Country,
+			&addr.Zip,
+			&gstin,
+		); err != nil {
+			return data, "", err
+		}
+
+		if addrType == "billing" {
+			data.ClientBilling = addr
+			billingGSTIN = gstin
+		} else if addrType == "shipping" {
+			data.ClientShipping = &addr
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return data, "", err
+	}
+
+	/* -----------------------------
+	   3️⃣ Tax treatment follows the original supply
+	------------------------------ */
+	if invoiceInter != nil {
+		data.Invoice.IsInterState = *invoiceInter
+		if invoicePOS != nil {
+			data.Invoice.PlaceOfSupply = utils.PlaceOfSupplyLabel(*invoicePOS)
+		}
+	} else {
+		supplierState := utils.ResolveStateCode(companyGSTIN, data.CompanyAddress.State)
+		pos := utils.ResolveStateCode(billingGSTIN, data.ClientBilling.State)
+		data.Invoice.IsInterState = utils.IsInterState(supplierState, pos)
+		data.Invoice.PlaceOfSupply = utils.PlaceOfSupplyLabel(pos)
+	}
+
+	/* -----------------------------
+	   4️⃣ Lines: returned goods, or one line for the credited value
+	------------------------------ */
+	if cnType != "return" {
+		name := "Credit adjustment"
+		if cnType == "discount" {
+			name = "Discount allowed"
+		}
+		data.Items = append(data.Items, pdf.InvoiceItem{
+			Name:  name,
+			Qty:   1,
+			Rate:  data.Invoice.Subtotal,
+			Total: data.Invoice.Total,
+		})
+		return data, reason, nil
+	}
+
+	itemRows, err := db.Query(`
+		SELECT
+			it.name,
+			COALESCE(it.hsn_code, ''),
+			cni.qty,
+			cni.rate,
+			cni.tax_rate,
+			cni.total
+		FROM credit_note_items cni
+		JOIN items it ON it.id = cni.item_id
+		WHERE cni.credit_note_id = $1
+		ORDER BY cni.id
+	`, creditNoteID)
+	if err != nil {
+		return data, "", fmt.Errorf("fetch items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item pdf.InvoiceItem
+		var qty float64
+
+		if err := itemRows.Scan(
+			&item.Name,
+			&item.HSNCode,
+			&qty,
+			&item.Rate,
+			&item.TaxRate,
+			&item.Total,
+		); err != nil {
+			return data, "", err
+		}
+
+		// return quantities are whole units (see CreateTx)
+		item.Qty = int(math.Round(qty))
+		item.CGST, item.SGST, item.IGST = utils.SplitTax(
+			item.Rate.Mul(qty).Percent(item.TaxRate),
+			data.Invoice.IsInterState,
+		)
+
+		data.Items = append(data.Items, item)
+	}
+
+	return data, reason, itemRows.Err()
 }
@@ -0,0 +1,520 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	"invo-server/internal/pdf"
+	utils "invo-server/internal/util"
+)
+
+var ErrDebitNoteNotFound = errors.New("debit note not found")
+
+type DebitNoteService struct {
+	db     *sql.DB
+	ledger *LedgerService
+}
+
+func NewDebitNoteService(db *sql.DB, ledger *LedgerService) *DebitNoteService {
+	return &DebitNoteService{db: db, ledger: ledger}
+}
+
+// debitNoteLine is a validated line ready to insert.
+type debitNoteLine struct {
+	itemID      *int64
+	description string
+	hsnCode     string
+	qty         float64
+	rate        money.Money
+	taxRate     float64
+	base        money.Money
+	tax         money.Money
+}
+
+// CreateTx raises a debit note against an issued invoice that was
+// under-billed. It is the mirror of a credit note: the client ledger is
+// debited with the extra amount and the invoice's remaining amount goes up
+// by the same, so payments settle the note along with the invoice. Any
+// unapplied payment credit the client holds is used against it straight
+// away. No goods move, so stock is untouched.
+func (s *DebitNoteService) CreateTx(
+	tx *sql.Tx,
+	userID int,
+	req models.DebitNoteRequestDTO,
+) (*models.DebitNote, error) {
+
+	// 1️⃣ Validate input
+	debitDate := time.Now()
+	if req.DebitDate != "" {
+		var err error
+		debitDate, err = time.Parse("2006-01-02", req.DebitDate)
+		if err != nil {
+			return nil, errors.New("invalid debit_date (YYYY-MM-DD)")
+		}
+	}
+
+	if len(req.Items) == 0 && req.Amount <= 0 {
+		return nil, errors.New("items or amount required for debit note")
+	}
+	if len(req.Items) > 0 && req.Amount != 0 {
+		return nil, errors.New("give either items or amount, not both")
+	}
+	if req.TaxRate < 0 {
+		return nil, errors.New("tax_rate must not be negative")
+	}
+
+	// 2️⃣ Original invoice
+	dn := &models.DebitNote{
+		InvoiceID: req.InvoiceID,
+		DebitDate: debitDate,
+		Reason:    strings.TrimSpace(req.Reason),
+	}
+
+	var status string
+	var invoiceDate time.Time
+	err := tx.QueryRow(`
+		SELECT i.company_id, i.client_id, i.invoice_number, i.status, i.invoice_date
+		FROM invoices i
+		JOIN companies c ON c.id = i.company_id
+		WHERE i.id = $1 AND c.user_id = $2
+		FOR UPDATE OF i
+	`, req.InvoiceID, userID).Scan(&dn.CompanyID, &dn.ClientID, &dn.InvoiceNumber, &status, &invoiceDate)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "draft" || status == "cancelled" {
+		return nil, fmt.Errorf("cannot raise a debit note against a %s invoice", status)
+	}
+	if debitDate.Before(invoiceDate) {
+		return nil, errors.New("debit_date must not be before the invoice date")
+	}
+
+	// 3️⃣ Lines (tax rounded per line, like invoices)
+	var lines []debitNoteLine
+
+	for _, it := range req.Items {
+		if it.Rate < 0 || it.TaxRate < 0 {
+			return nil, errors.New("rate and tax_rate must not be negative")
+		}
+		// invoices are billed in whole units
+		if it.Qty != math.Trunc(it.Qty) {
+			return nil, errors.New("debit note qty must be a whole number")
+		}
+
+		itemID := it.ItemID
+		l := debitNoteLine{
+			itemID:  &itemID,
+			qty:     it.Qty,
+			rate:    it.Rate,
+			taxRate: it.TaxRate,
+		}
+
+		err := tx.QueryRow(`
+			SELECT it.name, COALESCE(ii.hsn_code, '')
+			FROM invoice_items ii
+			JOIN items it ON it.id = ii.item_id
+			WHERE ii.invoice_id = $1 AND ii.item_id = $2
+			LIMIT 1
+		`, req.InvoiceID, it.ItemID).Scan(&l.description, &l.hsnCode)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("item %d is not on invoice %s", it.ItemID, dn.InvoiceNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		l.base = it.Rate.Mul(it.Qty)
+		l.tax = l.base.Percent(it.TaxRate)
+		lines = append(lines, l)
+	}
+
+	if len(lines) == 0 {
+		description := dn.Reason
+		if description == "" {
+			description = "Value revision of invoice " + dn.InvoiceNumber
+		}
+		lines = append(lines, debitNoteLine{
+			description: description,
+			qty:         1,
+			rate:        req.Amount,
+			taxRate:     req.TaxRate,
+			base:        req.Amount,
+			tax:         req.Amount.Percent(req.TaxRate),
+		})
+	}
+
+	for _, l := range lines {
+		dn.Subtotal += l.base
+		dn.Tax += l.tax
+	}
+	dn.Total = dn.Subtotal + dn.Tax
+	if dn.Total <= 0 {
+		return nil, errors.New("debit note total must be positive")
+	}
+
+	// 4️⃣ Number and insert
+	debitNumber, err := nextDocumentNumberTx(tx, dn.CompanyID, DocDebitNote, debitDate)
+	if err != nil {
+		return nil, err
+	}
+	dn.DebitNumber = debitNumber
+
+	err = tx.QueryRow(`
+		INSERT INTO debit_notes (
+			company_id, client_id, invoice_id, debit_number, debit_date,
+			reason, subtotal, tax, total, created_by
+		)
+		VALUES ($1,$2,$3,$4,$5,NULLIF($6, ''),$7,$8,$9,$10)
+		RETURNING id, created_at
+	`,
+		dn.CompanyID,
+		dn.ClientID,
+		dn.InvoiceID,
+		debitNumber,
+		debitDate,
+		dn.Reason,
+		dn.Subtotal,
+		dn.Tax,
+		dn.Total,
+		userID,
+	).Scan(&dn.ID, &dn.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range lines {
+		item := models.DebitNoteItem{
+			ItemID:      l.itemID,
+			Description: l.description,
+			HSNCode:     l.hsnCode,
+			Qty:         l.qty,
+			Rate:        l.rate,
+			TaxRate:     l.taxRate,
+			Total:       l.base + l.tax,
+		}
+
+		err = tx.QueryRow(`
+			INSERT INTO debit_note_items
+				(debit_note_id, item_id, description, hsn_code, qty, rate, tax_rate, total)
+			VALUES ($1,$2,$3,NULLIF($4, ''),$5,$6,$7,$8)
+			RETURNING id
+		`,
+			dn.ID,
+			l.itemID,
+			l.description,
+			l.hsnCode,
+			l.qty,
+			l.rate,
+			l.taxRate,
+			item.Total,
+		).Scan(&item.ID)
+		if err != nil {
+			return nil, err
+		}
+		dn.Items = append(dn.Items, item)
+	}
+
+	// 5️⃣ Ledger entry (debit: the client owes more)
+	err = s.ledger.AddEntryTx(
+		tx,
+		dn.CompanyID,
+		dn.ClientID,
+		"DEBIT_NOTE",
+		dn.ID,
+		dn.Total,
+		0,
+		"Debit note "+debitNumber+" against invoice "+dn.InvoiceNumber,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ The invoice is owed the extra amount (a paid invoice reopens)
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET
+			remaining_amount = remaining_amount + $1,
+			status = CASE WHEN status = 'paid' THEN 'partial' ELSE status END,
+			updated_at = NOW()
+		WHERE id = $2
+	`, dn.Total, dn.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := applyUnappliedPaymentsTx(tx, dn.CompanyID, dn.ClientID, dn.InvoiceID, dn.Total); err != nil {
+		return nil, err
+	}
+
+	return dn, nil
+}
+
+const debitNoteSelectSQL = `
+	SELECT
+		dn.id, dn.company_id, dn.client_id, cl.name,
+		dn.invoice_id, i.invoice_number, dn.debit_number, dn.debit_date,
+		COALESCE(dn.reason, ''), dn.subtotal, dn.tax, dn.total, dn.created_at
+	FROM debit_notes dn
+	JOIN clients cl ON cl.id = dn.client_id
+	JOIN invoices i ON i.id = dn.invoice_id`
+
+func scanDebitNote(row rowScanner) (*models.DebitNote, error) {
+	var dn models.DebitNote
+	err := row.Scan(
+		&dn.ID,
+		&dn.CompanyID,
+		&dn.ClientID,
+		&dn.ClientName,
+		&dn.InvoiceID,
+		&dn.InvoiceNumber,
+		&dn.DebitNumber,
+		&dn.DebitDate,
+		&dn.Reason,
+		&dn.Subtotal,
+		&dn.Tax,
+		&dn.Total,
+		&dn.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &dn, nil
+}
+
+// GetByID returns a debit note with its lines.
+func (s *DebitNoteService) GetByID(userID int, debitNoteID int64) (*models.DebitNote, error) {
+	dn, err := scanDebitNote(s.db.QueryRow(debitNoteSelectSQL+`
+		JOIN companies c ON c.id = dn.company_id
+		WHERE dn.id = $1 AND c.user_id = $2
+	`, debitNoteID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrDebitNoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, item_id, COALESCE(description, ''), COALESCE(hsn_code, ''),
+		       qty, rate, tax_rate, total
+		FROM debit_note_items
+		WHERE debit_note_id = $1
+		ORDER BY id
+	`, debitNoteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dn.Items = []models.DebitNoteItem{}
+	for rows.Next() {
+		var it models.DebitNoteItem
+		if err := rows.Scan(
+			&it.ID,
+			&it.ItemID,
+			&it.Description,
+			&it.HSNCode,
+			&it.Qty,
+			&it.Rate,
+			&it.TaxRate,
+			&it.Total,
+		); err != nil {
+			return nil, err
+		}
+		dn.Items = append(dn.Items, it)
+	}
+
+	return dn, rows.Err()
+}
+
+// List returns the company's debit notes, newest first, without lines.
+func (s *DebitNoteService) List(companyID int64, limit, offset int) ([]models.DebitNote, error) {
+	rows, err := s.db.Query(debitNoteSelectSQL+`
+		WHERE dn.company_id = $1
+		ORDER BY dn.debit_date DESC, dn.id DESC
+		LIMIT $2 OFFSET $3
+	`, companyID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []models.DebitNote{}
+	for rows.Next() {
+		dn, err := scanDebitNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, *dn)
+	}
+
+	return notes, rows.Err()
+}
+
+// FetchDebitNotePDFData loads a debit note onto the invoice layout, with
+// the party and tax treatment of the original invoice. It also returns the
+// note's reason.
+func FetchDebitNotePDFData(
+	db *sql.DB,
+	debitNoteID int64,
+) (pdf.InvoicePDFData, string, error) {
+
+	var data pdf.InvoicePDFData
+	var debitDate, invoiceDate time.Time
+	var invoiceID int64
+	var reason string
+	var pos *string
+
+	/* -----------------------------
+	   1️⃣ Fetch debit note + invoice + company
+	------------------------------ */
+	err := db.QueryRow(`
+		SELECT
+			dn.debit_number,
+			dn.debit_date,
+			COALESCE(dn.reason, ''),
+			dn.subtotal,
+			dn.tax,
+			dn.total,
+			dn.invoice_id,
+			i.invoice_number,
+			i.invoice_date,
+			i.place_of_supply,
+			i.is_inter_state,
+			c.name,
+			COALESCE(c.phone, ''),
+			COALESCE(c.address, ''),
+			COALESCE(c.city, ''),
+			COALESCE(c.state, ''),
+			COALESCE(c.pincode, '')
+		FROM debit_notes dn
+		JOIN invoices i ON i.id = dn.invoice_id
+		JOIN companies c ON c.id = dn.company_id
+		WHERE dn.id = $1
+	`, debitNoteID).Scan(
+		&data.Invoice.InvoiceNumber,
+		&debitDate,
+		&reason,
+		&data.Invoice.Subtotal,
+		&data.Invoice.Tax,
+		&data.Invoice.Total,
+		&invoiceID,
+		&data.Invoice.AgainstNumber,
+		&invoiceDate,
+		&pos,
+		&data.Invoice.IsInterState,
+		&data.Company.Name,
+		&data.Company.Phone,
+		&data.CompanyAddress.Line1,
+		&data.CompanyAddress.City,
+		&data.CompanyAddress.State,
+		&data.CompanyAddress.Zip,
+	)
+	if err != nil {
+		return data, "", fmt.Errorf("fetch debit note: %w", err)
+	}
+
+	data.Invoice.InvoiceDate = debitDate.Format("02-01-2006")
+	data.Invoice.AgainstDate = invoiceDate.Format("02-01-2006")
+	if pos != nil {
+		data.Invoice.PlaceOfSupply = utils.PlaceOfSupplyLabel(*pos)
+	}
+	data.CompanyAddress.Name = data.Company.Name
+	data.CompanyAddress.Country = "India"
+
+	/* -----------------------------
+	   2️⃣ Party as billed on the invoice
+	------------------------------ */
+	rows, err := db.Query(`
+		SELECT type, COALESCE(name, ''), line1, COALESCE(city, ''),
+		       COALESCE(state, ''), COALESCE(country, ''), COALESCE(postal_code, '')
+		FROM invoice_addresses
+		WHERE invoice_id = $1
+	`, invoiceID)
+	if err != nil {
+		return data, "", fmt.Errorf("fetch addresses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var addrType string
+		var addr pdf.Address
+
+		if err := rows.Scan(
+			&addrType,
+			&addr.Name,
+			&addr.Line1,
+			&addr.City,
+			&addr.State,
+			&addr.Country,
+			&addr.Zip,
+		); err != nil {
+			return data, "", err
+		}
+
+		if addrType == "billing" {
+			data.ClientBilling = addr
+		} else if addrType == "shipping" {
+			data.ClientShipping = &addr
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return data, "", err
+	}
+
+	/* -----------------------------
+	   3️⃣ Lines
+	------------------------------ */
+	itemRows, err := db.Query(`
+		SELECT
+			COALESCE(description, ''),
+			COALESCE(hsn_code, ''),
+			qty,
+			rate,
+			tax_rate,
+			total
+		FROM debit_note_items
+		WHERE debit_note_id = $1
+		ORDER BY id
+	`, debitNoteID)
+	if err != nil {
+		return data, "", fmt.Errorf("fetch items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item pdf.InvoiceItem
+		var qty float64
+
+		if err := itemRows.Scan(
+			&item.Name,
+			&item.HSNCode,
+			&qty,
+			&item.Rate,
+			&item.TaxRate,
+			&item.Total,
+		); err != nil {
+			return data, "", err
+		}
+
+		item.Qty = int(math.Round(qty))
+		item.CGST, item.SGST, item.IGST = utils.SplitTax(
+			item.Rate.Mul(qty).Percent(item.TaxRate),
+			data.Invoice.IsInterState,
+		)
+
+		data.Items = append(data.Items, item)
+	}
+
+	return data, reason, itemRows.Err()
+}
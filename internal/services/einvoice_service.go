@@ -0,0 +1,389 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	utils "invo-server/internal/util"
+)
+
+var (
+	ErrIRNAlreadyGenerated = errors.New("IRN already generated for this invoice")
+	ErrIRNAlreadyCancelled = errors.New("IRN was cancelled; this invoice number cannot be registered again")
+	ErrIRNNotGenerated     = errors.New("invoice has no active IRN")
+	ErrIRNCancelWindow     = errors.New("IRN can only be cancelled within 24 hours of generation")
+	ErrIRNActive           = errors.New("invoice has an active IRN; cancel the IRN first")
+	ErrIRPRequest          = errors.New("IRP request failed")
+	ErrIRNCancelReason     = errors.New("reason_code must be 1 (duplicate), 2 (data entry mistake), 3 (order cancelled) or 4 (others)")
+)
+
+// EInvoiceValidationError lists everything that stops an invoice from
+// being registered, so the user can fix it in one go.
+type EInvoiceValidationError struct {
+	Problems []string
+}
+
+func (e *EInvoiceValidationError) Error() string {
+	return "invoice is not ready for e-invoicing: " + strings.Join(e.Problems, "; ")
+}
+
+var irnCancelReasons = map[string]bool{"1": true, "2": true, "3": true, "4": true}
+
+type dbQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+type EInvoiceService struct {
+	db  *sql.DB
+	irp IRPClient
+}
+
+func NewEInvoiceService(db *sql.DB, irp IRPClient) *EInvoiceService {
+	return &EInvoiceService{db: db, irp: irp}
+}
+
+func parsePin(pin string) (int, bool) {
+	pin = strings.TrimSpace(pin)
+	if len(pin) != 6 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(pin)
+	return n, err == nil && n >= 100000
+}
+
+// BuildPayload renders an issued invoice as INV-01 JSON without
+// registering it.
+func (s *EInvoiceService) BuildPayload(userID, invoiceID int) (*models.EInvoicePayload, error) {
+	return buildEInvoicePayload(s.db, userID, invoiceID)
+}
+
+func buildEInvoicePayload(q dbQuerier, userID, invoiceID int) (*models.EInvoicePayload, error) {
+	var (
+		number, status, pos               string
+		invDate                           time.Time
+		subtotal, cgst, sgst, igst, total money.Money
+		sellerPin                         string
+		problems                          []string
+	)
+
+	p := &models.EInvoicePayload{
+		Version: "1.1",
+		TranDtls: models.EInvoiceTranDtls{
+			TaxSch:      "GST",
+			SupTyp:      "B2B",
+			RegRev:      "N",
+			IgstOnIntra: "N",
+		},
+	}
+
+	// 1️⃣ Invoice + seller
+	err := q.QueryRow(`
+		SELECT
+			i.invoice_number,
+			i.invoice_date,
+			i.status,
+			COALESCE(i.place_of_supply, ''),
+			i.subtotal,
+			i.cgst,
+			i.sgst,
+			i.igst,
+			i.total,
+			c.name,
+			COALESCE(c.gst, ''),
+			COALESCE(c.address, ''),
+			COALESCE(c.city, ''),
+			COALESCE(c.state, ''),
+			COALESCE(c.pincode, ''),
+			COALESCE(c.phone, '')
+		FROM invoices i
+		JOIN companies c ON c.id = i.company_id
+		WHERE i.id = $1 AND i.user_id = $2
+	`, invoiceID, userID).Scan(
+		&number,
+		&invDate,
+		&status,
+		&pos,
+		&subtotal,
+		&cgst,
+		&sgst,
+		&igst,
+		&total,
+		&p.SellerDtls.LglNm,
+		&p.SellerDtls.Gstin,
+		&p.SellerDtls.Addr1,
+		&p.SellerDtls.Loc,
+		&p.SellerDtls.Stcd,
+		&sellerPin,
+		&p.SellerDtls.Ph,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "draft" || status == "cancelled" {
+		return nil, &EInvoiceValidationError{Problems: []string{"only issued invoices can be e-invoiced"}}
+	}
+
+	p.DocDtls = models.EInvoiceDocDtls{Typ: "INV", No: number, Dt: invDate.Format("02/01/2006")}
+	if len(number) > 16 {
+		problems = append(problems, "invoice number is longer than 16 characters")
+	}
+
+	p.SellerDtls.Gstin = strings.ToUpper(strings.TrimSpace(p.SellerDtls.Gstin))
+	if !utils.ValidGSTIN(p.SellerDtls.Gstin) {
+		problems = append(problems, "company GSTIN is missing or invalid")
+	}
+	p.SellerDtls.Stcd = utils.ResolveStateCode(p.SellerDtls.Gstin, p.SellerDtls.Stcd)
+	if pin, ok := parsePin(sellerPin); ok {
+		p.SellerDtls.Pin = pin
+	} else {
+		problems = append(problems, "company pincode must be 6 digits")
+	}
+
+	// 2️⃣ Buyer (and ship-to) from the invoice's address snapshot
+	rows, err := q.Query(`
+		SELECT
+			type,
+			COALESCE(name, ''),
+			COALESCE(line1, ''),
+			COALESCE(line2, ''),
+			COALESCE(city, ''),
+			COALESCE(state, ''),
+			COALESCE(postal_code, ''),
+			COALESCE(gst_number, '')
+		FROM invoice_addresses
+		WHERE invoice_id = $1
+	`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var addrType, pin string
+		var party models.EInvoiceParty
+		if err := rows.Scan(
+			&addrType,
+			&party.LglNm,
+			&party.Addr1,
+			&party.Addr2,
+			&party.Loc,
+			&party.Stcd,
+			&pin,
+			&party.Gstin,
+		); err != nil {
+			return nil, err
+		}
+
+		party.Gstin = strings.ToUpper(strings.TrimSpace(party.Gstin))
+		party.Stcd = utils.ResolveStateCode(party.Gstin, party.Stcd)
+		n, pinOK := parsePin(pin)
+		party.Pin = n
+
+		if addrType == "billing" {
+			if !utils.ValidGSTIN(party.Gstin) {
+				problems = append(problems, "buyer GSTIN is missing or invalid (e-invoices are B2B only)")
+			}
+			if !pinOK {
+				problems = append(problems, "buyer pincode must be 6 digits")
+			}
+			party.Pos = pos
+			if party.Pos == "" {
+				party.Pos = party.Stcd
+			}
+			p.BuyerDtls = party
+		} else if addrType == "shipping" && pinOK {
+			p.ShipDtls = &party
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if p.BuyerDtls.LglNm == "" && p.BuyerDtls.Addr1 == "" {
+		problems = append(problems, "invoice has no billing address")
+	}
+
+	// 3️⃣ Items
+	itemRows, err := q.Query(`
+		SELECT
+			COALESCE(it.name, ''),
+			COALESCE(ii.hsn_code, ''),
+			COALESCE(it.unit, ''),
+			ii.qty,
+			ii.rate,
+			COALESCE(ii.discount, 0),
+			ii.tax_rate,
+			ii.cgst_amount,
+			ii.sgst_amount,
+			ii.igst_amount,
+			ii.total
+		FROM invoice_items ii
+		LEFT JOIN items it ON it.id = ii.item_id
+		WHERE ii.invoice_id = $1
+		ORDER BY ii.id
+	`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item models.EInvoiceItem
+		var unit string
+		if err := itemRows.Scan(
+			&item.PrdDesc,
+			&item.HsnCd,
+			&unit,
+			&item.Qty,
+			&item.UnitPrice,
+			&item.Discount,
+			&item.GstRt,
+			&item.CgstAmt,
+			&item.SgstAmt,
+			&item.IgstAmt,
+			&item.TotItemVal,
+		); err != nil {
+			return nil, err
+		}
+
+		item.SlNo = strconv.Itoa(len(p.ItemList) + 1)
+		item.Unit = utils.UQCCode(unit)
+		item.TotAmt = item.UnitPrice.Mul(item.Qty)
+		item.AssAmt = item.TotAmt - item.Discount
+
+		// SAC codes (services) all start with 99
+		item.IsServc = "N"
+		if strings.HasPrefix(item.HsnCd, "99") {
+			item.IsServc = "Y"
+		}
+
+		if !utils.ValidHSN(item.HsnCd) {
+			problems = append(problems, fmt.Sprintf("item %s (%s) has no valid HSN/SAC code", item.SlNo, item.PrdDesc))
+		}
+
+		p.ItemList = append(p.ItemList, item)
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, err
+	}
+
+	p.ValDtls = models.EInvoiceValueDtls{
+		AssVal:    subtotal,
+		CgstVal:   cgst,
+		SgstVal:   sgst,
+		IgstVal:   igst,
+		TotInvVal: total,
+	}
+
+	if len(problems) > 0 {
+		return p, &EInvoiceValidationError{Problems: problems}
+	}
+	return p, nil
+}
+
+// GenerateTx registers the invoice with the IRP and stores the IRN,
+// acknowledgement and signed QR on it.
+func (s *EInvoiceService) GenerateTx(tx *sql.Tx, userID, invoiceID int) (*models.IRNResult, error) {
+	var irnStatus string
+	err := tx.QueryRow(`
+		SELECT COALESCE(irn_status, '')
+		FROM invoices
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, invoiceID, userID).Scan(&irnStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	switch irnStatus {
+	case "generated":
+		return nil, ErrIRNAlreadyGenerated
+	case "cancelled":
+		// the IRP rejects a second IRN for the same document number
+		return nil, ErrIRNAlreadyCancelled
+	}
+
+	payload, err := buildEInvoicePayload(tx, userID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.irp.GenerateIRN(*payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIRPRequest, err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET irn = $2,
+			irn_ack_no = $3,
+			irn_ack_date = $4,
+			irn_signed_qr = $5,
+			irn_status = 'generated',
+			updated_at = NOW()
+		WHERE id = $1
+	`, invoiceID, result.IRN, result.AckNo, result.AckDate, result.SignedQRCode)
+	if err != nil {
+		return nil, fmt.Errorf("save irn: %w", err)
+	}
+
+	return result, nil
+}
+
+// CancelTx cancels the invoice's IRN. The IRP only allows this within 24
+// hours; after that the invoice has to be reversed with a credit note.
+func (s *EInvoiceService) CancelTx(
+	tx *sql.Tx,
+	userID, invoiceID int,
+	req models.CancelIRNRequestDTO,
+) error {
+
+	if !irnCancelReasons[req.ReasonCode] {
+		return ErrIRNCancelReason
+	}
+
+	var (
+		irn, irnStatus string
+		ackDate        sql.NullTime
+	)
+	err := tx.QueryRow(`
+		SELECT COALESCE(irn, ''), COALESCE(irn_status, ''), irn_ack_date
+		FROM invoices
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, invoiceID, userID).Scan(&irn, &irnStatus, &ackDate)
+	if err != nil {
+		return err
+	}
+
+	if irnStatus != "generated" {
+		return ErrIRNNotGenerated
+	}
+
+	if ackDate.Valid && time.Since(ackDate.Time) > 24*time.Hour {
+		return ErrIRNCancelWindow
+	}
+
+	cancelledAt, err := s.irp.CancelIRN(irn, req.ReasonCode, req.Remark)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIRPRequest, err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET irn_status = 'cancelled',
+			irn_cancelled_at = $2,
+			irn_cancel_reason = $3,
+			updated_at = NOW()
+		WHERE id = $1
+	`, invoiceID, cancelledAt, strings.TrimSpace(req.ReasonCode+" "+req.Remark))
+	return err
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"log"
 	"net/http"
+	"strings"
 )
 
 type EmailService struct {
@@ -100,6 +102,54 @@ func (s *EmailService) SendInvoiceEmail(
 	return s.send(toEmail, subject, html, attachments)
 }
 
+func (s *EmailService) SendCreditNoteEmail(
+	toEmail, toName, creditNumber string,
+	creditNotePDF []byte,
+) error {
+	subject := fmt.Sprintf("Credit Note %s from %s", creditNumber, s.fromName)
+
+	html := fmt.Sprintf(`
+		<h2>Credit Note %s</h2>
+		<p>Dear %s,</p>
+		<p>Please find your credit note attached.</p>
+		<br/>
+		<p>Regards,<br/>%s</p>
+	`, creditNumber, toName, s.fromName)
+
+	attachments := []resendAttachment{
+		{
+			Filename: fmt.Sprintf("credit-note-%s.pdf", creditNumber),
+			Content:  base64.StdEncoding.EncodeToString(creditNotePDF),
+		},
+	}
+
+	return s.send(toEmail, subject, html, attachments)
+}
+
+func (s *EmailService) SendPaymentReceiptEmail(
+	toEmail, toName, receiptNumber string,
+	receiptPDF []byte,
+) error {
+	subject := fmt.Sprintf("Payment Receipt %s from %s", receiptNumber, s.fromName)
+
+	html := fmt.Sprintf(`
+		<h2>Payment Receipt %s</h2>
+		<p>Dear %s,</p>
+		<p>Thank you for your payment. Please find your receipt attached.</p>
+		<br/>
+		<p>Regards,<br/>%s</p>
+	`, receiptNumber, toName, s.fromName)
+
+	attachments := []resendAttachment{
+		{
+			Filename: fmt.Sprintf("receipt-%s.pdf", receiptNumber),
+			Content:  base64.StdEncoding.EncodeToString(receiptPDF),
+		},
+	}
+
+	return s.send(toEmail, subject, html, attachments)
+}
+
 func (s *EmailService) SendOTPEmail(toEmail, code string) error {
 	subject := "Your Invo Billing Login Code"
 
@@ -171,3 +221,45 @@ func (s *EmailService) SendPasswordResetEmail(toEmail, code string) error {
 
 	return s.send(toEmail, subject, html, nil)
 }
+
+// LowStockDigestItem is one line of the low-stock digest.
+type LowStockDigestItem struct {
+	Name      string
+	Quantity  int
+	Threshold int
+}
+
+func (s *EmailService) SendLowStockDigestEmail(toEmail, companyName string, items []LowStockDigestItem) error {
+	subject := fmt.Sprintf("Low stock: %d item(s) at %s", len(items), companyName)
+
+	var rows strings.Builder
+	for _, it := range items {
+		fmt.Fprintf(&rows, `
+			<tr>
+				<td style="padding:8px;border-bottom:1px solid #eee;">%s</td>
+				<td style="padding:8px;border-bottom:1px solid #eee;text-align:right;">%d</td>
+				<td style="padding:8px;border-bottom:1px solid #eee;text-align:right;">%d</td>
+			</tr>`, html.EscapeString(it.Name), it.Quantity, it.Threshold)
+	}
+
+	body := fmt.Sprintf(`
+	<div style="font-family:Arial,sans-serif;max-width:560px;margin:0 auto;padding:32px;">
+		<h2 style="color:#1A1A1A;margin-bottom:8px;">Low Stock Alert</h2>
+		<p style="color:#666;margin-bottom:24px;">
+			These items at %s have fallen below their low-stock level:
+		</p>
+		<table style="width:100%%;border-collapse:collapse;font-size:14px;">
+			<tr style="background:#f5f5f5;">
+				<th style="padding:8px;text-align:left;">Item</th>
+				<th style="padding:8px;text-align:right;">In stock</th>
+				<th style="padding:8px;text-align:right;">Alert below</th>
+			</tr>%s
+		</table>
+		<p style="color:#999;font-size:12px;margin-top:24px;">
+			See the reorder report in Invo Billing for suggested quantities.
+		</p>
+	</div>
+	`, html.EscapeString(companyName), rows.String())
+
+	return s.send(toEmail, subject, body, nil)
+}
@@ -0,0 +1,508 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	utils "invo-server/internal/util"
+)
+
+// ewayBillThreshold is the consignment value above which an e-way bill is
+// required for movement of goods.
+const ewayBillThreshold = 50000 * money.Rupee
+
+var (
+	ErrEWayBillExists   = errors.New("e-way bill already generated for this invoice")
+	ErrEWayBillNotFound = errors.New("no e-way bill for this invoice")
+	ErrEWBRequest       = errors.New("e-way bill request failed")
+
+	vehicleNoPattern = regexp.MustCompile(`^[A-Z]{2}[0-9]{1,2}[A-Z]{0,3}[0-9]{4}$`)
+)
+
+// EWayBillValidationError lists every missing or invalid field at once.
+type EWayBillValidationError struct {
+	Problems []string
+}
+
+func (e *EWayBillValidationError) Error() string {
+	return "invoice is not ready for an e-way bill: " + strings.Join(e.Problems, "; ")
+}
+
+type EWayBillService struct {
+	db       *sql.DB
+	provider EWBProvider
+}
+
+func NewEWayBillService(db *sql.DB, provider EWBProvider) *EWayBillService {
+	return &EWayBillService{db: db, provider: provider}
+}
+
+// normalizeVehicleNo turns "ka 01-ab 1234" into "KA01AB1234".
+func normalizeVehicleNo(v string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(v)))
+}
+
+func stateCodeInt(code string) int {
+	n, _ := strconv.Atoi(code)
+	return n
+}
+
+// validateTransport checks Part-B of the e-way bill and copies it onto the
+// payload.
+func validateTransport(p *models.EWayBillPayload, req models.EWayBillRequestDTO) []string {
+	var problems []string
+
+	p.TransMode = req.TransMode
+	if p.TransMode == "" {
+		p.TransMode = "1"
+	}
+	if p.TransMode < "1" || p.TransMode > "4" || len(p.TransMode) != 1 {
+		problems = append(problems, "trans_mode must be 1 (road), 2 (rail), 3 (air) or 4 (ship)")
+	}
+
+	if req.DistanceKm <= 0 || req.DistanceKm > 4000 {
+		problems = append(problems, "distance_km must be between 1 and 4000")
+	}
+	p.TransDistance = strconv.Itoa(req.DistanceKm)
+
+	p.TransporterID = strings.ToUpper(strings.TrimSpace(req.TransporterID))
+	if p.TransporterID != "" && !utils.ValidGSTIN(p.TransporterID) {
+		problems = append(problems, "transporter_id must be a valid GSTIN or TRANSIN")
+	}
+	p.TransporterName = strings.TrimSpace(req.TransporterName)
+
+	p.VehicleType = req.VehicleType
+	if p.VehicleType == "" {
+		p.VehicleType = "R"
+	}
+	if p.VehicleType != "R" && p.VehicleType != "O" {
+		problems = append(problems, "vehicle_type must be R (regular) or O (over-dimensional cargo)")
+	}
+
+	p.VehicleNo = normalizeVehicleNo(req.VehicleNo)
+	if p.VehicleNo != "" && !vehicleNoPattern.MatchString(p.VehicleNo) {
+		problems = append(problems, "vehicle_no is not a valid registration number")
+	}
+
+	p.TransDocNo = strings.TrimSpace(req.TransDocNo)
+	if req.TransDocDate != "" {
+		d, err := time.Parse("2006-01-02", req.TransDocDate)
+		if err != nil {
+			problems = append(problems, "trans_doc_date must be YYYY-MM-DD")
+		} else {
+			p.TransDocDate = d.Format("02/01/2006")
+		}
+	}
+
+	switch p.TransMode {
+	case "1":
+		if p.VehicleNo == "" && p.TransporterID == "" {
+			problems = append(problems, "vehicle_no or transporter_id is required for road transport")
+		}
+	case "2", "3", "4":
+		if p.TransDocNo == "" || p.TransDocDate == "" {
+			problems = append(problems, "trans_doc_no and trans_doc_date are required for rail, air and ship")
+		}
+	}
+
+	return problems
+}
+
+func buildEWayBillPayload(
+	q dbQuerier,
+	userID, invoiceID int,
+	req models.EWayBillRequestDTO,
+) (*models.EWayBillPayload, error) {
+
+	var (
+		number, status, pos string
+		invDate             time.Time
+		interState          bool
+		fromState, fromPin  string
+		problems            []string
+	)
+
+	p := &models.EWayBillPayload{
+		SupplyType:      "O",
+		SubSupplyType:   "1",
+		DocType:         "INV",
+		TransactionType: 1,
+	}
+
+	// 1️⃣ Invoice + consignor
+	err := q.QueryRow(`
+		SELECT
+			i.invoice_number,
+			i.invoice_date,
+			i.status,
+			COALESCE(i.place_of_supply, ''),
+			i.is_inter_state,
+			c.name,
+			COALESCE(c.gst, ''),
+			COALESCE(c.address, ''),
+			COALESCE(c.city, ''),
+			COALESCE(c.state, ''),
+			COALESCE(c.pincode, '')
+		FROM invoices i
+		JOIN companies c ON c.id = i.company_id
+		WHERE i.id = $1 AND i.user_id = $2
+	`, invoiceID, userID).Scan(
+		&number,
+		&invDate,
+		&status,
+		&pos,
+		&interState,
+		&p.FromTrdName,
+		&p.FromGstin,
+		&p.FromAddr1,
+		&p.FromPlace,
+		&fromState,
+		&fromPin,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "draft" || status == "cancelled" {
+		return nil, &EWayBillValidationError{Problems: []string{"only issued invoices can have an e-way bill"}}
+	}
+
+	p.DocNo = number
+	p.DocDate = invDate.Format("02/01/2006")
+
+	p.FromGstin = strings.ToUpper(strings.TrimSpace(p.FromGstin))
+	if !utils.ValidGSTIN(p.FromGstin) {
+		problems = append(problems, "company GSTIN is missing or invalid")
+	}
+	p.FromStateCode = stateCodeInt(utils.ResolveStateCode(p.FromGstin, fromState))
+	p.ActFromStateCode = p.FromStateCode
+	if p.FromStateCode == 0 {
+		problems = append(problems, "company state is missing or unknown")
+	}
+	if pin, ok := parsePin(fromPin); ok {
+		p.FromPincode = pin
+	} else {
+		problems = append(problems, "company pincode must be 6 digits")
+	}
+
+	// 2️⃣ Consignee: bill-to from billing, ship-to (if any) decides the route
+	rows, err := q.Query(`
+		SELECT
+			type,
+			COALESCE(name, ''),
+			COALESCE(line1, ''),
+			COALESCE(line2, ''),
+			COALESCE(city, ''),
+			COALESCE(state, ''),
+			COALESCE(postal_code, ''),
+			COALESCE(gst_number, '')
+		FROM invoice_addresses
+		WHERE invoice_id = $1
+	`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type party struct {
+		name, line1, line2, city, state, pin, gst string
+	}
+	var billing, shipping *party
+
+	for rows.Next() {
+		var a party
+		var addrType string
+		if err := rows.Scan(&addrType, &a.name, &a.line1, &a.line2, &a.city, &a.state, &a.pin, &a.gst); err != nil {
+			return nil, err
+		}
+
+		if addrType == "billing" {
+			billing = &a
+		} else if addrType == "shipping" {
+			shipping = &a
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if billing == nil {
+		problems = append(problems, "invoice has no billing address")
+	} else {
+		p.ToTrdName = billing.name
+		p.ToGstin = strings.ToUpper(strings.TrimSpace(billing.gst))
+		if p.ToGstin == "" {
+			p.ToGstin = "URP" // unregistered person
+		} else if !utils.ValidGSTIN(p.ToGstin) {
+			problems = append(problems, "buyer GSTIN is invalid")
+		}
+
+		p.ToStateCode = stateCodeInt(pos)
+		if p.ToStateCode == 0 {
+			p.ToStateCode = stateCodeInt(utils.ResolveStateCode(p.ToGstin, billing.state))
+		}
+		if p.ToStateCode == 0 {
+			problems = append(problems, "buyer state is missing or unknown")
+		}
+
+		dest := billing
+		if shipping != nil {
+			dest = shipping
+			p.TransactionType = 2
+		}
+
+		p.ToAddr1 = dest.line1
+		p.ToAddr2 = dest.line2
+		p.ToPlace = dest.city
+		p.ActToStateCode = stateCodeInt(utils.StateCode(dest.state))
+		if p.ActToStateCode == 0 {
+			p.ActToStateCode = p.ToStateCode
+		}
+		if pin, ok := parsePin(dest.pin); ok {
+			p.ToPincode = pin
+		} else {
+			problems = append(problems, "delivery address pincode must be 6 digits")
+		}
+	}
+
+	// 3️⃣ Goods lines (services don't move, so SAC lines are left out)
+	itemRows, err := q.Query(`
+		SELECT
+			COALESCE(it.name, ''),
+			COALESCE(ii.hsn_code, ''),
+			COALESCE(it.unit, ''),
+			ii.qty,
+			ii.tax_rate,
+			ii.rate * ii.qty - COALESCE(ii.discount, 0),
+			ii.cgst_amount,
+			ii.sgst_amount,
+			ii.igst_amount
+		FROM invoice_items ii
+		LEFT JOIN items it ON it.id = ii.item_id
+		WHERE ii.invoice_id = $1
+		ORDER BY ii.id
+	`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var (
+			item             models.EWayBillItem
+			hsn, unit        string
+			rate             float64
+			cgst, sgst, igst money.Money
+		)
+		if err := itemRows.Scan(
+			&item.ProductName,
+			&hsn,
+			&unit,
+			&item.Quantity,
+			&rate,
+			&item.TaxableAmount,
+			&cgst,
+			&sgst,
+			&igst,
+		); err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(hsn, "99") {
+			continue
+		}
+
+		if !utils.ValidHSN(hsn) {
+			problems = append(problems, fmt.Sprintf("item %q has no valid HSN code", item.ProductName))
+		}
+		item.HsnCode, _ = strconv.Atoi(hsn)
+		item.ProductDesc = item.ProductName
+		item.QtyUnit = utils.UQCCode(unit)
+
+		if interState {
+			item.IgstRate = rate
+		} else {
+			item.CgstRate = rate / 2
+			item.SgstRate = rate / 2
+		}
+
+		p.TotalValue += item.TaxableAmount
+		p.CgstValue += cgst
+		p.SgstValue += sgst
+		p.IgstValue += igst
+
+		p.ItemList = append(p.ItemList, item)
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, err
+	}
+
+	p.TotInvValue = p.TotalValue + p.CgstValue + p.SgstValue + p.IgstValue + p.CessValue
+
+	if len(p.ItemList) == 0 {
+		problems = append(problems, "invoice has no goods lines (HSN codes starting 99 are services)")
+	} else if p.TotInvValue <= ewayBillThreshold {
+		problems = append(problems, fmt.Sprintf("goods value %s does not exceed the e-way bill threshold of %s", p.TotInvValue, ewayBillThreshold))
+	}
+
+	problems = append(problems, validateTransport(p, req)...)
+
+	if len(problems) > 0 {
+		return p, &EWayBillValidationError{Problems: problems}
+	}
+	return p, nil
+}
+
+// GenerateTx builds the e-way bill for an issued invoice, registers it
+// through the provider and records the EWB number.
+func (s *EWayBillService) GenerateTx(
+	tx *sql.Tx,
+	userID, invoiceID int,
+	req models.EWayBillRequestDTO,
+) (*models.EWayBill, error) {
+
+	// 1️⃣ Lock invoice so two requests can't both register it
+	var exists bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM eway_bills WHERE invoice_id = i.id)
+		FROM invoices i
+		WHERE i.id = $1 AND i.user_id = $2
+		FOR UPDATE
+	`, invoiceID, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEWayBillExists
+	}
+
+	// 2️⃣ Payload
+	payload, err := buildEWayBillPayload(tx, userID, invoiceID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3️⃣ Register
+	result, err := s.provider.GenerateEWB(*payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEWBRequest, err)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	bill := &models.EWayBill{
+		InvoiceID:       invoiceID,
+		EWBNo:           result.EWBNo,
+		EWBDate:         result.EWBDate,
+		ValidUntil:      result.ValidUntil,
+		TransMode:       payload.TransMode,
+		TransporterID:   payload.TransporterID,
+		TransporterName: payload.TransporterName,
+		VehicleNo:       payload.VehicleNo,
+		DistanceKm:      req.DistanceKm,
+		Payload:         *payload,
+	}
+
+	var transDocDate *string
+	if req.TransDocDate != "" {
+		transDocDate = &req.TransDocDate
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO eway_bills (
+			invoice_id, ewb_no, ewb_date, valid_until,
+			trans_mode, transporter_id, transporter_name,
+			vehicle_no, vehicle_type, distance_km,
+			trans_doc_no, trans_doc_date, payload
+		)
+		VALUES ($1,$2,$3,$4,$5,NULLIF($6,''),NULLIF($7,''),NULLIF($8,''),$9,$10,NULLIF($11,''),$12,$13)
+		RETURNING id, created_at
+	`,
+		invoiceID,
+		result.EWBNo,
+		result.EWBDate,
+		result.ValidUntil,
+		payload.TransMode,
+		payload.TransporterID,
+		payload.TransporterName,
+		payload.VehicleNo,
+		payload.VehicleType,
+		req.DistanceKm,
+		payload.TransDocNo,
+		transDocDate,
+		raw,
+	).Scan(&bill.ID, &bill.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("save e-way bill: %w", err)
+	}
+
+	return bill, nil
+}
+
+// Get returns the e-way bill recorded for an invoice.
+func (s *EWayBillService) Get(userID, invoiceID int) (*models.EWayBill, error) {
+	var (
+		bill models.EWayBill
+		raw  []byte
+	)
+
+	err := s.db.QueryRow(`
+		SELECT
+			e.id,
+			e.invoice_id,
+			e.ewb_no,
+			e.ewb_date,
+			e.valid_until,
+			e.trans_mode,
+			COALESCE(e.transporter_id, ''),
+			COALESCE(e.transporter_name, ''),
+			COALESCE(e.vehicle_no, ''),
+			e.distance_km,
+			e.payload,
+			e.created_at
+		FROM eway_bills e
+		JOIN invoices i ON i.id = e.invoice_id
+		WHERE e.invoice_id = $1 AND i.user_id = $2
+	`, invoiceID, userID).Scan(
+		&bill.ID,
+		&bill.InvoiceID,
+		&bill.EWBNo,
+		&bill.EWBDate,
+		&bill.ValidUntil,
+		&bill.TransMode,
+		&bill.TransporterID,
+		&bill.TransporterName,
+		&bill.VehicleNo,
+		&bill.DistanceKm,
+		&raw,
+		&bill.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrEWayBillNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, &bill.Payload); err != nil {
+		return nil, err
+	}
+
+	return &bill, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+
+	"invo-server/internal/models"
+)
+
+// EWBProvider registers e-way bills with the e-way bill system (directly
+// or through a GSP). Implementations must be safe for concurrent use.
+type EWBProvider interface {
+	GenerateEWB(payload models.EWayBillPayload) (*models.EWayBillResult, error)
+}
+
+// StubEWBProvider issues e-way bill numbers offline so the flow can run
+// without portal credentials. Numbers are derived from the supplier GSTIN
+// and document so retries return the same bill.
+type StubEWBProvider struct{}
+
+func NewStubEWBProvider() *StubEWBProvider {
+	return &StubEWBProvider{}
+}
+
+func (p *StubEWBProvider) GenerateEWB(payload models.EWayBillPayload) (*models.EWayBillResult, error) {
+	distance, err := strconv.Atoi(payload.TransDistance)
+	if err != nil {
+		return nil, fmt.Errorf("invalid distance %q", payload.TransDistance)
+	}
+
+	sum := sha256.Sum256([]byte(payload.FromGstin + payload.DocType + payload.DocNo + payload.DocDate))
+	n := binary.BigEndian.Uint64(sum[:8])%900_000_000_000 + 100_000_000_000
+
+	now := time.Now().Truncate(time.Second)
+
+	result := &models.EWayBillResult{
+		EWBNo:   strconv.FormatUint(n, 10),
+		EWBDate: now,
+	}
+
+	// Validity only starts once Part-B (vehicle or transporter) is filled
+	if payload.VehicleNo != "" || payload.TransporterID != "" {
+		until := ewbValidUntil(now, distance, payload.VehicleType)
+		result.ValidUntil = &until
+	}
+
+	return result, nil
+}
+
+// ewbValidUntil applies the rule 138(10) validity: one day per 200 km
+// (20 km for over-dimensional cargo). The first day runs until midnight of
+// the day after generation.
+func ewbValidUntil(from time.Time, distanceKm int, vehicleType string) time.Time {
+	perDay := 200
+	if vehicleType == "O" {
+		perDay = 20
+	}
+
+	days := (distanceKm + perDay - 1) / perDay
+	if days < 1 {
+		days = 1
+	}
+
+	y, m, d := from.Date()
+	return time.Date(y, m, d+days+1, 0, 0, 0, 0, from.Location()).Add(-time.Second)
+}
@@ -0,0 +1,607 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	utils "invo-server/internal/util"
+)
+
+// Inter-state sales to unregistered buyers above this invoice value go to
+// B2CL instead of B2CS.
+const b2clThreshold = 100000 * money.Rupee
+
+const gstr1Version = "GST3.0.4"
+
+// gstr1Doc is an invoice, credit note or debit note with its lines grouped by rate.
+type gstr1Doc struct {
+	id         int64
+	number     string
+	date       time.Time
+	value      money.Money
+	pos        string
+	interState bool
+	gstin      string
+	rates      []*models.GSTR1ItemDetail
+}
+
+func (d *gstr1Doc) addLine(rate float64, taxable, cgst, sgst, igst money.Money) {
+	for _, r := range d.rates {
+		if r.Rate == rate {
+			r.TaxableValue += taxable
+			r.CGST += cgst
+			r.SGST += sgst
+			r.IGST += igst
+			return
+		}
+	}
+	d.rates = append(d.rates, &models.GSTR1ItemDetail{
+		Rate:         rate,
+		TaxableValue: taxable,
+		CGST:         cgst,
+		SGST:         sgst,
+		IGST:         igst,
+	})
+}
+
+func (d *gstr1Doc) items() []models.GSTR1Item {
+	items := make([]models.GSTR1Item, 0, len(d.rates))
+	for i, r := range d.rates {
+		items = append(items, models.GSTR1Item{Num: i + 1, Detail: *r})
+	}
+	return items
+}
+
+func gstr1Date(t time.Time) string {
+	return t.Format("02-01-2006")
+}
+
+// GSTR1 builds the offline-tool JSON for one month. Issued invoices and
+// credit and debit notes dated in the period are included; drafts and cancelled
+// invoices are not. Anything that would make the portal reject or
+// misclassify a document is listed in Issues.
+func (s *ReportService) GSTR1(companyID int64, period string) (*models.GSTR1Export, error) {
+	from, to, fp, err := utils.ParseReturnPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.GSTR1Export{
+		Return: models.GSTR1Return{
+			FP:      fp,
+			Version: gstr1Version,
+			Hash:    "hash",
+		},
+		Issues: []models.GSTR1Issue{},
+	}
+
+	issue := func(docType string, id int64, number, field, msg string) {
+		export.Issues = append(export.Issues, models.GSTR1Issue{
+			DocumentType:   docType,
+			DocumentID:     id,
+			DocumentNumber: number,
+			Field:          field,
+			Message:        msg,
+		})
+	}
+
+	// 1️⃣ Supplier
+	var companyGSTIN, companyState string
+	err = s.db.QueryRow(`
+		SELECT COALESCE(gst, ''), COALESCE(state, '')
+		FROM companies
+		WHERE id = $1
+	`, companyID).Scan(&companyGSTIN, &companyState)
+	if err != nil {
+		return nil, err
+	}
+
+	companyGSTIN = strings.ToUpper(strings.TrimSpace(companyGSTIN))
+	export.Return.GSTIN = companyGSTIN
+	if !utils.ValidGSTIN(companyGSTIN) {
+		issue("company", companyID, "", "gstin", "company GSTIN is missing or invalid")
+	}
+	supplierState := utils.ResolveStateCode(companyGSTIN, companyState)
+
+	// 2️⃣ Invoices
+	invoices, err := s.gstr1Invoices(companyID, from, to, supplierState, issue)
+	if err != nil {
+		return nil, fmt.Errorf("gstr1 invoices: %w", err)
+	}
+
+	b2bIdx := map[string]int{}
+	b2clIdx := map[string]int{}
+	b2csIdx := map[string]int{}
+
+	// sign is 1 for supplies and debit notes, -1 for credit notes
+	addB2CS := func(d *gstr1Doc, sign money.Money) {
+		supplyType := "INTRA"
+		if d.interState {
+			supplyType = "INTER"
+		}
+		for _, r := range d.rates {
+			key := fmt.Sprintf("%s|%s|%v", supplyType, d.pos, r.Rate)
+			i, ok := b2csIdx[key]
+			if !ok {
+				i = len(export.Return.B2CS)
+				b2csIdx[key] = i
+				export.Return.B2CS = append(export.Return.B2CS, models.GSTR1B2CS{
+					SupplyType: supplyType,
+					Rate:       r.Rate,
+					Type:       "OE",
+					POS:        d.pos,
+				})
+			}
+			row := &export.Return.B2CS[i]
+			row.TaxableValue += sign * r.TaxableValue
+			row.CGST += sign * r.CGST
+			row.SGST += sign * r.SGST
+			row.IGST += sign * r.IGST
+		}
+	}
+
+	for _, d := range invoices {
+		inv := models.GSTR1Invoice{
+			Number: d.number,
+			Date:   gstr1Date(d.date),
+			Value:  d.value,
+			Items:  d.items(),
+		}
+
+		switch {
+		case d.gstin != "":
+			inv.POS = d.pos
+			inv.ReverseCharge = "N"
+			inv.InvoiceType = "R"
+
+			i, ok := b2bIdx[d.gstin]
+			if !ok {
+				i = len(export.Return.B2B)
+				b2bIdx[d.gstin] = i
+				export.Return.B2B = append(export.Return.B2B, models.GSTR1B2B{CTIN: d.gstin})
+			}
+			export.Return.B2B[i].Invoices = append(export.Return.B2B[i].Invoices, inv)
+
+		case d.interState && d.value > b2clThreshold:
+			i, ok := b2clIdx[d.pos]
+			if !ok {
+				i = len(export.Return.B2CL)
+				b2clIdx[d.pos] = i
+				export.Return.B2CL = append(export.Return.B2CL, models.GSTR1B2CL{POS: d.pos})
+			}
+			export.Return.B2CL[i].Invoices = append(export.Return.B2CL[i].Invoices, inv)
+
+		default:
+			addB2CS(d, 1)
+		}
+	}
+
+	// 3️⃣ Credit and debit notes: registered buyers go to CDNR, the rest
+	// reduce or add to B2CS
+	notes, err := s.gstr1CreditNotes(companyID, from, to, supplierState)
+	if err != nil {
+		return nil, fmt.Errorf("gstr1 credit notes: %w", err)
+	}
+
+	debitNotes, err := s.gstr1DebitNotes(companyID, from, to, supplierState)
+	if err != nil {
+		return nil, fmt.Errorf("gstr1 debit notes: %w", err)
+	}
+
+	cdnrIdx := map[string]int{}
+
+	addNote := func(d *gstr1Doc, noteType string, sign money.Money) {
+		if d.gstin == "" {
+			addB2CS(d, sign)
+			return
+		}
+
+		i, ok := cdnrIdx[d.gstin]
+		if !ok {
+			i = len(export.Return.CDNR)
+			cdnrIdx[d.gstin] = i
+			export.Return.CDNR = append(export.Return.CDNR, models.GSTR1CDNR{CTIN: d.gstin})
+		}
+		export.Return.CDNR[i].Notes = append(export.Return.CDNR[i].Notes, models.GSTR1Note{
+			Type:          noteType,
+			Number:        d.number,
+			Date:          gstr1Date(d.date),
+			Value:         d.value,
+			POS:           d.pos,
+			ReverseCharge: "N",
+			InvoiceType:   "R",
+			Items:         d.items(),
+		})
+	}
+
+	for _, d := range notes {
+		addNote(d, "C", -1)
+	}
+	for _, d := range debitNotes {
+		addNote(d, "D", 1)
+	}
+
+	// 4️⃣ HSN summary
+	hsn, err := s.HSNSummary(companyID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("gstr1 hsn: %w", err)
+	}
+
+	export.Return.HSN = &models.GSTR1HSN{Data: []models.GSTR1HSNRow{}}
+	for i, r := range hsn.Rows {
+		export.Return.HSN.Data = append(export.Return.HSN.Data, models.GSTR1HSNRow{
+			Num:          i + 1,
+			HSNCode:      r.HSNCode,
+			UQC:          utils.UQCCode(r.UQC),
+			Quantity:     float64(r.Quantity),
+			Value:        r.TotalValue,
+			TaxableValue: r.TaxableValue,
+			Rate:         r.TaxRate,
+			IGST:         r.IGST,
+			CGST:         r.CGST,
+			SGST:         r.SGST,
+		})
+	}
+
+	return export, nil
+}
+
+func (s *ReportService) gstr1Invoices(
+	companyID int64,
+	from, to time.Time,
+	supplierState string,
+	issue func(docType string, id int64, number, field, msg string),
+) ([]*gstr1Doc, error) {
+
+	rows, err := s.db.Query(`
+		SELECT
+			i.id,
+			i.invoice_number,
+			i.invoice_date,
+			i.total,
+			COALESCE(i.place_of_supply, ''),
+			i.is_inter_state,
+			COALESCE(
+				NULLIF((
+					SELECT gst_number FROM invoice_addresses
+					WHERE invoice_id = i.id AND type = 'billing'
+					LIMIT 1
+				), ''),
+				(
+					SELECT gst_number FROM client_addresses
+					WHERE client_id = i.client_id AND type = 'billing'
+					LIMIT 1
+				),
+				''
+			)
+		FROM invoices i
+		WHERE i.company_id = $1
+		  AND i.invoice_date BETWEEN $2 AND $3
+		  AND i.status NOT IN ('draft', 'cancelled')
+		ORDER BY i.invoice_date, i.id
+	`, companyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*gstr1Doc
+	byID := map[int64]*gstr1Doc{}
+
+	for rows.Next() {
+		d := &gstr1Doc{}
+		var gstin string
+		if err := rows.Scan(
+			&d.id, &d.number, &d.date, &d.value, &d.pos, &d.interState, &gstin,
+		); err != nil {
+			return nil, err
+		}
+
+		gstin = strings.ToUpper(strings.TrimSpace(gstin))
+		switch {
+		case gstin == "":
+			issue("invoice", d.id, d.number, "gstin", "client has no GSTIN; reported as B2C")
+		case !utils.ValidGSTIN(gstin):
+			issue("invoice", d.id, d.number, "gstin", fmt.Sprintf("client GSTIN %q is invalid; reported as B2C", gstin))
+		default:
+			d.gstin = gstin
+		}
+
+		if d.pos == "" {
+			d.pos = supplierState
+			issue("invoice", d.id, d.number, "place_of_supply", "place of supply unknown; reported as intra-state")
+		}
+
+		docs = append(docs, d)
+		byID[d.id] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.db.Query(`
+		SELECT
+			ii.invoice_id,
+			ii.tax_rate,
+			COALESCE(ii.hsn_code, ''),
+			SUM(ii.rate * ii.qty - COALESCE(ii.discount, 0)),
+			SUM(ii.cgst_amount),
+			SUM(ii.sgst_amount),
+			SUM(ii.igst_amount)
+		FROM invoice_items ii
+		JOIN invoices i ON i.id = ii.invoice_id
+		WHERE i.company_id = $1
+		  AND i.invoice_date BETWEEN $2 AND $3
+		  AND i.status NOT IN ('draft', 'cancelled')
+		GROUP BY ii.invoice_id, ii.tax_rate, COALESCE(ii.hsn_code, '')
+		ORDER BY ii.invoice_id, ii.tax_rate
+	`, companyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var (
+			invoiceID                 int64
+			rate                      float64
+			taxable, cgst, sgst, igst money.Money
+			hsn                       string
+		)
+		if err := lines.Scan(&invoiceID, &rate, &hsn, &taxable, &cgst, &sgst, &igst); err != nil {
+			return nil, err
+		}
+
+		d := byID[invoiceID]
+		if d == nil {
+			continue
+		}
+
+		if !utils.ValidHSN(hsn) {
+			msg := "line has no HSN/SAC code"
+			if hsn != "" {
+				msg = fmt.Sprintf("HSN/SAC code %q is not 4, 6 or 8 digits", hsn)
+			}
+			issue("invoice", d.id, d.number, "hsn", msg)
+		}
+
+		d.addLine(rate, taxable, cgst, sgst, igst)
+	}
+
+	return docs, lines.Err()
+}
+
+func (s *ReportService) gstr1CreditNotes(
+	companyID int64,
+	from, to time.Time,
+	supplierState string,
+) ([]*gstr1Doc, error) {
+
+	rows, err := s.db.Query(`
+		SELECT
+			cn.id,
+			cn.credit_number,
+			cn.credit_date,
+			cn.total,
+			cn.subtotal,
+			cn.tax,
+			i.place_of_supply,
+			i.is_inter_state,
+			COALESCE(ca.gst_number, ''),
+			COALESCE(ca.state, '')
+		FROM credit_notes cn
+		LEFT JOIN invoices i ON i.id = cn.invoice_id
+		LEFT JOIN LATERAL (
+			SELECT gst_number, state
+			FROM client_addresses
+			WHERE client_id = cn.client_id AND type = 'billing'
+			LIMIT 1
+		) ca ON TRUE
+		WHERE cn.company_id = $1
+		  AND cn.credit_date BETWEEN $2 AND $3
+		ORDER BY cn.credit_date, cn.id
+	`, companyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*gstr1Doc
+	byID := map[int64]*gstr1Doc{}
+	untaxed := map[int64]money.Money{}
+
+	for rows.Next() {
+		d := &gstr1Doc{}
+		var (
+			subtotal, tax money.Money
+			invPOS        *string
+			invInterState *bool
+			gstin, state  string
+		)
+		if err := rows.Scan(
+			&d.id, &d.number, &d.date, &d.value, &subtotal, &tax,
+			&invPOS, &invInterState, &gstin, &state,
+		); err != nil {
+			return nil, err
+		}
+
+		if g := strings.ToUpper(strings.TrimSpace(gstin)); utils.ValidGSTIN(g) {
+			d.gstin = g
+		}
+
+		// Follow the original invoice when there is one
+		if invPOS != nil && *invPOS != "" {
+			d.pos = *invPOS
+			d.interState = invInterState != nil && *invInterState
+		} else {
+			d.pos = utils.ResolveStateCode(gstin, state)
+			d.interState = utils.IsInterState(supplierState, d.pos)
+			if d.pos == "" {
+				d.pos = supplierState
+			}
+		}
+
+		if tax == 0 {
+			untaxed[d.id] = subtotal
+		}
+
+		docs = append(docs, d)
+		byID[d.id] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.db.Query(`
+		SELECT
+			cni.credit_note_id,
+			COALESCE(cni.tax_rate, 0),
+			SUM(ROUND(cni.qty * cni.rate, 2)),
+			SUM(ROUND(ROUND(cni.qty * cni.rate, 2) * COALESCE(cni.tax_rate, 0) / 100, 2))
+		FROM credit_note_items cni
+		JOIN credit_notes cn ON cn.id = cni.credit_note_id
+		WHERE cn.company_id = $1
+		  AND cn.credit_date BETWEEN $2 AND $3
+		GROUP BY cni.credit_note_id, COALESCE(cni.tax_rate, 0)
+		ORDER BY cni.credit_note_id, 2
+	`, companyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var noteID int64
+		var rate float64
+		var taxable, tax money.Money
+		if err := lines.Scan(&noteID, &rate, &taxable, &tax); err != nil {
+			return nil, err
+		}
+
+		d := byID[noteID]
+		if d == nil {
+			continue
+		}
+
+		cgst, sgst, igst := utils.SplitTax(tax, d.interState)
+		d.addLine(rate, taxable, cgst, sgst, igst)
+		delete(untaxed, noteID)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	// Value credit notes carry no items and no tax
+	for id, subtotal := range untaxed {
+		byID[id].addLine(0, subtotal, 0, 0, 0)
+	}
+
+	return docs, nil
+}
+
+// gstr1DebitNotes loads debit notes with the buyer and place of supply of
+// the invoice they revise.
+func (s *ReportService) gstr1DebitNotes(
+	companyID int64,
+	from, to time.Time,
+	supplierState string,
+) ([]*gstr1Doc, error) {
+
+	rows, err := s.db.Query(`
+		SELECT
+			dn.id,
+			dn.debit_number,
+			dn.debit_date,
+			dn.total,
+			COALESCE(i.place_of_supply, ''),
+			i.is_inter_state,
+			COALESCE(
+				NULLIF((
+					SELECT gst_number FROM invoice_addresses
+					WHERE invoice_id = i.id AND type = 'billing'
+					LIMIT 1
+				), ''),
+				(
+					SELECT gst_number FROM client_addresses
+					WHERE client_id = dn.client_id AND type = 'billing'
+					LIMIT 1
+				),
+				''
+			)
+		FROM debit_notes dn
+		JOIN invoices i ON i.id = dn.invoice_id
+		WHERE dn.company_id = $1
+		  AND dn.debit_date BETWEEN $2 AND $3
+		ORDER BY dn.debit_date, dn.id
+	`, companyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*gstr1Doc
+	byID := map[int64]*gstr1Doc{}
+
+	for rows.Next() {
+		d := &gstr1Doc{}
+		var gstin string
+		if err := rows.Scan(
+			&d.id, &d.number, &d.date, &d.value, &d.pos, &d.interState, &gstin,
+		); err != nil {
+			return nil, err
+		}
+
+		if g := strings.ToUpper(strings.TrimSpace(gstin)); utils.ValidGSTIN(g) {
+			d.gstin = g
+		}
+		if d.pos == "" {
+			d.pos = supplierState
+		}
+
+		docs = append(docs, d)
+		byID[d.id] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.db.Query(`
+		SELECT
+			dni.debit_note_id,
+			dni.tax_rate,
+			SUM(ROUND(dni.qty * dni.rate, 2)),
+			SUM(ROUND(ROUND(dni.qty * dni.rate, 2) * dni.tax_rate / 100, 2))
+		FROM debit_note_items dni
+		JOIN debit_notes dn ON dn.id = dni.debit_note_id
+		WHERE dn.company_id = $1
+		  AND dn.debit_date BETWEEN $2 AND $3
+		GROUP BY dni.debit_note_id, dni.tax_rate
+		ORDER BY dni.debit_note_id, 2
+	`, companyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+
+	for lines.Next() {
+		var noteID int64
+		var rate float64
+		var taxable, tax money.Money
+		if err := lines.Scan(&noteID, &rate, &taxable, &tax); err != nil {
+			return nil, err
+		}
+
+		d := byID[noteID]
+		if d == nil {
+			continue
+		}
+
+		cgst, sgst, igst := utils.SplitTax(tax, d.interState)
+		d.addLine(rate, taxable, cgst, sgst, igst)
+	}
+
+	return docs, lines.Err()
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	"invo-server/internal/pdf"
+	utils "invo-server/internal/util"
+)
+
+func addAmounts(a, b models.GSTR3BAmounts) models.GSTR3BAmounts {
+	return models.GSTR3BAmounts{
+		TaxableValue: a.TaxableValue + b.TaxableValue,
+		IGST:         a.IGST + b.IGST,
+		CGST:         a.CGST + b.CGST,
+		SGST:         a.SGST + b.SGST,
+		Cess:         a.Cess + b.Cess,
+	}
+}
+
+func subtractAmounts(a, b models.GSTR3BAmounts) models.GSTR3BAmounts {
+	return models.GSTR3BAmounts{
+		TaxableValue: a.TaxableValue - b.TaxableValue,
+		IGST:         a.IGST - b.IGST,
+		CGST:         a.CGST - b.CGST,
+		SGST:         a.SGST - b.SGST,
+		Cess:         a.Cess - b.Cess,
+	}
+}
+
+// setOffITC applies input tax credit against the liability in the order
+// the GST rules require: IGST credit first (IGST, then CGST, then SGST),
+// then CGST credit (CGST, then IGST), then SGST credit (SGST, then IGST).
+// CGST and SGST credit can never be used against each other.
+func setOffITC(liability, itc models.GSTR3BAmounts) (paid, cash models.GSTR3BAmounts) {
+	cash = liability
+	use := func(credit *money.Money, against *money.Money) {
+		n := money.Min(max(*credit, 0), max(*against, 0))
+		*credit -= n
+		*against -= n
+	}
+
+	igst, cgst, sgst := itc.IGST, itc.CGST, itc.SGST
+
+	use(&igst, &cash.IGST)
+	use(&igst, &cash.CGST)
+	use(&igst, &cash.SGST)
+
+	use(&cgst, &cash.CGST)
+	use(&cgst, &cash.IGST)
+
+	use(&sgst, &cash.SGST)
+	use(&sgst, &cash.IGST)
+
+	paid = subtractAmounts(liability, cash)
+	paid.TaxableValue, cash.TaxableValue = 0, 0
+	return paid, cash
+}
+
+// sumNoteRates totals credit or debit notes, split into taxable and
+// nil-rated lines like the invoices they revise.
+func sumNoteRates(notes []*gstr1Doc) (taxable, nilRated models.GSTR3BAmounts) {
+	for _, d := range notes {
+		for _, r := range d.rates {
+			target := &taxable
+			if r.Rate == 0 {
+				target = &nilRated
+			}
+			target.TaxableValue += r.TaxableValue
+			target.IGST += r.IGST
+			target.CGST += r.CGST
+			target.SGST += r.SGST
+		}
+	}
+	return taxable, nilRated
+}
+
+// GSTR3B summarises one month for filing 3B: outward supplies from issued
+// invoices and debit notes less credit notes, and input tax credit from
+// expenses and purchase bills.
+func (s *ReportService) GSTR3B(companyID int64, period string) (*models.GSTR3BReport, error) {
+	from, to, _, err := utils.ParseReturnPeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.GSTR3BReport{Period: period}
+
+	var companyState string
+	err = s.db.QueryRow(`
+		SELECT COALESCE(gst, ''), COALESCE(state, '')
+		FROM companies
+		WHERE id = $1
+	`, companyID).Scan(&report.GSTIN, &companyState)
+	if err != nil {
+		return nil, err
+	}
+	report.GSTIN = strings.ToUpper(strings.TrimSpace(report.GSTIN))
+
+	// 1️⃣ Invoices, split into taxable and nil-rated lines
+	rows, err := s.db.Query(`
+		SELECT
+			ii.tax_rate = 0,
+			COALESCE(SUM(ii.rate * ii.qty - COALESCE(ii.discount, 0)), 0),
+			COALESCE(SUM(ii.igst_amount), 0),
+			COALESCE(SUM(ii.cgst_amount), 0),
+			COALESCE(SUM(ii.sgst_amount), 0)
+		FROM invoice_items ii
+		JOIN invoices i ON i.id = ii.invoice_id
+		WHERE i.company_id = $1
+		  AND i.invoice_date BETWEEN $2 AND $3
+		  AND i.status NOT IN ('draft', 'cancelled')
+		GROUP BY 1
+	`, companyID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("gstr3b invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var taxable, nilRated models.GSTR3BAmounts
+
+	for rows.Next() {
+		var zero bool
+		var a models.GSTR3BAmounts
+		if err := rows.Scan(&zero, &a.TaxableValue, &a.IGST, &a.CGST, &a.SGST); err != nil {
+			return nil, err
+		}
+		if zero {
+			nilRated = a
+		} else {
+			taxable = a
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Invoices = models.GSTR3BAmounts{
+		TaxableValue: taxable.TaxableValue + nilRated.TaxableValue,
+		IGST:         taxable.IGST,
+		CGST:         taxable.CGST,
+		SGST:         taxable.SGST,
+	}
+
+	supplierState := utils.ResolveStateCode(report.GSTIN, companyState)
+
+	// 2️⃣ Debit notes add to the same rows, credit notes reduce them
+	debitNotes, err := s.gstr1DebitNotes(companyID, from, to, supplierState)
+	if err != nil {
+		return nil, fmt.Errorf("gstr3b debit notes: %w", err)
+	}
+	dnTaxable, dnNil := sumNoteRates(debitNotes)
+
+	report.DebitNotes = models.GSTR3BAmounts{
+		TaxableValue: dnTaxable.TaxableValue + dnNil.TaxableValue,
+		IGST:         dnTaxable.IGST,
+		CGST:         dnTaxable.CGST,
+		SGST:         dnTaxable.SGST,
+	}
+
+	notes, err := s.gstr1CreditNotes(companyID, from, to, supplierState)
+	if err != nil {
+		return nil, fmt.Errorf("gstr3b credit notes: %w", err)
+	}
+	cnTaxable, cnNil := sumNoteRates(notes)
+
+	report.CreditNotes = models.GSTR3BAmounts{
+		TaxableValue: cnTaxable.TaxableValue + cnNil.TaxableValue,
+		IGST:         cnTaxable.IGST,
+		CGST:         cnTaxable.CGST,
+		SGST:         cnTaxable.SGST,
+	}
+
+	report.OutwardTaxable = subtractAmounts(addAmounts(taxable, dnTaxable), cnTaxable)
+	report.OutwardNilRated = subtractAmounts(addAmounts(nilRated, dnNil), cnNil)
+
+	// 3️⃣ Input tax credit from expenses and purchase bills
+	err = s.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(taxable_value) FILTER (WHERE itc_eligible), 0),
+			COALESCE(SUM(igst) FILTER (WHERE itc_eligible), 0),
+			COALESCE(SUM(cgst) FILTER (WHERE itc_eligible), 0),
+			COALESCE(SUM(sgst) FILTER (WHERE itc_eligible), 0),
+			COALESCE(SUM(taxable_value) FILTER (WHERE NOT itc_eligible), 0),
+			COALESCE(SUM(igst) FILTER (WHERE NOT itc_eligible), 0),
+			COALESCE(SUM(cgst) FILTER (WHERE NOT itc_eligible), 0),
+			COALESCE(SUM(sgst) FILTER (WHERE NOT itc_eligible), 0)
+		FROM (
+			SELECT taxable_value, igst, cgst, sgst, itc_eligible
+			FROM expensess
+			WHERE company_id = $1
+			  AND date BETWEEN $2 AND $3
+			UNION ALL
+			SELECT subtotal, igst, cgst, sgst, itc_eligible
+			FROM purchase_bills
+			WHERE company_id = $1
+			  AND bill_date BETWEEN $2 AND $3
+		) inward
+	`, companyID, from, to).Scan(
+		&report.EligibleITC.TaxableValue,
+		&report.EligibleITC.IGST,
+		&report.EligibleITC.CGST,
+		&report.EligibleITC.SGST,
+		&report.IneligibleITC.TaxableValue,
+		&report.IneligibleITC.IGST,
+		&report.IneligibleITC.CGST,
+		&report.IneligibleITC.SGST,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gstr3b itc: %w", err)
+	}
+
+	// 4️⃣ Payment of tax
+	report.Liability = report.OutwardTaxable
+	report.Liability.TaxableValue = 0
+	report.PaidThroughITC, report.PayableInCash = setOffITC(report.Liability, report.EligibleITC)
+
+	return report, nil
+}
+
+// GSTR3BPDFData lays the report out as the sections printed on the PDF.
+func GSTR3BPDFData(companyName string, r *models.GSTR3BReport) pdf.GSTR3BPDFData {
+	row := func(label string, a models.GSTR3BAmounts) pdf.GSTR3BRow {
+		return pdf.GSTR3BRow{
+			Label:        label,
+			TaxableValue: a.TaxableValue,
+			IGST:         a.IGST,
+			CGST:         a.CGST,
+			SGST:         a.SGST,
+			Cess:         a.Cess,
+		}
+	}
+
+	return pdf.GSTR3BPDFData{
+		CompanyName: companyName,
+		GSTIN:       r.GSTIN,
+		Period:      r.Period,
+		Sections: []pdf.GSTR3BSection{
+			{
+				Title: "3.1 OUTWARD SUPPLIES",
+				Rows: []pdf.GSTR3BRow{
+					row("(a) Outward taxable supplies", r.OutwardTaxable),
+					row("(c) Nil rated / exempted", r.OutwardNilRated),
+				},
+			},
+			{
+				Title: "ADJUSTMENTS INCLUDED ABOVE",
+				Rows: []pdf.GSTR3BRow{
+					row("Invoices issued", r.Invoices),
+					row("Add: debit notes", r.DebitNotes),
+					row("Less: credit notes", r.CreditNotes),
+				},
+			},
+			{
+				Title: "4. ELIGIBLE ITC",
+				Rows: []pdf.GSTR3BRow{
+					row("(A)(5) All other ITC", r.EligibleITC),
+					row("(D) Ineligible ITC", r.IneligibleITC),
+				},
+			},
+			{
+				Title: "6.1 PAYMENT OF TAX",
+				Rows: []pdf.GSTR3BRow{
+					row("Tax payable", r.Liability),
+					row("Paid through ITC", r.PaidThroughITC),
+					row("Payable in cash", r.PayableInCash),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+
+	"invo-server/internal/models"
+)
+
+func TestSetOffITC(t *testing.T) {
+	tests := []struct {
+		name       string
+		liability  models.GSTR3BAmounts
+		itc        models.GSTR3BAmounts
+		paid, cash models.GSTR3BAmounts
+	}{
+		{
+			name:      "IGST credit spills into CGST",
+			liability: models.GSTR3BAmounts{IGST: 100000, CGST: 50000, SGST: 50000},
+			itc:       models.GSTR3BAmounts{IGST: 120000, CGST: 10000},
+			paid:      models.GSTR3BAmounts{IGST: 100000, CGST: 30000},
+			cash:      models.GSTR3BAmounts{CGST: 20000, SGST: 50000},
+		},
+		{
+			name:      "CGST credit never pays SGST",
+			liability: models.GSTR3BAmounts{IGST: 10001, CGST: 5001, SGST: 5001},
+			itc:       models.GSTR3BAmounts{CGST: 8003, SGST: 1001},
+			paid:      models.GSTR3BAmounts{IGST: 3002, CGST: 5001, SGST: 1001},
+			cash:      models.GSTR3BAmounts{IGST: 6999, SGST: 4000},
+		},
+		{
+			name:      "taxable value is not tax",
+			liability: models.GSTR3BAmounts{TaxableValue: 1000000, IGST: 180000},
+			itc:       models.GSTR3BAmounts{TaxableValue: 500000, IGST: 90000},
+			paid:      models.GSTR3BAmounts{IGST: 90000},
+			cash:      models.GSTR3BAmounts{IGST: 90000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paid, cash := setOffITC(tt.liability, tt.itc)
+			if paid != tt.paid {
+				t.Errorf("paid = %+v, want %+v", paid, tt.paid)
+			}
+			if cash != tt.cash {
+				t.Errorf("cash = %+v, want %+v", cash, tt.cash)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"invo-server/internal/pdf"
+	utils "invo-server/internal/util"
 )
 
 func FetchInvoicePDFData(
@@ -26,9 +27,18 @@ func FetchInvoicePDFData(
 			i.tax,
 			i.total,
 			COALESCE(i.notes, ''),
+			COALESCE(i.place_of_supply, ''),
+			i.is_inter_state,
+			CASE WHEN i.irn_status = 'generated' THEN i.irn ELSE '' END,
+			COALESCE(i.irn_ack_no, ''),
+			COALESCE(TO_CHAR(i.irn_ack_date, 'DD-MM-YYYY HH24:MI'), ''),
+			COALESCE(i.irn_signed_qr, ''),
+			COALESCE(e.ewb_no, ''),
+			COALESCE(TO_CHAR(e.ewb_date, 'DD-MM-YYYY'), ''),
 			c.name
 		FROM invoices i
 		JOIN companies c ON c.id = i.company_id
+		LEFT JOIN eway_bills e ON e.invoice_id = i.id
 		WHERE i.id = $1
 	`, invoiceID).Scan(
 		&data.Invoice.InvoiceNumber,
@@ -38,6 +48,14 @@ func FetchInvoicePDFData(
 		&data.Invoice.Tax,
 		&data.Invoice.Total,
 		&data.Invoice.Notes,
+		&data.Invoice.PlaceOfSupply,
+		&data.Invoice.IsInterState,
+		&data.Invoice.IRN,
+		&data.Invoice.AckNo,
+		&data.Invoice.AckDate,
+		&data.Invoice.SignedQR,
+		&data.Invoice.EWayBillNo,
+		&data.Invoice.EWayBillDate,
 		&data.Company.Name,
 	)
 
@@ -45,6 +63,8 @@ func FetchInvoicePDFData(
 		return data, fmt.Errorf("fetch invoice: %w", err)
 	}
 
+	data.Invoice.PlaceOfSupply = utils.PlaceOfSupplyLabel(data.Invoice.PlaceOfSupply)
+
 	/* -----------------------------
 	   2️⃣ Fetch company address
 	------------------------------ */
@@ -113,9 +133,13 @@ func FetchInvoicePDFData(
 	itemRows, err := db.Query(`
 		SELECT
 			it.name,
-			COALESCE(it.hsn_code, ''),
+			COALESCE(ii.hsn_code, it.hsn_code, ''),
 			ii.qty,
 			ii.rate,
+			ii.tax_rate,
+			ii.cgst_amount,
+			ii.sgst_amount,
+			ii.igst_amount,
 			ii.total
 		FROM invoice_items ii
 		JOIN items it ON it.id = ii.item_id
@@ -136,6 +160,10 @@ func FetchInvoicePDFData(
 			&item.HSNCode,
 			&item.Qty,
 			&item.Rate,
+			&item.TaxRate,
+			&item.CGST,
+			&item.SGST,
+			&item.IGST,
 			&item.Total,
 		); err != nil {
 			return data, err
@@ -145,7 +173,27 @@ func FetchInvoicePDFData(
 	}
 
 	/* -----------------------------
-	   5️⃣ Fetch Default Bank Details
+	   5️⃣ HSN-wise tax summary
+	------------------------------ */
+	hsnRows, err := InvoiceHSNSummary(db, invoiceID)
+	if err != nil {
+		return data, fmt.Errorf("fetch hsn summary: %w", err)
+	}
+
+	for _, r := range hsnRows {
+		data.HSNSummary = append(data.HSNSummary, pdf.HSNRow{
+			HSNCode:      r.HSNCode,
+			TaxRate:      r.TaxRate,
+			TaxableValue: r.TaxableValue,
+			CGST:         r.CGST,
+			SGST:         r.SGST,
+			IGST:         r.IGST,
+			TotalTax:     r.TotalTax,
+		})
+	}
+
+	/* -----------------------------
+	   6️⃣ Fetch Default Bank Details
 	------------------------------ */
 	err = db.QueryRow(`
         SELECT 
@@ -0,0 +1,699 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	utils "invo-server/internal/util"
+	"time"
+)
+
+var (
+	ErrInvoiceAlreadyIssued    = errors.New("invoice already issued")
+	ErrBillingAddressRequired  = errors.New("client billing address is required")
+	ErrInvoiceNumberGeneration = errors.New("failed to generate invoice number")
+	ErrInvoiceNotDraft         = errors.New("only draft invoices can be edited")
+	ErrInvoiceNotFound         = errors.New("invoice not found")
+	ErrInvoiceHasCreditNotes   = errors.New("invoice has credit notes raised against it and cannot be cancelled")
+	ErrInvoiceHasDebitNotes    = errors.New("invoice has debit notes raised against it and cannot be cancelled")
+)
+
+type InvoiceService struct {
+	db     *sql.DB
+	ledger *LedgerService
+	stock  *StockService
+}
+
+func NewInvoiceService(db *sql.DB, ledger *LedgerService, stock *StockService) *InvoiceService {
+	return &InvoiceService{db: db, ledger: ledger, stock: stock}
+}
+
+// lineTaxable is qty × rate less the line discount.
+func lineTaxable(item models.InvoiceItemRequest) money.Money {
+	return item.Rate.Mul(float64(item.Qty)) - item.Discount
+}
+
+// lineTotal is the tax-inclusive amount of one invoice/quote line.
+func lineTotal(item models.InvoiceItemRequest) money.Money {
+	taxable := lineTaxable(item)
+	return taxable + taxable.Percent(item.TaxRate)
+}
+
+func calculateLineTotals(items []models.InvoiceItemRequest) (subtotal, tax, total money.Money) {
+	for _, item := range items {
+		taxable := lineTaxable(item)
+
+		subtotal += taxable
+		tax += taxable.Percent(item.TaxRate)
+	}
+	return subtotal, tax, subtotal + tax
+}
+
+// invoiceLine is a request item with its tax worked out and split into
+// GST heads. Tax is rounded per line (see package money), so lines always
+// add up to the invoice totals.
+type invoiceLine struct {
+	models.InvoiceItemRequest
+	Taxable money.Money
+	CGST    money.Money
+	SGST    money.Money
+	IGST    money.Money
+	Total   money.Money
+}
+
+type invoiceTotals struct {
+	Subtotal money.Money
+	Tax      money.Money
+	CGST     money.Money
+	SGST     money.Money
+	IGST     money.Money
+	Total    money.Money
+}
+
+func splitInvoiceLines(
+	items []models.InvoiceItemRequest,
+	interState bool,
+) ([]invoiceLine, invoiceTotals) {
+
+	lines := make([]invoiceLine, 0, len(items))
+	var t invoiceTotals
+
+	for _, item := range items {
+		taxable := lineTaxable(item)
+		cgst, sgst, igst := utils.SplitTax(taxable.Percent(item.TaxRate), interState)
+
+		line := invoiceLine{
+			InvoiceItemRequest: item,
+			Taxable:            taxable,
+			CGST:               cgst,
+			SGST:               sgst,
+			IGST:               igst,
+			Total:              taxable + cgst + sgst + igst,
+		}
+		lines = append(lines, line)
+
+		t.Subtotal += taxable
+		t.CGST += cgst
+		t.SGST += sgst
+		t.IGST += igst
+	}
+
+	t.Tax = t.CGST + t.SGST + t.IGST
+	t.Total = t.Subtotal + t.Tax
+	return lines, t
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// placeOfSupplyTx returns the GST state codes of the supplying company and
+// of the buyer's billing address. Either may be "" when it can't be worked
+// out, in which case the sale is taxed as intra-state.
+func placeOfSupplyTx(
+	tx *sql.Tx,
+	companyID int,
+	billing *models.Address,
+) (supplierState, placeOfSupply string, err error) {
+
+	var gstin, state string
+	err = tx.QueryRow(`
+		SELECT COALESCE(gst, ''), COALESCE(state, '')
+		FROM companies
+		WHERE id = $1
+	`, companyID).Scan(&gstin, &state)
+	if err != nil {
+		return "", "", fmt.Errorf("fetch company state: %w", err)
+	}
+
+	supplierState = utils.ResolveStateCode(gstin, state)
+	placeOfSupply = utils.ResolveStateCode(
+		stringValue(billing.GSTNumber),
+		stringValue(billing.State),
+	)
+	return supplierState, placeOfSupply, nil
+}
+
+func insertInvoiceItems(tx *sql.Tx, invoiceID int, lines []invoiceLine) error {
+	for _, line := range lines {
+		_, err := tx.Exec(`
+			INSERT INTO invoice_items (
+				invoice_id, item_id, qty, rate, discount, tax_rate,
+				cgst_amount, sgst_amount, igst_amount, total, hsn_code
+			)
+			VALUES (
+				$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,
+				(SELECT hsn_code FROM items WHERE id = $2)
+			)
+		`,
+			invoiceID,
+			line.ItemID,
+			line.Qty,
+			line.Rate,
+			line.Discount,
+			line.TaxRate,
+			line.CGST,
+			line.SGST,
+			line.IGST,
+			line.Total,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotClientAddresses copies the client's current addresses onto the
+// invoice and returns the billing one.
+func snapshotClientAddresses(tx *sql.Tx, invoiceID, clientID int) (*models.Address, error) {
+	billingAddr, err := fetchClientAddress(tx, clientID, "billing")
+	if err != nil {
+		return nil, ErrBillingAddressRequired
+	}
+
+	shippingAddr, _ := fetchClientAddress(tx, clientID, "shipping")
+
+	if err := insertInvoiceAddress(tx, invoiceID, "billing", *billingAddr); err != nil {
+		return nil, fmt.Errorf("save invoice billing address: %w", err)
+	}
+
+	if shippingAddr != nil {
+		if err := insertInvoiceAddress(tx, invoiceID, "shipping", *shippingAddr); err != nil {
+			return nil, fmt.Errorf("save invoice shipping address: %w", err)
+		}
+	}
+
+	return billingAddr, nil
+}
+
+func insertInvoiceAddress(
+	tx *sql.Tx,
+	invoiceID int,
+	addressType string,
+	addr models.Address,
+) error {
+
+	_, err := tx.Exec(`
+		INSERT INTO invoice_addresses (
+			invoice_id, type,
+			name, line1, line2, city, state,
+			postal_code, country, phone, gst_number
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+	`,
+		invoiceID,
+		addressType,
+		addr.Name,
+		addr.Line1,
+		addr.Line2,
+		addr.City,
+		addr.State,
+		addr.PostalCode,
+		addr.Country,
+		addr.Phone,
+		addr.GSTNumber,
+	)
+
+	return err
+}
+
+func fetchClientAddress(
+	tx *sql.Tx,
+	clientID int,
+	addressType string, // billing | shipping
+) (*models.Address, error) {
+
+	var addr models.Address
+
+	err := tx.QueryRow(`
+		SELECT
+			type,
+			name,
+			line1,
+			line2,
+			city,
+			state,
+			postal_code,
+			country,
+			phone,
+			email,
+			gst_number
+		FROM client_addresses
+		WHERE client_id = $1
+		  AND type = $2
+	`, clientID, addressType).Scan(
+		&addr.AddressType,
+		&addr.Name,
+		&addr.Line1,
+		&addr.Line2,
+		&addr.City,
+		&addr.State,
+		&addr.PostalCode,
+		&addr.Country,
+		&addr.Phone,
+		&addr.Email,
+		&addr.GSTNumber,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &addr, nil
+}
+
+// CreateTx inserts a draft invoice with its items and a snapshot of the
+// client's addresses. Ownership of the company, client and items must be
+// checked by the caller.
+func (s *InvoiceService) CreateTx(
+	tx *sql.Tx,
+	userID int,
+	req models.InvoiceRequestDTO,
+) (*models.CreatedInvoice, error) {
+
+	if len(req.Items) == 0 {
+		return nil, errors.New("invoice must contain at least one item")
+	}
+
+	// 1️⃣ Parse dates
+	invDate, err := time.Parse("2006-01-02", req.InvoiceDate)
+	if err != nil {
+		return nil, errors.New("invalid invoice_date (YYYY-MM-DD)")
+	}
+
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		return nil, errors.New("invalid due_date (YYYY-MM-DD)")
+	}
+
+	// 2️⃣ Generate invoice number from the company's numbering scheme
+	fy := utils.FinancialYear(invDate)
+
+	invoiceNumber, err := nextDocumentNumberTx(tx, int64(req.CompanyID), DocInvoice, invDate)
+	if err != nil {
+		return nil, ErrInvoiceNumberGeneration
+	}
+
+	// 3️⃣ Insert invoice (amounts are filled in once the place of supply is known)
+	var invoiceID int
+	err = tx.QueryRow(`
+		INSERT INTO invoices (
+			company_id,
+			user_id,
+			client_id,
+			invoice_number,
+			invoice_date,
+			due_date,
+			subtotal,
+			tax,
+			total,
+			status,
+			paid_amount,
+			remaining_amount
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,0,0,0,'draft',0,0)
+		RETURNING id
+	`,
+		req.CompanyID,
+		userID,
+		req.ClientID,
+		invoiceNumber,
+		invDate,
+		dueDate,
+	).Scan(&invoiceID)
+
+	if err != nil {
+		return nil, fmt.Errorf("insert invoice: %w", err)
+	}
+
+	// 4️⃣ Snapshot client addresses
+	billingAddr, err := snapshotClientAddresses(tx, invoiceID, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Items and GST split
+	if err := s.applyItemsTx(tx, invoiceID, req.CompanyID, billingAddr, req.Items); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedInvoice{
+		ID:            invoiceID,
+		InvoiceNumber: invoiceNumber,
+		FinancialYear: fy,
+	}, nil
+}
+
+// applyItemsTx inserts the invoice lines with their CGST/SGST/IGST split
+// and writes the header totals and place of supply.
+func (s *InvoiceService) applyItemsTx(
+	tx *sql.Tx,
+	invoiceID int,
+	companyID int,
+	billing *models.Address,
+	items []models.InvoiceItemRequest,
+) error {
+
+	supplierState, pos, err := placeOfSupplyTx(tx, companyID, billing)
+	if err != nil {
+		return err
+	}
+
+	interState := utils.IsInterState(supplierState, pos)
+	lines, totals := splitInvoiceLines(items, interState)
+
+	if err := insertInvoiceItems(tx, invoiceID, lines); err != nil {
+		return fmt.Errorf("insert invoice items: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET subtotal = $2,
+			tax = $3,
+			total = $4,
+			remaining_amount = $4,
+			cgst = $5,
+			sgst = $6,
+			igst = $7,
+			place_of_supply = NULLIF($8, ''),
+			is_inter_state = $9
+		WHERE id = $1
+	`,
+		invoiceID,
+		totals.Subtotal,
+		totals.Tax,
+		totals.Total,
+		totals.CGST,
+		totals.SGST,
+		totals.IGST,
+		pos,
+		interState,
+	)
+	if err != nil {
+		return fmt.Errorf("update invoice totals: %w", err)
+	}
+	return nil
+}
+
+// UpdateTx replaces the client, dates and items of a draft invoice. The
+// address snapshot and place of supply are re-taken from the (possibly new)
+// client. Ownership of the client and items must be checked by the caller.
+func (s *InvoiceService) UpdateTx(
+	tx *sql.Tx,
+	invoiceID int,
+	companyID int,
+	req models.UpdateInvoiceRequestDTO,
+) error {
+
+	invDate, err := time.Parse("2006-01-02", req.InvoiceDate)
+	if err != nil {
+		return errors.New("invalid invoice_date (YYYY-MM-DD)")
+	}
+
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		return errors.New("invalid due_date (YYYY-MM-DD)")
+	}
+
+	var status string
+	err = tx.QueryRow(`
+		SELECT status FROM invoices WHERE id = $1 FOR UPDATE
+	`, invoiceID).Scan(&status)
+	if err != nil {
+		return err
+	}
+
+	if status != "draft" {
+		return ErrInvoiceNotDraft
+	}
+
+	// 1️⃣ Update invoice header
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET
+			client_id = $1,
+			invoice_date = $2,
+			due_date = $3,
+			updated_at = NOW()
+		WHERE id = $4
+	`, req.ClientID, invDate, dueDate, invoiceID)
+	if err != nil {
+		return fmt.Errorf("update invoice: %w", err)
+	}
+
+	// 2️⃣ Replace items and address snapshot
+	if _, err = tx.Exec(`DELETE FROM invoice_items WHERE invoice_id = $1`, invoiceID); err != nil {
+		return fmt.Errorf("clear invoice items: %w", err)
+	}
+
+	if _, err = tx.Exec(`DELETE FROM invoice_addresses WHERE invoice_id = $1`, invoiceID); err != nil {
+		return fmt.Errorf("clear invoice addresses: %w", err)
+	}
+
+	billingAddr, err := snapshotClientAddresses(tx, invoiceID, req.ClientID)
+	if err != nil {
+		return err
+	}
+
+	// 3️⃣ Items and GST split
+	return s.applyItemsTx(tx, invoiceID, companyID, billingAddr, req.Items)
+}
+
+// IssueTx moves a draft invoice to issued and posts its ledger debit. With
+// applyCredit, the client's unapplied payments are put against it straight
+// away; the amount applied is returned.
+func (s *InvoiceService) IssueTx(
+	tx *sql.Tx,
+	userID int,
+	invoiceID int,
+	applyCredit bool,
+) (money.Money, error) {
+
+	var (
+		status    string
+		total     money.Money
+		clientID  int64
+		companyID int64
+		number    string
+	)
+
+	err := tx.QueryRow(`
+		SELECT status, total, client_id, company_id, invoice_number
+		FROM invoices
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, invoiceID, userID).Scan(
+		&status, &total, &clientID, &companyID, &number,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if status != "draft" {
+		return 0, ErrInvoiceAlreadyIssued
+	}
+
+	// 1️⃣ Update invoice
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET status = 'issued',
+			remaining_amount = total
+		WHERE id = $1
+	`, invoiceID)
+	if err != nil {
+		return 0, fmt.Errorf("update invoice: %w", err)
+	}
+
+	// 2️⃣ Take the goods out of stock
+	if err := s.stock.IssueInvoiceTx(tx, companyID, int64(invoiceID), number); err != nil {
+		return 0, err
+	}
+
+	// 3️⃣ Ledger entry
+	err = s.ledger.AddEntryTx(
+		tx,
+		companyID,
+		clientID,
+		"INVOICE",
+		int64(invoiceID),
+		total,
+		0,
+		"Invoice "+number,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// 4️⃣ Advances and overpayments (already credited on the ledger)
+	if !applyCredit || total <= 0 {
+		return 0, nil
+	}
+	return applyUnappliedPaymentsTx(tx, companyID, clientID, int64(invoiceID), total)
+}
+
+// CancelTx voids an invoice while keeping its row (and therefore its number)
+// so the GST series stays gapless. Issued invoices get a reversing ledger
+// credit; drafts never hit the ledger so they are simply marked cancelled.
+// An invoice with credit or debit notes raised against it can't be
+// cancelled: the notes already adjust what the client owes for it.
+// Returns the amount of payments that were unapplied from the invoice.
+func (s *InvoiceService) CancelTx(
+	tx *sql.Tx,
+	userID int,
+	invoiceID int64,
+	req models.CancelInvoiceRequestDTO,
+) (money.Money, error) {
+
+	var (
+		status    string
+		total     money.Money
+		clientID  int64
+		companyID int64
+		number    string
+		irnStatus string
+	)
+
+	// 1️⃣ Lock invoice
+	err := tx.QueryRow(`
+		SELECT status, total, client_id, company_id, invoice_number, COALESCE(irn_status, '')
+		FROM invoices
+		WHERE id = $1 AND user_id = $2
+		FOR UPDATE
+	`, invoiceID, userID).Scan(&status, &total, &clientID, &companyID, &number, &irnStatus)
+	if err != nil {
+		return 0, err
+	}
+
+	if status == "cancelled" {
+		return 0, errors.New("invoice already cancelled")
+	}
+
+	if irnStatus == "generated" {
+		return 0, ErrIRNActive
+	}
+
+	var noteCount int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM credit_notes WHERE invoice_id = $1
+	`, invoiceID).Scan(&noteCount)
+	if err != nil {
+		return 0, err
+	}
+	if noteCount > 0 {
+		return 0, ErrInvoiceHasCreditNotes
+	}
+
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM debit_notes WHERE invoice_id = $1
+	`, invoiceID).Scan(&noteCount)
+	if err != nil {
+		return 0, err
+	}
+	if noteCount > 0 {
+		return 0, ErrInvoiceHasDebitNotes
+	}
+
+	// 2️⃣ Payment allocations must be unapplied first
+	var allocCount int
+	var allocated money.Money
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(amount), 0)
+		FROM payment_allocations
+		WHERE invoice_id = $1
+	`, invoiceID).Scan(&allocCount, &allocated)
+	if err != nil {
+		return 0, err
+	}
+
+	var creditCount int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM credit_note_allocations WHERE invoice_id = $1
+	`, invoiceID).Scan(&creditCount)
+	if err != nil {
+		return 0, err
+	}
+
+	if allocCount > 0 || creditCount > 0 {
+		if !req.UnapplyPayments {
+			return 0, errors.New("invoice has payments or credit notes applied; set unapply_payments to cancel anyway")
+		}
+
+		// the payments stay on the ledger as client credit
+		_, err = tx.Exec(`
+			DELETE FROM payment_allocations WHERE invoice_id = $1
+		`, invoiceID)
+		if err != nil {
+			return 0, err
+		}
+
+		// applied credit goes back onto its credit notes
+		_, err = tx.Exec(`
+			WITH released AS (
+				DELETE FROM credit_note_allocations
+				WHERE invoice_id = $1
+				RETURNING credit_note_id, amount
+			)
+			UPDATE credit_notes cn
+			SET balance = cn.balance + r.amount,
+				status = CASE
+					WHEN cn.balance + r.amount >= cn.total THEN 'issued'
+					ELSE 'partially_applied'
+				END
+			FROM (
+				SELECT credit_note_id, SUM(amount) AS amount
+				FROM released
+				GROUP BY credit_note_id
+			) r
+			WHERE cn.id = r.credit_note_id
+		`, invoiceID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// 3️⃣ Mark cancelled (number stays reserved)
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET status = 'cancelled',
+			paid_amount = 0,
+			remaining_amount = 0,
+			cancelled_at = NOW(),
+			cancel_reason = $2,
+			updated_at = NOW()
+		WHERE id = $1
+	`, invoiceID, req.Reason)
+	if err != nil {
+		return 0, err
+	}
+
+	// 4️⃣ Reverse the stock and ledger debit posted by IssueInvoice
+	if status == "draft" {
+		return allocated, nil
+	}
+
+	if err := s.stock.ReverseInvoiceTx(tx, companyID, invoiceID, number); err != nil {
+		return 0, err
+	}
+
+	err = s.ledger.AddEntryTx(
+		tx,
+		companyID,
+		clientID,
+		"INVOICE",
+		invoiceID,
+		0,
+		total,
+		"Invoice "+number+" cancelled",
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return allocated, nil
+}
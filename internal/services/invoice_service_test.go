@@ -0,0 +1,150 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+)
+
+func TestSplitInvoiceLinesNoDrift(t *testing.T) {
+	// Rates and tax rates chosen so every line's tax has an odd or
+	// fractional paisa before rounding.
+	items := []models.InvoiceItemRequest{
+		{Qty: 3, Rate: 1005, TaxRate: 18},              // 30.15 → tax 5.427
+		{Qty: 7, Rate: 333, Discount: 1, TaxRate: 5},   // 23.30 → tax 1.165
+		{Qty: 1, Rate: 1, TaxRate: 28},                 // 0.01 → tax 0.0028
+		{Qty: 11, Rate: 999, TaxRate: 12},              // 109.89 → tax 13.1868
+		{Qty: 2, Rate: 12345, Discount: 3, TaxRate: 3}, // 246.87 → tax 7.4061
+	}
+
+	tests := []struct {
+		name       string
+		interState bool
+	}{
+		{name: "intra-state", interState: false},
+		{name: "inter-state", interState: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, totals := splitInvoiceLines(items, tt.interState)
+
+			var subtotal, cgst, sgst, igst, total money.Money
+			for i, l := range lines {
+				tax := lineTaxable(items[i]).Percent(items[i].TaxRate)
+				if l.CGST+l.SGST+l.IGST != tax {
+					t.Errorf("line %d heads = %d, want tax %d", i, l.CGST+l.SGST+l.IGST, tax)
+				}
+				if l.Total != lineTotal(items[i]) {
+					t.Errorf("line %d total = %d, want %d", i, l.Total, lineTotal(items[i]))
+				}
+				subtotal += l.Taxable
+				cgst += l.CGST
+				sgst += l.SGST
+				igst += l.IGST
+				total += l.Total
+			}
+
+			if totals.Subtotal != subtotal || totals.CGST != cgst ||
+				totals.SGST != sgst || totals.IGST != igst {
+				t.Errorf("totals %+v don't match line sums", totals)
+			}
+			if totals.Total != total {
+				t.Errorf("total = %d, lines add up to %d", totals.Total, total)
+			}
+			if totals.Subtotal+totals.Tax != totals.Total {
+				t.Errorf("subtotal %d + tax %d != total %d", totals.Subtotal, totals.Tax, totals.Total)
+			}
+
+			sub, tax, tot := calculateLineTotals(items)
+			if sub != totals.Subtotal || tax != totals.Tax || tot != totals.Total {
+				t.Errorf("calculateLineTotals = %d, %d, %d, want %d, %d, %d",
+					sub, tax, tot, totals.Subtotal, totals.Tax, totals.Total)
+			}
+
+			// 30.15 + 23.30 + 0.01 + 109.89 + 246.87 = 410.22 taxable,
+			// 5.43 + 1.17 + 0.00 + 13.19 + 7.41 = 27.20 tax
+			if totals.Subtotal != 41022 || totals.Tax != 2720 || totals.Total != 43742 {
+				t.Errorf("totals = %s + %s = %s, want 410.22 + 27.20 = 437.42",
+					totals.Subtotal, totals.Tax, totals.Total)
+			}
+		})
+	}
+}
+
+func TestCancelTxNotes(t *testing.T) {
+	invoiceRow := func(status string) fakeResult {
+		return fakeResult{
+			match: "invoice_number, COALESCE(irn_status",
+			cols:  []string{"status", "total", "client_id", "company_id", "invoice_number", "irn_status"},
+			rows:  [][]driver.Value{{status, "1180.00", int64(7), int64(3), "INV/2026-27/0001", ""}},
+		}
+	}
+	noteCount := func(table string, n int64) fakeResult {
+		return fakeResult{
+			match: "FROM " + table + " WHERE invoice_id",
+			cols:  []string{"count"},
+			rows:  [][]driver.Value{{n}},
+		}
+	}
+
+	t.Run("blocked by a credit note", func(t *testing.T) {
+		tx, db := openFakeTx(t, invoiceRow("issued"), noteCount("credit_notes", 1))
+
+		s := &InvoiceService{}
+		_, err := s.CancelTx(tx, 1, 42, models.CancelInvoiceRequestDTO{Reason: "duplicate"})
+		if !errors.Is(err, ErrInvoiceHasCreditNotes) {
+			t.Fatalf("CancelTx error = %v, want ErrInvoiceHasCreditNotes", err)
+		}
+		if db.ran("SET status = 'cancelled'") {
+			t.Error("invoice was marked cancelled")
+		}
+	})
+
+	t.Run("blocked by a debit note", func(t *testing.T) {
+		tx, db := openFakeTx(t,
+			invoiceRow("issued"),
+			noteCount("credit_notes", 0),
+			noteCount("debit_notes", 1),
+		)
+
+		s := &InvoiceService{}
+		_, err := s.CancelTx(tx, 1, 42, models.CancelInvoiceRequestDTO{Reason: "duplicate"})
+		if !errors.Is(err, ErrInvoiceHasDebitNotes) {
+			t.Fatalf("CancelTx error = %v, want ErrInvoiceHasDebitNotes", err)
+		}
+		if db.ran("SET status = 'cancelled'") {
+			t.Error("invoice was marked cancelled")
+		}
+	})
+
+	t.Run("no notes", func(t *testing.T) {
+		tx, db := openFakeTx(t,
+			invoiceRow("draft"),
+			noteCount("credit_notes", 0),
+			noteCount("debit_notes", 0),
+			fakeResult{
+				match: "FROM payment_allocations",
+				cols:  []string{"count", "sum"},
+				rows:  [][]driver.Value{{int64(0), "0"}},
+			},
+			fakeResult{
+				match: "FROM credit_note_allocations",
+				cols:  []string{"count"},
+				rows:  [][]driver.Value{{int64(0)}},
+			},
+			fakeResult{match: "SET status = 'cancelled'"},
+		)
+
+		s := &InvoiceService{}
+		if _, err := s.CancelTx(tx, 1, 42, models.CancelInvoiceRequestDTO{Reason: "duplicate"}); err != nil {
+			t.Fatalf("CancelTx error = %v", err)
+		}
+		if !db.ran("SET status = 'cancelled'") {
+			t.Error("invoice was not marked cancelled")
+		}
+	})
+}
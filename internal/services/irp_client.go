@@ -0,0 +1,105 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"invo-server/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IRPClient talks to an Invoice Registration Portal (directly or through a
+// GSP). Implementations must be safe for concurrent use.
+type IRPClient interface {
+	GenerateIRN(payload models.EInvoicePayload) (*models.IRNResult, error)
+	CancelIRN(irn, reasonCode, remark string) (time.Time, error)
+}
+
+// StubIRPClient registers documents locally so the e-invoice flow can run
+// without portal credentials. IRNs are computed the way NIC does (SHA-256
+// of supplier GSTIN, financial year, document type and number) so they
+// stay stable across retries; the QR is an HS256 JWT instead of NIC's RS256.
+type StubIRPClient struct {
+	signingKey []byte
+}
+
+func NewStubIRPClient(signingKey []byte) *StubIRPClient {
+	return &StubIRPClient{signingKey: signingKey}
+}
+
+// irnFinancialYear returns the FY of a DD/MM/YYYY date as "2025-26".
+func irnFinancialYear(docDate string) (string, error) {
+	t, err := time.Parse("02/01/2006", docDate)
+	if err != nil {
+		return "", err
+	}
+	start := t.Year()
+	if t.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100), nil
+}
+
+func (c *StubIRPClient) GenerateIRN(p models.EInvoicePayload) (*models.IRNResult, error) {
+	fy, err := irnFinancialYear(p.DocDtls.Dt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document date %q", p.DocDtls.Dt)
+	}
+
+	sum := sha256.Sum256([]byte(p.SellerDtls.Gstin + fy + p.DocDtls.Typ + p.DocDtls.No))
+	irn := hex.EncodeToString(sum[:])
+
+	// 15 digit acknowledgement number derived from the IRN
+	ack, _ := strconv.ParseUint(irn[:15], 16, 64)
+	ackNo := fmt.Sprintf("%015d", ack%1_000_000_000_000_000)
+
+	ackDate := time.Now().Truncate(time.Second)
+
+	mainHSN := ""
+	if len(p.ItemList) > 0 {
+		mainHSN = p.ItemList[0].HsnCd
+	}
+
+	qrData, err := json.Marshal(map[string]interface{}{
+		"SellerGstin": p.SellerDtls.Gstin,
+		"BuyerGstin":  p.BuyerDtls.Gstin,
+		"DocNo":       p.DocDtls.No,
+		"DocTyp":      p.DocDtls.Typ,
+		"DocDt":       p.DocDtls.Dt,
+		"TotInvVal":   p.ValDtls.TotInvVal,
+		"ItemCnt":     len(p.ItemList),
+		"MainHsnCode": mainHSN,
+		"Irn":         irn,
+		"IrnDt":       ackDate.Format("2006-01-02 15:04:05"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signedQR, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"data": string(qrData),
+		"iss":  "NIC (stub)",
+	}).SignedString(c.signingKey)
+	if err != nil {
+		return nil, fmt.Errorf("sign qr: %w", err)
+	}
+
+	return &models.IRNResult{
+		IRN:          irn,
+		AckNo:        ackNo,
+		AckDate:      ackDate,
+		SignedQRCode: signedQR,
+	}, nil
+}
+
+func (c *StubIRPClient) CancelIRN(irn, reasonCode, remark string) (time.Time, error) {
+	if len(irn) != 64 {
+		return time.Time{}, fmt.Errorf("invalid IRN %q", irn)
+	}
+	return time.Now().Truncate(time.Second), nil
+}
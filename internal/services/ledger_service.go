@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"invo-server/internal/models"
+	"invo-server/internal/money"
 )
 
 type LedgerService struct {
@@ -19,9 +20,9 @@ func NewLedgerService(db *sql.DB) *LedgerService {
 func (s *LedgerService) getLastBalanceTx(
 	tx *sql.Tx,
 	companyID, clientID int64,
-) (float64, error) {
+) (money.Money, error) {
 
-	var balance float64
+	var balance money.Money
 
 	err := tx.QueryRow(`
 		SELECT balance
@@ -44,8 +45,8 @@ func (s *LedgerService) AddEntryTx(
 	clientID int64,
 	sourceType string,
 	sourceID int64,
-	debit float64,
-	credit float64,
+	debit money.Money,
+	credit money.Money,
 	description string,
 ) error {
 
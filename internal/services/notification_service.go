@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"invo-server/internal/models"
+)
+
+type NotificationService struct {
+	db    *sql.DB
+	email *EmailService
+}
+
+func NewNotificationService(db *sql.DB, email *EmailService) *NotificationService {
+	return &NotificationService{db: db, email: email}
+}
+
+// lowStockCrossed reports whether a movement took the stock from at or
+// above the threshold to below it. Items already below it don't alert
+// again until they are restocked.
+func lowStockCrossed(before, after, threshold int) bool {
+	return threshold > 0 && after < threshold && before >= threshold
+}
+
+func raiseLowStockTx(tx *sql.Tx, companyID, itemID int64, name string, quantity, threshold int) error {
+	_, err := tx.Exec(`
+		INSERT INTO notifications (company_id, type, title, message, item_id)
+		VALUES ($1, 'low_stock', $2, $3, $4)
+	`,
+		companyID,
+		"Low stock: "+name,
+		fmt.Sprintf("%s is down to %d (alert below %d).", name, quantity, threshold),
+		itemID,
+	)
+	return err
+}
+
+// List returns the company's notifications, newest first.
+func (s *NotificationService) List(companyID int64, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	query := `
+		SELECT id, company_id, type, title, message, item_id, read_at, created_at
+		FROM notifications
+		WHERE company_id = $1
+	`
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY id DESC LIMIT $2 OFFSET $3`
+
+	rows, err := s.db.Query(query, companyID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(
+			&n.ID,
+			&n.CompanyID,
+			&n.Type,
+			&n.Title,
+			&n.Message,
+			&n.ItemID,
+			&n.ReadAt,
+			&n.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+
+	return result, rows.Err()
+}
+
+// MarkRead marks a notification as read. Returns sql.ErrNoRows when it
+// doesn't belong to one of the user's companies.
+func (s *NotificationService) MarkRead(userID int, notificationID int64) error {
+	res, err := s.db.Exec(`
+		UPDATE notifications n
+		SET read_at = COALESCE(n.read_at, NOW())
+		FROM companies c
+		WHERE n.id = $1
+		  AND c.id = n.company_id
+		  AND c.user_id = $2
+	`, notificationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// StartLowStockDigest emails each company's new low-stock alerts once per
+// interval until ctx is cancelled.
+func (s *NotificationService) StartLowStockDigest(ctx context.Context, interval time.Duration) {
+	log.Printf("📦 Low-stock digest started (every %s)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.SendLowStockDigests(ctx)
+		}
+	}
+}
+
+// SendLowStockDigests sends one email per company listing the low-stock
+// alerts not emailed yet. A failed send leaves the alerts for the next run.
+func (s *NotificationService) SendLowStockDigests(ctx context.Context) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT company_id
+		FROM notifications
+		WHERE type = 'low_stock' AND emailed_at IS NULL
+	`)
+	if err != nil {
+		log.Printf("❌ Low-stock digest: failed to load companies: %v", err)
+		return
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, companyID := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.sendLowStockDigest(ctx, companyID); err != nil {
+			log.Printf("❌ Low-stock digest for company %d: %v", companyID, err)
+		}
+	}
+}
+
+func (s *NotificationService) sendLowStockDigest(ctx context.Context, companyID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var companyName, toEmail string
+	err = tx.QueryRow(`
+		SELECT c.name, u.email
+		FROM companies c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1
+	`, companyID).Scan(&companyName, &toEmail)
+	if err != nil {
+		return fmt.Errorf("load recipient: %w", err)
+	}
+
+	// Lock the batch so two schedulers can't send the same alerts
+	rows, err := tx.Query(`
+		SELECT n.id, it.name, it.quantity, COALESCE(it.low_stock_alert, 0)
+		FROM notifications n
+		JOIN items it ON it.id = n.item_id
+		WHERE n.company_id = $1
+		  AND n.type = 'low_stock'
+		  AND n.emailed_at IS NULL
+		ORDER BY it.name
+		FOR UPDATE OF n SKIP LOCKED
+	`, companyID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		ids   []int64
+		items []LowStockDigestItem
+		seen  = map[string]bool{}
+	)
+	for rows.Next() {
+		var (
+			id   int64
+			item LowStockDigestItem
+		)
+		if err := rows.Scan(&id, &item.Name, &item.Quantity, &item.Threshold); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+
+		// One line per item, with its stock as of now
+		if !seen[item.Name] {
+			seen[item.Name] = true
+			items = append(items, item)
+		}
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := s.email.SendLowStockDigestEmail(toEmail, companyName, items); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := tx.Exec(`UPDATE notifications SET emailed_at = NOW() WHERE id = $1`, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
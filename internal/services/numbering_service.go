@@ -0,0 +1,235 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"invo-server/internal/models"
+	utils "invo-server/internal/util"
+)
+
+// Document types that draw numbers from a numbering scheme.
+const (
+	DocInvoice       = "invoice"
+	DocCreditNote    = "credit_note"
+	DocQuote         = "quote"
+	DocReceipt       = "receipt"
+	DocPurchaseOrder = "purchase_order"
+	DocRefund        = "refund"
+	DocDebitNote     = "debit_note"
+)
+
+// defaultNumberingSchemes are used until a company saves its own scheme.
+// They match the formats issued before schemes were configurable.
+var defaultNumberingSchemes = map[string]models.NumberingScheme{
+	DocInvoice:       {DocumentType: DocInvoice, Template: "INV/{FY}/{SEQ:4}", ResetPolicy: utils.ResetFY, StartNumber: 1},
+	DocCreditNote:    {DocumentType: DocCreditNote, Template: "CN-{YYYY}-{SEQ:5}", ResetPolicy: utils.ResetYearly, StartNumber: 1},
+	DocQuote:         {DocumentType: DocQuote, Template: "QT/{FY}/{SEQ:4}", ResetPolicy: utils.ResetFY, StartNumber: 1},
+	DocReceipt:       {DocumentType: DocReceipt, Template: "RCT/{FY}/{SEQ:4}", ResetPolicy: utils.ResetFY, StartNumber: 1},
+	DocPurchaseOrder: {DocumentType: DocPurchaseOrder, Template: "PO/{FY}/{SEQ:4}", ResetPolicy: utils.ResetFY, StartNumber: 1},
+	DocRefund:        {DocumentType: DocRefund, Template: "RFD/{FY}/{SEQ:4}", ResetPolicy: utils.ResetFY, StartNumber: 1},
+	DocDebitNote:     {DocumentType: DocDebitNote, Template: "DN/{FY}/{SEQ:4}", ResetPolicy: utils.ResetFY, StartNumber: 1},
+}
+
+var documentTypeOrder = []string{DocInvoice, DocCreditNote, DocQuote, DocReceipt, DocPurchaseOrder, DocRefund, DocDebitNote}
+
+var ErrUnknownDocumentType = errors.New("document type must be invoice, credit_note, quote, receipt, purchase_order, refund or debit_note")
+
+// NumberingValidationError wraps a template or reset policy the scheme
+// can't be saved with.
+type NumberingValidationError struct {
+	Err error
+}
+
+func (e *NumberingValidationError) Error() string {
+	return e.Err.Error()
+}
+
+type NumberingService struct {
+	db *sql.DB
+}
+
+func NewNumberingService(db *sql.DB) *NumberingService {
+	return &NumberingService{db: db}
+}
+
+func loadNumberingScheme(q dbQuerier, companyID int64, docType string) (models.NumberingScheme, error) {
+	scheme, ok := defaultNumberingSchemes[docType]
+	if !ok {
+		return scheme, ErrUnknownDocumentType
+	}
+	scheme.IsDefault = true
+
+	err := q.QueryRow(`
+		SELECT template, reset_policy, start_number
+		FROM numbering_schemes
+		WHERE company_id = $1 AND document_type = $2
+	`, companyID, docType).Scan(&scheme.Template, &scheme.ResetPolicy, &scheme.StartNumber)
+
+	switch {
+	case err == sql.ErrNoRows:
+		return scheme, nil
+	case err != nil:
+		return scheme, err
+	}
+
+	scheme.IsDefault = false
+	return scheme, nil
+}
+
+// documentNumberTaken reports whether number is already used by a document
+// of the company. Numbers can collide after a template or start number
+// change, and the counter skips over them.
+func documentNumberTaken(tx *sql.Tx, companyID int64, docType, number string) (bool, error) {
+	var query string
+	switch docType {
+	case DocInvoice:
+		query = `SELECT EXISTS (SELECT 1 FROM invoices WHERE company_id = $1 AND invoice_number = $2)`
+	case DocCreditNote:
+		query = `SELECT EXISTS (SELECT 1 FROM credit_notes WHERE company_id = $1 AND credit_number = $2)`
+	case DocQuote:
+		query = `SELECT EXISTS (SELECT 1 FROM quotes WHERE company_id = $1 AND quote_number = $2)`
+	case DocReceipt:
+		query = `SELECT EXISTS (SELECT 1 FROM payments WHERE company_id = $1 AND receipt_number = $2)`
+	case DocPurchaseOrder:
+		query = `SELECT EXISTS (SELECT 1 FROM purchase_orders WHERE company_id = $1 AND po_number = $2)`
+	case DocRefund:
+		query = `SELECT EXISTS (SELECT 1 FROM refunds WHERE company_id = $1 AND refund_number = $2)`
+	case DocDebitNote:
+		query = `SELECT EXISTS (SELECT 1 FROM debit_notes WHERE company_id = $1 AND debit_number = $2)`
+	default:
+		return false, ErrUnknownDocumentType
+	}
+
+	var taken bool
+	err := tx.QueryRow(query, companyID, number).Scan(&taken)
+	return taken, err
+}
+
+// nextDocumentNumberTx issues the next number for a document dated date.
+// The counter row is locked by the upsert, so concurrent documents of the
+// same company and type wait for each other.
+func nextDocumentNumberTx(tx *sql.Tx, companyID int64, docType string, date time.Time) (string, error) {
+	scheme, err := loadNumberingScheme(tx, companyID, docType)
+	if err != nil {
+		return "", err
+	}
+
+	period := utils.NumberingPeriod(scheme.ResetPolicy, date)
+
+	for attempt := 0; attempt < 1000; attempt++ {
+		var seq int
+		err := tx.QueryRow(`
+			INSERT INTO numbering_counters (company_id, document_type, period_key, last_number)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (company_id, document_type, period_key)
+			DO UPDATE SET last_number = GREATEST(numbering_counters.last_number + 1, $4)
+			RETURNING last_number
+		`, companyID, docType, period, scheme.StartNumber).Scan(&seq)
+		if err != nil {
+			return "", err
+		}
+
+		number := utils.FormatDocumentNumber(scheme.Template, date, seq)
+
+		taken, err := documentNumberTaken(tx, companyID, docType, number)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return number, nil
+		}
+	}
+
+	return "", fmt.Errorf("no free %s number in period %q", docType, period)
+}
+
+// List returns the scheme in effect for every document type.
+func (s *NumberingService) List(companyID int64) ([]models.NumberingScheme, error) {
+	schemes := make([]models.NumberingScheme, 0, len(documentTypeOrder))
+
+	for _, docType := range documentTypeOrder {
+		scheme, err := loadNumberingScheme(s.db, companyID, docType)
+		if err != nil {
+			return nil, err
+		}
+		schemes = append(schemes, scheme)
+	}
+
+	return schemes, nil
+}
+
+// Upsert saves the company's scheme for a document type. Counters are kept,
+// so a changed template continues from the last number of the period.
+func (s *NumberingService) Upsert(
+	companyID int64,
+	docType string,
+	req models.NumberingSchemeRequestDTO,
+) (*models.NumberingScheme, error) {
+
+	if _, ok := defaultNumberingSchemes[docType]; !ok {
+		return nil, ErrUnknownDocumentType
+	}
+
+	if req.StartNumber == 0 {
+		req.StartNumber = 1
+	}
+	if req.StartNumber < 0 {
+		return nil, &NumberingValidationError{Err: errors.New("start_number must be positive")}
+	}
+
+	if err := utils.ValidateNumberTemplate(req.Template, req.ResetPolicy); err != nil {
+		return nil, &NumberingValidationError{Err: err}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO numbering_schemes (company_id, document_type, template, reset_policy, start_number)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (company_id, document_type)
+		DO UPDATE SET
+			template = EXCLUDED.template,
+			reset_policy = EXCLUDED.reset_policy,
+			start_number = EXCLUDED.start_number,
+			updated_at = NOW()
+	`, companyID, docType, req.Template, req.ResetPolicy, req.StartNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NumberingScheme{
+		DocumentType: docType,
+		Template:     req.Template,
+		ResetPolicy:  req.ResetPolicy,
+		StartNumber:  req.StartNumber,
+	}, nil
+}
+
+// Preview returns the number the next document dated date would get,
+// without using it up.
+func (s *NumberingService) Preview(companyID int64, docType string, date time.Time) (string, error) {
+	scheme, err := loadNumberingScheme(s.db, companyID, docType)
+	if err != nil {
+		return "", err
+	}
+
+	period := utils.NumberingPeriod(scheme.ResetPolicy, date)
+
+	var last int
+	err = s.db.QueryRow(`
+		SELECT last_number
+		FROM numbering_counters
+		WHERE company_id = $1 AND document_type = $2 AND period_key = $3
+	`, companyID, docType, period).Scan(&last)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	next := last + 1
+	if next < scheme.StartNumber {
+		next = scheme.StartNumber
+	}
+
+	return utils.FormatDocumentNumber(scheme.Template, date, next), nil
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+)
+
+// Strategies for spreading a payment over a client's open invoices when
+// the user doesn't allocate it by hand.
+const (
+	AllocateByDueDate         = "due_date"         // oldest due date first
+	AllocateByInvoiceDate     = "invoice_date"     // oldest invoice first
+	AllocateBySmallestBalance = "smallest_balance" // clear small balances first
+	AllocateProportional      = "proportional"     // split by share of the total due
+)
+
+var ErrUnknownAllocationStrategy = errors.New("allocation_strategy must be due_date, invoice_date, smallest_balance or proportional")
+
+// allocationOrder is the ORDER BY for each strategy. Proportional splits
+// follow due date order when handing out leftover paise.
+var allocationOrder = map[string]string{
+	AllocateByDueDate:         "due_date ASC NULLS LAST, invoice_date ASC, id ASC",
+	AllocateByInvoiceDate:     "invoice_date ASC, id ASC",
+	AllocateBySmallestBalance: "remaining_amount ASC, invoice_date ASC, id ASC",
+	AllocateProportional:      "due_date ASC NULLS LAST, invoice_date ASC, id ASC",
+}
+
+// openInvoice is an invoice that can still take a payment.
+type openInvoice struct {
+	id          int64
+	number      string
+	invoiceDate time.Time
+	dueDate     *time.Time
+	remaining   money.Money
+}
+
+// resolveAllocationStrategy returns the requested strategy, or the
+// company's default when none was asked for.
+func resolveAllocationStrategy(q dbQuerier, companyID int64, requested string) (string, error) {
+	if requested != "" {
+		if _, ok := allocationOrder[requested]; !ok {
+			return "", ErrUnknownAllocationStrategy
+		}
+		return requested, nil
+	}
+
+	var strategy string
+	err := q.QueryRow(`
+		SELECT payment_allocation_strategy FROM companies WHERE id = $1
+	`, companyID).Scan(&strategy)
+	return strategy, err
+}
+
+// openInvoicesForAllocation loads the client's issued, unpaid invoices at
+// the company in the strategy's order. Inside a transaction the rows are
+// locked so the balances can't move before the payment is applied.
+func openInvoicesForAllocation(
+	q dbQuerier,
+	companyID, clientID int64,
+	strategy string,
+	forUpdate bool,
+) ([]openInvoice, error) {
+
+	query := `
+		SELECT id, invoice_number, invoice_date, due_date, remaining_amount
+		FROM invoices
+		WHERE company_id = $1
+		  AND client_id = $2
+		  AND status NOT IN ('draft', 'cancelled')
+		  AND remaining_amount > 0
+		ORDER BY ` + allocationOrder[strategy]
+	if forUpdate {
+		query += `
+		FOR UPDATE`
+	}
+
+	rows, err := q.Query(query, companyID, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []openInvoice
+	for rows.Next() {
+		var inv openInvoice
+		if err := rows.Scan(&inv.id, &inv.number, &inv.invoiceDate, &inv.dueDate, &inv.remaining); err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// planAllocation splits amount over invoices, which are already in the
+// strategy's order. Whatever the invoices can't take is left unallocated.
+func planAllocation(invoices []openInvoice, amount money.Money, strategy string) []models.PaymentAllocationDTO {
+	shares := make([]money.Money, len(invoices))
+
+	var due money.Money
+	for _, inv := range invoices {
+		due += inv.remaining
+	}
+
+	switch {
+	case strategy == AllocateProportional && amount < due:
+		// each invoice gets its share rounded down; the leftover paise go
+		// one at a time in due date order. amount × remaining can overflow
+		// int64 on large invoices, so the share is worked out in big.Int.
+		var given money.Money
+		for i, inv := range invoices {
+			share := new(big.Int).Mul(big.NewInt(amount.Paise()), big.NewInt(inv.remaining.Paise()))
+			share.Quo(share, big.NewInt(due.Paise()))
+			shares[i] = money.Min(money.FromPaise(share.Int64()), inv.remaining)
+			given += shares[i]
+		}
+		for left := amount - given; left > 0; {
+			for i, inv := range invoices {
+				if left == 0 {
+					break
+				}
+				if shares[i] < inv.remaining {
+					shares[i]++
+					left--
+				}
+			}
+		}
+
+	default:
+		left := amount
+		for i, inv := range invoices {
+			if left <= 0 {
+				break
+			}
+			shares[i] = money.Min(inv.remaining, left)
+			left -= shares[i]
+		}
+	}
+
+	var allocations []models.PaymentAllocationDTO
+	for i, inv := range invoices {
+		if shares[i] > 0 {
+			allocations = append(allocations, models.PaymentAllocationDTO{
+				InvoiceID: inv.id,
+				Amount:    shares[i],
+			})
+		}
+	}
+	return allocations
+}
+
+// autoAllocateTx locks the client's open invoices and plans how amount is
+// spread over them.
+func autoAllocateTx(
+	tx *sql.Tx,
+	companyID, clientID int64,
+	amount money.Money,
+	strategy string,
+) ([]models.PaymentAllocationDTO, error) {
+
+	invoices, err := openInvoicesForAllocation(tx, companyID, clientID, strategy, true)
+	if err != nil {
+		return nil, err
+	}
+	return planAllocation(invoices, amount, strategy), nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"invo-server/internal/money"
+)
+
+func TestPlanAllocationProportionalIsExact(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining []money.Money
+		amount    money.Money
+	}{
+		{name: "thirds", remaining: []money.Money{100, 100, 100}, amount: 100},
+		{name: "odd balances", remaining: []money.Money{333, 667, 1001, 9}, amount: 1009},
+		{name: "one paisa", remaining: []money.Money{50, 50}, amount: 1},
+		// past 2^53 paise a float share rounds up over the exact one
+		{name: "large invoices", remaining: []money.Money{
+			10723443921859614, 59555579988162157, 85285721025171022,
+		}, amount: 155564744935192790},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoices := make([]openInvoice, len(tt.remaining))
+			for i, r := range tt.remaining {
+				invoices[i] = openInvoice{id: int64(i + 1), remaining: r}
+			}
+
+			var total money.Money
+			for _, a := range planAllocation(invoices, tt.amount, AllocateProportional) {
+				if a.Amount <= 0 || a.Amount > tt.remaining[a.InvoiceID-1] {
+					t.Errorf("invoice %d allocated %s of %s", a.InvoiceID, a.Amount, tt.remaining[a.InvoiceID-1])
+				}
+				total += a.Amount
+			}
+			if total != tt.amount {
+				t.Errorf("allocated %s, want %s", total, tt.amount)
+			}
+		})
+	}
+}
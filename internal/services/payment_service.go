@@ -3,10 +3,15 @@ package services
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"invo-server/internal/models"
-	"math"
+	"invo-server/internal/money"
+	"invo-server/internal/pdf"
+	"time"
 )
 
+var ErrPaymentVoided = errors.New("payment is already voided")
+
 type PaymentService struct {
 	db     *sql.DB
 	ledger *LedgerService
@@ -16,53 +21,71 @@ func NewPaymentService(db *sql.DB, ledger *LedgerService) *PaymentService {
 	return &PaymentService{db: db, ledger: ledger}
 }
 
+// RecordPaymentTx records money received from a client and applies it to
+// open invoices, by the request's allocations or allocation strategy.
+// Whatever isn't allocated - an advance, or an overpayment - stays on the
+// payment as unapplied credit for later invoices. The ledger is credited with
+// the full amount either way. Returns the payment id and the unapplied amount.
 func (s *PaymentService) RecordPaymentTx(
 	tx *sql.Tx,
 	companyID int64,
 	clientID int64,
 	req models.PaymentRequestDTO,
-) error {
+) (int64, money.Money, error) {
 
 	// 1️⃣ Auto-allocate if allocations not provided
 	if len(req.Allocations) == 0 {
-		allocations, err := s.autoAllocateFIFO(
+		strategy, err := resolveAllocationStrategy(tx, companyID, req.AllocationStrategy)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		allocations, err := autoAllocateTx(
 			tx,
+			companyID,
 			clientID,
 			req.Amount,
+			strategy,
 		)
 		if err != nil {
-			return err
+			return 0, 0, err
 		}
 		req.Allocations = allocations
 	}
 
 	// 2️⃣ Validate allocation total
-	var allocated float64
+	var allocated money.Money
 	for _, a := range req.Allocations {
 		allocated += a.Amount
 	}
 
-	// float-safe comparison
-	if math.Abs(allocated-req.Amount) > 0.01 {
-		return errors.New("allocation total does not match payment amount")
+	if allocated > req.Amount {
+		return 0, 0, errors.New("allocation total exceeds payment amount")
+	}
+
+	// 3️⃣ Insert payment with the next receipt number
+	receiptNumber, err := nextDocumentNumberTx(tx, companyID, DocReceipt, time.Now())
+	if err != nil {
+		return 0, 0, err
 	}
 
-	// 3️⃣ Insert payment
 	var paymentID int64
-	err := tx.QueryRow(`
+	err = tx.QueryRow(`
 		INSERT INTO payments (
 			company_id,
 			client_id,
+			receipt_number,
 			amount,
 			payment_method,
 			reference,
 			notes
 		)
-		VALUES ($1,$2,$3,$4,$5,$6)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
 		RETURNING id
 	`,
 		companyID,
 		clientID,
+		receiptNumber,
 		req.Amount,
 		req.PaymentMethod,
 		req.Reference,
@@ -70,116 +93,726 @@ func (s *PaymentService) RecordPaymentTx(
 	).Scan(&paymentID)
 
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
 
 	// 4️⃣ Apply allocations
 	for _, alloc := range req.Allocations {
+		if err := applyPaymentAllocationTx(tx, companyID, clientID, paymentID, alloc); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// 5️⃣ Ledger entry (ONE credit entry)
+	err = s.ledger.AddEntryTx(
+		tx,
+		companyID,
+		clientID,
+		"PAYMENT",
+		paymentID,
+		0,
+		req.Amount,
+		"Payment received",
+	)
+	if err != nil {
+		return 0, 0, err
+	}
 
-		var remaining float64
-		err := tx.QueryRow(`
-			SELECT remaining_amount
-			FROM invoices
-			WHERE id = $1
-			FOR UPDATE
-		`, alloc.InvoiceID).Scan(&remaining)
+	return paymentID, req.Amount - allocated, nil
+}
 
-		if err != nil {
-			return err
+// applyPaymentAllocationTx puts part of a payment against one of the
+// client's open invoices. Applying the same payment to an invoice again adds
+// to the existing allocation.
+func applyPaymentAllocationTx(
+	tx *sql.Tx,
+	companyID, clientID, paymentID int64,
+	alloc models.PaymentAllocationDTO,
+) error {
+
+	if alloc.Amount <= 0 {
+		return errors.New("allocation amount must be positive")
+	}
+
+	var remaining money.Money
+	var status string
+	err := tx.QueryRow(`
+		SELECT remaining_amount, status
+		FROM invoices
+		WHERE id = $1 AND company_id = $2 AND client_id = $3
+		FOR UPDATE
+	`, alloc.InvoiceID, companyID, clientID).Scan(&remaining, &status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("invoice %d not found for this client", alloc.InvoiceID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if status == "draft" || status == "cancelled" {
+		return fmt.Errorf("cannot apply a payment to a %s invoice", status)
+	}
+
+	if alloc.Amount > remaining {
+		return errors.New("allocation exceeds invoice balance")
+	}
+
+	// save allocation
+	_, err = tx.Exec(`
+		INSERT INTO payment_allocations
+			(payment_id, invoice_id, amount)
+		VALUES ($1,$2,$3)
+		ON CONFLICT (payment_id, invoice_id)
+		DO UPDATE SET amount = payment_allocations.amount + EXCLUDED.amount
+	`, paymentID, alloc.InvoiceID, alloc.Amount)
+	if err != nil {
+		return err
+	}
+
+	// update invoice
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET
+			paid_amount = paid_amount + $1,
+			remaining_amount = remaining_amount - $1,
+			status = CASE
+				WHEN remaining_amount - $1 <= 0 THEN 'paid'
+				ELSE 'partial'
+			END
+		WHERE id = $2
+	`, alloc.Amount, alloc.InvoiceID)
+	return err
+}
+
+// paymentUnappliedSQL is the part of payment p not yet allocated to an
+// invoice or refunded.
+const paymentUnappliedSQL = `(p.amount
+	- COALESCE((SELECT SUM(amount) FROM payment_allocations WHERE payment_id = p.id), 0)
+	- COALESCE((SELECT SUM(amount) FROM refunds WHERE payment_id = p.id), 0))`
+
+// applyUnappliedPaymentsTx uses the client's unapplied payment credit,
+// oldest payment first, against up to due of an invoice. Returns the amount
+// applied.
+func applyUnappliedPaymentsTx(
+	tx *sql.Tx,
+	companyID, clientID, invoiceID int64,
+	due money.Money,
+) (money.Money, error) {
+
+	rows, err := tx.Query(`
+		SELECT p.id, `+paymentUnappliedSQL+`
+		FROM payments p
+		WHERE p.company_id = $1
+		  AND p.client_id = $2
+		  AND p.status = 'recorded'
+		ORDER BY p.created_at, p.id
+		FOR UPDATE
+	`, companyID, clientID)
+	if err != nil {
+		return 0, err
+	}
+
+	type draw struct {
+		paymentID int64
+		amount    money.Money
+	}
+
+	var draws []draw
+	remaining := due
+	for remaining > 0 && rows.Next() {
+		var paymentID int64
+		var unapplied money.Money
+		if err := rows.Scan(&paymentID, &unapplied); err != nil {
+			rows.Close()
+			return 0, err
 		}
+		if unapplied <= 0 {
+			continue
+		}
+
+		amount := money.Min(unapplied, remaining)
+		draws = append(draws, draw{paymentID: paymentID, amount: amount})
+		remaining -= amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
 
-		if alloc.Amount > remaining {
-			return errors.New("allocation exceeds invoice balance")
+	// the rows must be closed before the connection runs more statements
+	for _, d := range draws {
+		err := applyPaymentAllocationTx(tx, companyID, clientID, d.paymentID, models.PaymentAllocationDTO{
+			InvoiceID: invoiceID,
+			Amount:    d.amount,
+		})
+		if err != nil {
+			return 0, err
 		}
+	}
+
+	return due - remaining, nil
+}
+
+// ApplyTx applies a payment's unapplied credit to open invoices: the given
+// allocations, or else spread by the allocation strategy until the credit
+// runs out.
+func (s *PaymentService) ApplyTx(
+	tx *sql.Tx,
+	userID int,
+	paymentID int64,
+	req models.ApplyPaymentRequestDTO,
+) (*models.Payment, error) {
+
+	var companyID, clientID int64
+	var status string
+	var unapplied money.Money
+	err := tx.QueryRow(`
+		SELECT p.company_id, p.client_id, p.status, `+paymentUnappliedSQL+`
+		FROM payments p
+		JOIN companies c ON c.id = p.company_id
+		WHERE p.id = $1 AND c.user_id = $2
+		FOR UPDATE OF p
+	`, paymentID, userID).Scan(&companyID, &clientID, &status, &unapplied)
+	if err == sql.ErrNoRows {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		// save allocation
-		_, err = tx.Exec(`
-			INSERT INTO payment_allocations
-				(payment_id, invoice_id, amount)
-			VALUES ($1,$2,$3)
-		`, paymentID, alloc.InvoiceID, alloc.Amount)
+	if status == "voided" {
+		return nil, ErrPaymentVoided
+	}
+	if unapplied <= 0 {
+		return nil, errors.New("payment has no unapplied amount")
+	}
 
+	allocations := req.Allocations
+	if len(allocations) == 0 {
+		strategy, err := resolveAllocationStrategy(tx, companyID, req.AllocationStrategy)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		// update invoice
-		_, err = tx.Exec(`
-			UPDATE invoices
-			SET
-				paid_amount = paid_amount + $1,
-				remaining_amount = remaining_amount - $1,
-				status = CASE
-					WHEN remaining_amount - $1 <= 0 THEN 'paid'
-					ELSE 'partial'
-				END
-			WHERE id = $2
-		`, alloc.Amount, alloc.InvoiceID)
+		allocations, err = autoAllocateTx(tx, companyID, clientID, unapplied, strategy)
+		if err != nil {
+			return nil, err
+		}
+		if len(allocations) == 0 {
+			return nil, errors.New("client has no open invoices")
+		}
+	}
+
+	var total money.Money
+	for _, a := range allocations {
+		total += a.Amount
+	}
+	if total > unapplied {
+		return nil, fmt.Errorf("allocations exceed the unapplied amount of %s", unapplied)
+	}
+
+	for _, a := range allocations {
+		if err := applyPaymentAllocationTx(tx, companyID, clientID, paymentID, a); err != nil {
+			return nil, err
+		}
+	}
+
+	return getPayment(tx, userID, paymentID)
+}
+
+// ClientCredit sums what one of the user's clients has paid in advance or
+// overpaid, with the payments it sits on, and their open credit note
+// balance.
+func (s *PaymentService) ClientCredit(userID int, clientID int64) (*models.ClientCredit, error) {
+	var companyID int64
+	err := s.db.QueryRow(`
+		SELECT cl.company_id
+		FROM clients cl
+		JOIN companies c ON c.id = cl.company_id
+		WHERE cl.id = $1 AND c.user_id = $2
+	`, clientID, userID).Scan(&companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	credit := &models.ClientCredit{
+		ClientID: clientID,
+		Payments: []models.UnappliedPayment{},
+	}
+
+	rows, err := s.db.Query(`
+		SELECT * FROM (
+			SELECT p.id, p.receipt_number, p.amount, `+paymentUnappliedSQL+` AS unapplied, p.created_at
+			FROM payments p
+			WHERE p.company_id = $1 AND p.client_id = $2 AND p.status = 'recorded'
+		) u
+		WHERE u.unapplied > 0
+		ORDER BY u.created_at, u.id
+	`, companyID, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.UnappliedPayment
+		if err := rows.Scan(&p.PaymentID, &p.ReceiptNumber, &p.Amount, &p.Unapplied, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		credit.UnappliedPayments += p.Unapplied
+		credit.Payments = append(credit.Payments, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRow(`
+		SELECT COALESCE(SUM(balance), 0)
+		FROM credit_notes
+		WHERE company_id = $1 AND client_id = $2
+	`, companyID, clientID).Scan(&credit.CreditNoteBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	credit.Total = credit.UnappliedPayments + credit.CreditNoteBalance
+	return credit, nil
+}
+
+const paymentSelectSQL = `
+	SELECT
+		p.id, p.company_id, p.client_id, cl.name, p.receipt_number,
+		p.amount, COALESCE(p.payment_method, ''), COALESCE(p.reference, ''),
+		COALESCE(p.notes, ''), p.status, p.voided_at, COALESCE(p.void_reason, ''),
+		p.created_at
+	FROM payments p
+	JOIN clients cl ON cl.id = p.client_id`
 
+func scanPayment(row rowScanner) (*models.Payment, error) {
+	var p models.Payment
+	err := row.Scan(
+		&p.ID,
+		&p.CompanyID,
+		&p.ClientID,
+		&p.ClientName,
+		&p.ReceiptNumber,
+		&p.Amount,
+		&p.PaymentMethod,
+		&p.Reference,
+		&p.Notes,
+		&p.Status,
+		&p.VoidedAt,
+		&p.VoidReason,
+		&p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// List returns the company's payments, newest first.
+func (s *PaymentService) List(
+	companyID int64,
+	filter models.PaymentFilter,
+	limit, offset int,
+) ([]models.Payment, error) {
+
+	rows, err := s.db.Query(paymentSelectSQL+`
+		WHERE p.company_id = $1
+		  AND ($2 = 0 OR p.client_id = $2)
+		  AND ($3 = '' OR p.status = $3)
+		  AND ($4 = '' OR p.payment_method = $4)
+		  AND ($5::date IS NULL OR p.created_at::date >= $5)
+		  AND ($6::date IS NULL OR p.created_at::date <= $6)
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $7 OFFSET $8
+	`,
+		companyID,
+		filter.ClientID,
+		filter.Status,
+		filter.PaymentMethod,
+		filter.From,
+		filter.To,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []models.Payment{}
+	for rows.Next() {
+		p, err := scanPayment(rows)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		payments = append(payments, *p)
 	}
 
-	// 5️⃣ Ledger entry (ONE credit entry)
-	return s.ledger.AddEntryTx(
+	return payments, rows.Err()
+}
+
+// GetByID returns one of the user's payments with the invoices it was
+// applied to.
+func (s *PaymentService) GetByID(userID int, paymentID int64) (*models.Payment, error) {
+	return getPayment(s.db, userID, paymentID)
+}
+
+func getPayment(q dbQuerier, userID int, paymentID int64) (*models.Payment, error) {
+	p, err := scanPayment(q.QueryRow(paymentSelectSQL+`
+		JOIN companies c ON c.id = p.company_id
+		WHERE p.id = $1 AND c.user_id = $2
+	`, paymentID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`
+		SELECT a.id, a.invoice_id, i.invoice_number, a.amount, a.created_at
+		FROM payment_allocations a
+		JOIN invoices i ON i.id = a.invoice_id
+		WHERE a.payment_id = $1
+		ORDER BY a.id
+	`, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.Allocations = []models.PaymentAllocationResponse{}
+	for rows.Next() {
+		var a models.PaymentAllocationResponse
+		if err := rows.Scan(
+			&a.ID,
+			&a.InvoiceID,
+			&a.InvoiceNumber,
+			&a.Amount,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		p.Allocations = append(p.Allocations, a)
+	}
+
+	return p, rows.Err()
+}
+
+// VoidTx reverses a payment recorded in error. Each invoice it was applied
+// to gets the amount back as outstanding, the allocations are removed, and
+// a PAYMENT debit cancels the original ledger credit. A payment that has
+// been partly refunded can't be voided: that money has already gone back.
+func (s *PaymentService) VoidTx(
+	tx *sql.Tx,
+	userID int,
+	paymentID int64,
+	reason string,
+) (*models.Payment, error) {
+
+	// 1️⃣ Lock the payment
+	var companyID, clientID int64
+	var amount money.Money
+	var status string
+	var receiptNumber *string
+	err := tx.QueryRow(`
+		SELECT p.company_id, p.client_id, p.amount, p.status, p.receipt_number
+		FROM payments p
+		JOIN companies c ON c.id = p.company_id
+		WHERE p.id = $1 AND c.user_id = $2
+		FOR UPDATE OF p
+	`, paymentID, userID).Scan(&companyID, &clientID, &amount, &status, &receiptNumber)
+	if err == sql.ErrNoRows {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "voided" {
+		return nil, ErrPaymentVoided
+	}
+
+	var refunded bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM refunds WHERE payment_id = $1)
+	`, paymentID).Scan(&refunded)
+	if err != nil {
+		return nil, err
+	}
+	if refunded {
+		return nil, errors.New("payment has refunds against it and cannot be voided")
+	}
+
+	// 2️⃣ Give each invoice its outstanding amount back
+	_, err = tx.Exec(`
+		WITH released AS (
+			DELETE FROM payment_allocations
+			WHERE payment_id = $1
+			RETURNING invoice_id, amount
+		)
+		UPDATE invoices i
+		SET
+			paid_amount = i.paid_amount - r.amount,
+			remaining_amount = i.remaining_amount + r.amount,
+			status = CASE
+				WHEN i.remaining_amount + r.amount >= i.total + COALESCE((
+					SELECT SUM(dn.total) FROM debit_notes dn WHERE dn.invoice_id = i.id
+				), 0) THEN 'issued'
+				ELSE 'partial'
+			END,
+			updated_at = NOW()
+		FROM (
+			SELECT invoice_id, SUM(amount) AS amount
+			FROM released
+			GROUP BY invoice_id
+		) r
+		WHERE i.id = r.invoice_id
+	`, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3️⃣ Mark voided
+	_, err = tx.Exec(`
+		UPDATE payments
+		SET status = 'voided', voided_at = NOW(), void_reason = $2
+		WHERE id = $1
+	`, paymentID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Reversing ledger entry (debit)
+	narration := "Payment voided"
+	if receiptNumber != nil {
+		narration = "Payment " + *receiptNumber + " voided"
+	}
+	err = s.ledger.AddEntryTx(
 		tx,
 		companyID,
 		clientID,
 		"PAYMENT",
 		paymentID,
+		amount,
 		0,
-		req.Amount,
-		"Payment received",
+		narration+": "+reason,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPayment(tx, userID, paymentID)
 }
 
-func (s *PaymentService) autoAllocateFIFO(
-	tx *sql.Tx,
-	clientID int64,
-	amount float64,
-) ([]models.PaymentAllocationDTO, error) {
+// PreviewAllocation shows how a payment from one of the user's clients
+// would be split, without recording anything.
+func (s *PaymentService) PreviewAllocation(
+	userID int,
+	req models.AllocationPreviewRequestDTO,
+) (*models.AllocationPreview, error) {
 
-	rows, err := tx.Query(`
-		SELECT id, remaining_amount
-		FROM invoices
-		WHERE client_id = $1
-		  AND remaining_amount > 0
-		ORDER BY invoice_date ASC
-		FOR UPDATE
-	`, clientID)
+	var companyID int64
+	err := s.db.QueryRow(`
+		SELECT cl.company_id
+		FROM clients cl
+		JOIN companies c ON c.id = cl.company_id
+		WHERE cl.id = $1 AND c.user_id = $2
+	`, req.ClientID, userID).Scan(&companyID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	remaining := amount
-	var allocations []models.PaymentAllocationDTO
-
-	for rows.Next() && remaining > 0 {
-		var invoiceID int64
-		var due float64
+	strategy, err := resolveAllocationStrategy(s.db, companyID, req.AllocationStrategy)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := rows.Scan(&invoiceID, &due); err != nil {
-			return nil, err
-		}
+	invoices, err := openInvoicesForAllocation(s.db, companyID, req.ClientID, strategy, false)
+	if err != nil {
+		return nil, err
+	}
 
-		applied := due
-		if remaining < due {
-			applied = remaining
-		}
+	byID := map[int64]openInvoice{}
+	for _, inv := range invoices {
+		byID[inv.id] = inv
+	}
 
-		allocations = append(allocations, models.PaymentAllocationDTO{
-			InvoiceID: invoiceID,
-			Amount:    applied,
+	preview := &models.AllocationPreview{
+		Strategy:    strategy,
+		Amount:      req.Amount,
+		Allocations: []models.AllocationPreviewLine{},
+	}
+	for _, a := range planAllocation(invoices, req.Amount, strategy) {
+		inv := byID[a.InvoiceID]
+		preview.Allocations = append(preview.Allocations, models.AllocationPreviewLine{
+			InvoiceID:     inv.id,
+			InvoiceNumber: inv.number,
+			InvoiceDate:   inv.invoiceDate,
+			DueDate:       inv.dueDate,
+			Remaining:     inv.remaining,
+			Amount:        a.Amount,
 		})
+		preview.Allocated += a.Amount
+	}
+	preview.Unapplied = req.Amount - preview.Allocated
 
-		remaining -= applied
+	return preview, nil
+}
+
+// GetAllocationStrategy returns the company's default allocation strategy.
+func (s *PaymentService) GetAllocationStrategy(companyID int64) (string, error) {
+	return resolveAllocationStrategy(s.db, companyID, "")
+}
+
+// SetAllocationStrategy changes the company's default allocation strategy.
+func (s *PaymentService) SetAllocationStrategy(companyID int64, strategy string) error {
+	if _, ok := allocationOrder[strategy]; !ok {
+		return ErrUnknownAllocationStrategy
 	}
 
-	if remaining > 0 {
-		return nil, errors.New("payment exceeds outstanding balance")
+	_, err := s.db.Exec(`
+		UPDATE companies SET payment_allocation_strategy = $1 WHERE id = $2
+	`, strategy, companyID)
+	return err
+}
+
+// FetchPaymentReceiptPDFData loads a payment with the company, the client's
+// billing address and the invoices it settled for the receipt.
+func FetchPaymentReceiptPDFData(db *sql.DB, paymentID int64) (pdf.VoucherPDFData, error) {
+	var data pdf.VoucherPDFData
+	var createdAt time.Time
+	var clientID int64
+	var receiptNumber *string
+	var method, reference, status string
+
+	err := db.QueryRow(`
+		SELECT
+			p.receipt_number,
+			p.created_at,
+			p.amount,
+			COALESCE(p.payment_method, ''),
+			COALESCE(p.reference, ''),
+			COALESCE(p.notes, ''),
+			p.status,
+			p.client_id,
+			cl.name,
+			c.name,
+			COALESCE(c.phone, ''),
+			COALESCE(c.address, ''),
+			COALESCE(c.city, ''),
+			COALESCE(c.state, ''),
+			COALESCE(c.pincode, ''),
+			COALESCE(c.gst, '')
+		FROM payments p
+		JOIN companies c ON c.id = p.company_id
+		JOIN clients cl ON cl.id = p.client_id
+		WHERE p.id = $1
+	`, paymentID).Scan(
+		&receiptNumber,
+		&createdAt,
+		&data.Amount,
+		&method,
+		&reference,
+		&data.Notes,
+		&status,
+		&clientID,
+		&data.Party.Name,
+		&data.Company.Name,
+		&data.Company.Phone,
+		&data.CompanyAddress.Line1,
+		&data.CompanyAddress.City,
+		&data.CompanyAddress.State,
+		&data.CompanyAddress.Zip,
+		&data.CompanyGSTIN,
+	)
+	if err != nil {
+		return data, fmt.Errorf("fetch payment: %w", err)
 	}
 
-	return allocations, nil
+	// payments recorded before receipt numbering have none
+	data.Number = fmt.Sprintf("PAY-%d", paymentID)
+	if receiptNumber != nil {
+		data.Number = *receiptNumber
+	}
+	data.Date = createdAt.Format("02-01-2006")
+	data.CompanyAddress.Name = data.Company.Name
+	data.CompanyAddress.Country = "India"
+
+	if method != "" {
+		data.Details = append(data.Details, [2]string{"Mode", method})
+	}
+	if reference != "" {
+		data.Details = append(data.Details, [2]string{"Reference", reference})
+	}
+	if status == "voided" {
+		data.Details = append(data.Details, [2]string{"Status", "VOIDED"})
+	}
+
+	// Client billing address, when there is one
+	var addrName string
+	err = db.QueryRow(`
+		SELECT COALESCE(name, ''), line1, COALESCE(city, ''), COALESCE(state, ''),
+		       COALESCE(country, ''), COALESCE(postal_code, '')
+		FROM client_addresses
+		WHERE client_id = $1 AND type = 'billing'
+	`, clientID).Scan(
+		&addrName,
+		&data.Party.Line1,
+		&data.Party.City,
+		&data.Party.State,
+		&data.Party.Country,
+		&data.Party.Zip,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return data, fmt.Errorf("fetch client address: %w", err)
+	}
+	if addrName != "" {
+		data.Party.Name = addrName
+	}
+
+	// Invoices settled
+	rows, err := db.Query(`
+		SELECT i.invoice_number, i.invoice_date, a.amount
+		FROM payment_allocations a
+		JOIN invoices i ON i.id = a.invoice_id
+		WHERE a.payment_id = $1
+		ORDER BY i.invoice_date, i.id
+	`, paymentID)
+	if err != nil {
+		return data, fmt.Errorf("fetch allocations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line pdf.VoucherLine
+		var invoiceDate time.Time
+		if err := rows.Scan(&line.Number, &invoiceDate, &line.Amount); err != nil {
+			return data, err
+		}
+		line.Date = invoiceDate.Format("02-01-2006")
+		data.Lines = append(data.Lines, line)
+	}
+
+	return data, rows.Err()
+}
+
+// ClientEmailRecipient returns who a client's payment receipts are mailed
+// to: the billing address email, or the client's own email when that is
+// empty.
+func ClientEmailRecipient(q dbQuerier, clientID int64) (name, email string, err error) {
+	err = q.QueryRow(`
+		SELECT cl.name, COALESCE(NULLIF(ca.email, ''), cl.email)
+		FROM clients cl
+		LEFT JOIN client_addresses ca
+		       ON ca.client_id = cl.id AND ca.type = 'billing'
+		WHERE cl.id = $1
+	`, clientID).Scan(&name, &email)
+	if err != nil {
+		return "", "", fmt.Errorf("fetch client email: %w", err)
+	}
+	return name, email, nil
 }
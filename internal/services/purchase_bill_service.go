@@ -0,0 +1,612 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	utils "invo-server/internal/util"
+)
+
+var (
+	ErrPurchaseBillNotFound  = errors.New("purchase bill not found")
+	ErrDuplicatePurchaseBill = errors.New("a bill with this number already exists for the vendor")
+)
+
+type PurchaseBillService struct {
+	db     *sql.DB
+	ledger *VendorLedgerService
+	stock  *StockService
+}
+
+func NewPurchaseBillService(db *sql.DB, ledger *VendorLedgerService, stock *StockService) *PurchaseBillService {
+	return &PurchaseBillService{db: db, ledger: ledger, stock: stock}
+}
+
+func validatePurchaseItems(items []models.PurchaseBillItemRequest) error {
+	if len(items) == 0 {
+		return errors.New("bill must contain at least one item")
+	}
+
+	for i, it := range items {
+		switch {
+		case it.ItemID == 0 && strings.TrimSpace(it.Description) == "":
+			return fmt.Errorf("item %d: item_id or description is required", i+1)
+		case it.Qty <= 0:
+			return fmt.Errorf("item %d: qty must be positive", i+1)
+		case it.Rate < 0 || it.Discount < 0:
+			return fmt.Errorf("item %d: rate and discount must not be negative", i+1)
+		case it.Discount > it.Rate.Mul(float64(it.Qty)):
+			return fmt.Errorf("item %d: discount exceeds line amount", i+1)
+		case it.TaxRate < 0:
+			return fmt.Errorf("item %d: tax_rate must not be negative", i+1)
+		case it.HSNCode != "" && !utils.ValidHSN(it.HSNCode):
+			return fmt.Errorf("item %d: invalid HSN/SAC code", i+1)
+		}
+	}
+
+	return nil
+}
+
+// CreateTx records a vendor bill: it computes the GST split from the
+// vendor's and company's states, posts the payable to the vendor ledger and
+// brings catalogue items into stock unless the bill is against a purchase
+// order.
+func (s *PurchaseBillService) CreateTx(
+	tx *sql.Tx,
+	userID int,
+	req models.PurchaseBillRequestDTO,
+) (*models.PurchaseBill, error) {
+
+	// 1️⃣ Validate input
+	if err := validatePurchaseItems(req.Items); err != nil {
+		return nil, err
+	}
+
+	billDate, err := time.Parse("2006-01-02", req.BillDate)
+	if err != nil {
+		return nil, errors.New("invalid bill_date (YYYY-MM-DD)")
+	}
+
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		return nil, errors.New("invalid due_date (YYYY-MM-DD)")
+	}
+
+	if dueDate.Before(billDate) {
+		return nil, errors.New("due_date must not be before bill_date")
+	}
+
+	billNumber := strings.TrimSpace(req.BillNumber)
+
+	// 2️⃣ Vendor, company and where the supply comes from
+	var (
+		companyID                         int64
+		vendorName, vendorGSTIN, vendorSt string
+		companyGSTIN, companyState        string
+	)
+	err = tx.QueryRow(`
+		SELECT v.company_id, v.name,
+		       COALESCE(NULLIF(v.gstin, ''), va.gst_number, ''), COALESCE(va.state, ''),
+		       COALESCE(c.gst, ''), COALESCE(c.state, '')
+		FROM vendors v
+		JOIN companies c ON c.id = v.company_id
+		LEFT JOIN vendor_addresses va ON va.vendor_id = v.id AND va.type = 'billing'
+		WHERE v.id = $1 AND c.user_id = $2
+	`, req.VendorID, userID).Scan(
+		&companyID, &vendorName, &vendorGSTIN, &vendorSt,
+		&companyGSTIN, &companyState,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrVendorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicate bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM purchase_bills
+			WHERE company_id = $1 AND vendor_id = $2 AND bill_number = $3
+		)
+	`, companyID, req.VendorID, billNumber).Scan(&duplicate)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate {
+		return nil, ErrDuplicatePurchaseBill
+	}
+
+	if req.PurchaseOrderID != nil {
+		var poStatus string
+		err = tx.QueryRow(`
+			SELECT status FROM purchase_orders
+			WHERE id = $1 AND company_id = $2 AND vendor_id = $3
+		`, *req.PurchaseOrderID, companyID, req.VendorID).Scan(&poStatus)
+		if err == sql.ErrNoRows {
+			return nil, ErrPurchaseOrderNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if poStatus == "draft" {
+			return nil, errors.New("cannot bill a purchase order that has not been sent")
+		}
+	}
+
+	supplierState := utils.ResolveStateCode(vendorGSTIN, vendorSt)
+	recipientState := utils.ResolveStateCode(companyGSTIN, companyState)
+	interState := supplierState != "" && recipientState != "" && supplierState != recipientState
+
+	// 3️⃣ Tax per line, split into GST heads
+	reqItems := make([]models.InvoiceItemRequest, len(req.Items))
+	for i, it := range req.Items {
+		reqItems[i] = models.InvoiceItemRequest{
+			ItemID:   it.ItemID,
+			Qty:      it.Qty,
+			Rate:     it.Rate,
+			Discount: it.Discount,
+			TaxRate:  it.TaxRate,
+		}
+	}
+	lines, totals := splitInvoiceLines(reqItems, interState)
+
+	itcEligible := true
+	if req.ITCEligible != nil {
+		itcEligible = *req.ITCEligible
+	}
+
+	// 4️⃣ Insert bill
+	bill := &models.PurchaseBill{
+		CompanyID:       companyID,
+		VendorID:        req.VendorID,
+		VendorName:      vendorName,
+		BillNumber:      billNumber,
+		BillDate:        billDate,
+		DueDate:         dueDate,
+		Subtotal:        totals.Subtotal,
+		CGST:            totals.CGST,
+		SGST:            totals.SGST,
+		IGST:            totals.IGST,
+		Tax:             totals.Tax,
+		Total:           totals.Total,
+		RemainingAmount: totals.Total,
+		ITCEligible:     itcEligible,
+		Status:          "unpaid",
+		PurchaseOrderID: req.PurchaseOrderID,
+		Notes:           stringValue(req.Notes),
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO purchase_bills (
+			company_id, user_id, vendor_id,
+			bill_number, bill_date, due_date, supplier_state_code,
+			subtotal, cgst, sgst, igst, tax, total,
+			paid_amount, remaining_amount, itc_eligible, status, notes,
+			purchase_order_id
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,NULLIF($7, ''),$8,$9,$10,$11,$12,$13,0,$13,$14,'unpaid',$15,$16)
+		RETURNING id, created_at
+	`,
+		companyID,
+		userID,
+		req.VendorID,
+		billNumber,
+		billDate,
+		dueDate,
+		supplierState,
+		totals.Subtotal,
+		totals.CGST,
+		totals.SGST,
+		totals.IGST,
+		totals.Tax,
+		totals.Total,
+		itcEligible,
+		req.Notes,
+		req.PurchaseOrderID,
+	).Scan(&bill.ID, &bill.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Insert items; HSN defaults to the catalogue item's
+	for i, line := range lines {
+		src := req.Items[i]
+
+		var itemID *int64
+		if src.ItemID != 0 {
+			var exists bool
+			err = tx.QueryRow(`
+				SELECT EXISTS (SELECT 1 FROM items WHERE id = $1 AND company_id = $2)
+			`, src.ItemID, companyID).Scan(&exists)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, fmt.Errorf("item %d: %w", i+1, ErrStockItemNotFound)
+			}
+
+			id := int64(src.ItemID)
+			itemID = &id
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO purchase_bill_items (
+				bill_id, item_id, description, hsn_code,
+				qty, rate, discount, tax_rate,
+				cgst_amount, sgst_amount, igst_amount, total
+			)
+			VALUES (
+				$1, $2, NULLIF($3, ''),
+				COALESCE(NULLIF($4, ''), (SELECT hsn_code FROM items WHERE id = $2 AND company_id = $13)),
+				$5, $6, $7, $8, $9, $10, $11, $12
+			)
+		`,
+			bill.ID,
+			itemID,
+			strings.TrimSpace(src.Description),
+			strings.TrimSpace(src.HSNCode),
+			line.Qty,
+			line.Rate,
+			line.Discount,
+			line.TaxRate,
+			line.CGST,
+			line.SGST,
+			line.IGST,
+			line.Total,
+			companyID,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 6️⃣ Goods into stock, unless they were received against the order
+	if req.PurchaseOrderID == nil {
+		if err := s.stock.PurchaseTx(tx, companyID, bill.ID, "Bill "+billNumber+" from "+vendorName); err != nil {
+			return nil, err
+		}
+	}
+
+	// 7️⃣ Payable on the vendor ledger
+	err = s.ledger.AddEntryTx(
+		tx,
+		companyID,
+		req.VendorID,
+		"BILL",
+		bill.ID,
+		0,
+		totals.Total,
+		"Bill "+billNumber,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return bill, nil
+}
+
+// List returns the company's bills, newest first. status filters on
+// unpaid, partial or paid when set.
+func (s *PurchaseBillService) List(companyID int64, status string, limit, offset int) ([]models.PurchaseBill, error) {
+	query := `
+		SELECT
+			b.id, b.company_id, b.vendor_id, v.name,
+			b.bill_number, b.bill_date, b.due_date,
+			b.subtotal, b.cgst, b.sgst, b.igst, b.tax, b.total,
+			b.paid_amount, b.remaining_amount, b.itc_eligible, b.status,
+			b.purchase_order_id, COALESCE(b.notes, ''), b.created_at
+		FROM purchase_bills b
+		JOIN vendors v ON v.id = b.vendor_id
+		WHERE b.company_id = $1
+	`
+	args := []interface{}{companyID}
+
+	if status != "" {
+		query += ` AND b.status = $2`
+		args = append(args, status)
+	}
+
+	query += fmt.Sprintf(`
+		ORDER BY b.bill_date DESC, b.id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bills := []models.PurchaseBill{}
+	for rows.Next() {
+		b, err := scanPurchaseBill(rows)
+		if err != nil {
+			return nil, err
+		}
+		bills = append(bills, *b)
+	}
+
+	return bills, rows.Err()
+}
+
+func (s *PurchaseBillService) GetByID(userID int, billID int64) (*models.PurchaseBill, error) {
+	row := s.db.QueryRow(`
+		SELECT
+			b.id, b.company_id, b.vendor_id, v.name,
+			b.bill_number, b.bill_date, b.due_date,
+			b.subtotal, b.cgst, b.sgst, b.igst, b.tax, b.total,
+			b.paid_amount, b.remaining_amount, b.itc_eligible, b.status,
+			b.purchase_order_id, COALESCE(b.notes, ''), b.created_at
+		FROM purchase_bills b
+		JOIN vendors v ON v.id = b.vendor_id
+		JOIN companies c ON c.id = b.company_id
+		WHERE b.id = $1 AND c.user_id = $2
+	`, billID, userID)
+
+	bill, err := scanPurchaseBill(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrPurchaseBillNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, item_id, COALESCE(description, ''), COALESCE(hsn_code, ''),
+		       qty, rate, discount, tax_rate,
+		       cgst_amount, sgst_amount, igst_amount, total
+		FROM purchase_bill_items
+		WHERE bill_id = $1
+		ORDER BY id
+	`, billID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bill.Items = []models.PurchaseBillItem{}
+	for rows.Next() {
+		var it models.PurchaseBillItem
+		if err := rows.Scan(
+			&it.ID,
+			&it.ItemID,
+			&it.Description,
+			&it.HSNCode,
+			&it.Qty,
+			&it.Rate,
+			&it.Discount,
+			&it.TaxRate,
+			&it.CGST,
+			&it.SGST,
+			&it.IGST,
+			&it.Total,
+		); err != nil {
+			return nil, err
+		}
+		bill.Items = append(bill.Items, it)
+	}
+
+	return bill, rows.Err()
+}
+
+func scanPurchaseBill(row rowScanner) (*models.PurchaseBill, error) {
+	var b models.PurchaseBill
+	err := row.Scan(
+		&b.ID,
+		&b.CompanyID,
+		&b.VendorID,
+		&b.VendorName,
+		&b.BillNumber,
+		&b.BillDate,
+		&b.DueDate,
+		&b.Subtotal,
+		&b.CGST,
+		&b.SGST,
+		&b.IGST,
+		&b.Tax,
+		&b.Total,
+		&b.PaidAmount,
+		&b.RemainingAmount,
+		&b.ITCEligible,
+		&b.Status,
+		&b.PurchaseOrderID,
+		&b.Notes,
+		&b.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// RecordPaymentTx pays a vendor. Without explicit allocations the oldest
+// open bills are settled first; the whole amount must be allocated.
+func (s *PurchaseBillService) RecordPaymentTx(
+	tx *sql.Tx,
+	userID int,
+	req models.VendorPaymentRequestDTO,
+) (int64, error) {
+
+	// 1️⃣ Vendor must belong to one of the user's companies
+	var companyID int64
+	err := tx.QueryRow(`
+		SELECT v.company_id
+		FROM vendors v
+		JOIN companies c ON c.id = v.company_id
+		WHERE v.id = $1 AND c.user_id = $2
+	`, req.VendorID, userID).Scan(&companyID)
+	if err == sql.ErrNoRows {
+		return 0, ErrVendorNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	paymentDate := time.Now()
+	if req.PaymentDate != "" {
+		paymentDate, err = time.Parse("2006-01-02", req.PaymentDate)
+		if err != nil {
+			return 0, errors.New("invalid payment_date (YYYY-MM-DD)")
+		}
+	}
+
+	// 2️⃣ Allocate
+	if len(req.Allocations) == 0 {
+		allocations, err := s.autoAllocateFIFO(tx, req.VendorID, req.Amount)
+		if err != nil {
+			return 0, err
+		}
+		req.Allocations = allocations
+	}
+
+	var allocated money.Money
+	for _, a := range req.Allocations {
+		allocated += a.Amount
+	}
+
+	if allocated != req.Amount {
+		return 0, errors.New("allocation total does not match payment amount")
+	}
+
+	// 3️⃣ Insert payment
+	var paymentID int64
+	err = tx.QueryRow(`
+		INSERT INTO vendor_payments (
+			company_id, vendor_id, amount, payment_date,
+			payment_method, reference, notes
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		RETURNING id
+	`,
+		companyID,
+		req.VendorID,
+		req.Amount,
+		paymentDate,
+		req.PaymentMethod,
+		req.Reference,
+		req.Notes,
+	).Scan(&paymentID)
+	if err != nil {
+		return 0, err
+	}
+
+	// 4️⃣ Apply allocations
+	for _, alloc := range req.Allocations {
+		if alloc.Amount <= 0 {
+			return 0, errors.New("allocation amount must be positive")
+		}
+
+		var remaining money.Money
+		err := tx.QueryRow(`
+			SELECT remaining_amount
+			FROM purchase_bills
+			WHERE id = $1 AND vendor_id = $2
+			FOR UPDATE
+		`, alloc.BillID, req.VendorID).Scan(&remaining)
+		if err == sql.ErrNoRows {
+			return 0, ErrPurchaseBillNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if alloc.Amount > remaining {
+			return 0, errors.New("allocation exceeds bill balance")
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO vendor_payment_allocations
+				(vendor_payment_id, bill_id, amount)
+			VALUES ($1,$2,$3)
+		`, paymentID, alloc.BillID, alloc.Amount)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE purchase_bills
+			SET
+				paid_amount = paid_amount + $1,
+				remaining_amount = remaining_amount - $1,
+				status = CASE
+					WHEN remaining_amount - $1 <= 0 THEN 'paid'
+					ELSE 'partial'
+				END,
+				updated_at = NOW()
+			WHERE id = $2
+		`, alloc.Amount, alloc.BillID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// 5️⃣ Ledger entry (ONE debit entry)
+	err = s.ledger.AddEntryTx(
+		tx,
+		companyID,
+		req.VendorID,
+		"PAYMENT",
+		paymentID,
+		req.Amount,
+		0,
+		"Payment made",
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return paymentID, nil
+}
+
+func (s *PurchaseBillService) autoAllocateFIFO(
+	tx *sql.Tx,
+	vendorID int64,
+	amount money.Money,
+) ([]models.VendorPaymentAllocationDTO, error) {
+
+	rows, err := tx.Query(`
+		SELECT id, remaining_amount
+		FROM purchase_bills
+		WHERE vendor_id = $1
+		  AND remaining_amount > 0
+		ORDER BY due_date ASC, id ASC
+		FOR UPDATE
+	`, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	remaining := amount
+	var allocations []models.VendorPaymentAllocationDTO
+
+	for rows.Next() && remaining > 0 {
+		var billID int64
+		var due money.Money
+
+		if err := rows.Scan(&billID, &due); err != nil {
+			return nil, err
+		}
+
+		applied := money.Min(due, remaining)
+
+		allocations = append(allocations, models.VendorPaymentAllocationDTO{
+			BillID: billID,
+			Amount: applied,
+		})
+
+		remaining -= applied
+	}
+
+	if remaining > 0 {
+		return nil, errors.New("payment exceeds outstanding balance")
+	}
+
+	return allocations, nil
+}
@@ -0,0 +1,679 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	"invo-server/internal/pdf"
+	utils "invo-server/internal/util"
+)
+
+var (
+	ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+	ErrPurchaseOrderNotOpen  = errors.New("purchase order is not open for receipt")
+)
+
+// allowed manual status transitions; partially_received and the automatic
+// close on full receipt are set by ReceiveTx
+var purchaseOrderTransitions = map[string][]string{
+	"draft":              {"sent", "closed"},
+	"sent":               {"closed"},
+	"partially_received": {"closed"},
+}
+
+type PurchaseOrderService struct {
+	db    *sql.DB
+	stock *StockService
+}
+
+func NewPurchaseOrderService(db *sql.DB, stock *StockService) *PurchaseOrderService {
+	return &PurchaseOrderService{db: db, stock: stock}
+}
+
+func (s *PurchaseOrderService) CreateTx(
+	tx *sql.Tx,
+	userID int,
+	req models.PurchaseOrderRequestDTO,
+) (*models.PurchaseOrder, error) {
+
+	// 1️⃣ Validate input
+	if len(req.Items) == 0 {
+		return nil, errors.New("purchase order must contain at least one item")
+	}
+
+	items := make([]models.PurchaseBillItemRequest, len(req.Items))
+	for i, it := range req.Items {
+		items[i] = models.PurchaseBillItemRequest{
+			ItemID:      it.ItemID,
+			Description: it.Description,
+			Qty:         it.Qty,
+			Rate:        it.Rate,
+			Discount:    it.Discount,
+			TaxRate:     it.TaxRate,
+		}
+	}
+	if err := validatePurchaseItems(items); err != nil {
+		return nil, err
+	}
+
+	poDate, err := time.Parse("2006-01-02", req.PODate)
+	if err != nil {
+		return nil, errors.New("invalid po_date (YYYY-MM-DD)")
+	}
+
+	var expected *time.Time
+	if req.ExpectedDate != "" {
+		d, err := time.Parse("2006-01-02", req.ExpectedDate)
+		if err != nil {
+			return nil, errors.New("invalid expected_date (YYYY-MM-DD)")
+		}
+		if d.Before(poDate) {
+			return nil, errors.New("expected_date must not be before po_date")
+		}
+		expected = &d
+	}
+
+	// 2️⃣ Vendor must belong to one of the user's companies
+	var companyID int64
+	var vendorName string
+	err = tx.QueryRow(`
+		SELECT v.company_id, v.name
+		FROM vendors v
+		JOIN companies c ON c.id = v.company_id
+		WHERE v.id = $1 AND c.user_id = $2
+	`, req.VendorID, userID).Scan(&companyID, &vendorName)
+	if err == sql.ErrNoRows {
+		return nil, ErrVendorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 3️⃣ Totals and number
+	reqItems := make([]models.InvoiceItemRequest, len(req.Items))
+	for i, it := range req.Items {
+		reqItems[i] = models.InvoiceItemRequest{
+			ItemID:   it.ItemID,
+			Qty:      it.Qty,
+			Rate:     it.Rate,
+			Discount: it.Discount,
+			TaxRate:  it.TaxRate,
+		}
+	}
+	subtotal, tax, total := calculateLineTotals(reqItems)
+
+	poNumber, err := nextDocumentNumberTx(tx, companyID, DocPurchaseOrder, poDate)
+	if err != nil {
+		return nil, errors.New("failed to generate purchase order number")
+	}
+
+	// 4️⃣ Insert order
+	po := &models.PurchaseOrder{
+		CompanyID:    companyID,
+		VendorID:     req.VendorID,
+		VendorName:   vendorName,
+		PONumber:     poNumber,
+		PODate:       poDate,
+		ExpectedDate: expected,
+		Subtotal:     subtotal,
+		Tax:          tax,
+		Total:        total,
+		Status:       "draft",
+		Notes:        stringValue(req.Notes),
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO purchase_orders (
+			company_id, user_id, vendor_id,
+			po_number, po_date, expected_date,
+			subtotal, tax, total, status, notes
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,'draft',$10)
+		RETURNING id, created_at
+	`,
+		companyID,
+		userID,
+		req.VendorID,
+		poNumber,
+		poDate,
+		expected,
+		subtotal,
+		tax,
+		total,
+		req.Notes,
+	).Scan(&po.ID, &po.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Insert items
+	for i, src := range req.Items {
+		var itemID *int64
+		if src.ItemID != 0 {
+			var exists bool
+			err = tx.QueryRow(`
+				SELECT EXISTS (SELECT 1 FROM items WHERE id = $1 AND company_id = $2)
+			`, src.ItemID, companyID).Scan(&exists)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				return nil, fmt.Errorf("item %d: %w", i+1, ErrStockItemNotFound)
+			}
+
+			id := int64(src.ItemID)
+			itemID = &id
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO purchase_order_items (
+				po_id, item_id, description,
+				qty, rate, discount, tax_rate, total
+			)
+			VALUES ($1,$2,NULLIF($3, ''),$4,$5,$6,$7,$8)
+		`,
+			po.ID,
+			itemID,
+			strings.TrimSpace(src.Description),
+			src.Qty,
+			src.Rate,
+			src.Discount,
+			src.TaxRate,
+			lineTotal(reqItems[i]),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return po, nil
+}
+
+// List returns the company's purchase orders, newest first. status
+// filters on draft, sent, partially_received or closed when set.
+func (s *PurchaseOrderService) List(companyID int64, status string, limit, offset int) ([]models.PurchaseOrder, error) {
+	query := `
+		SELECT
+			po.id, po.company_id, po.vendor_id, v.name,
+			po.po_number, po.po_date, po.expected_date,
+			po.subtotal, po.tax, po.total, po.status,
+			COALESCE(po.notes, ''), po.created_at
+		FROM purchase_orders po
+		JOIN vendors v ON v.id = po.vendor_id
+		WHERE po.company_id = $1
+	`
+	args := []interface{}{companyID}
+
+	if status != "" {
+		query += ` AND po.status = $2`
+		args = append(args, status)
+	}
+
+	query += fmt.Sprintf(`
+		ORDER BY po.po_date DESC, po.id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.PurchaseOrder{}
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *po)
+	}
+
+	return orders, rows.Err()
+}
+
+// GetByID returns the order with its lines and each line's receipt history.
+func (s *PurchaseOrderService) GetByID(userID int, poID int64) (*models.PurchaseOrder, error) {
+	row := s.db.QueryRow(`
+		SELECT
+			po.id, po.company_id, po.vendor_id, v.name,
+			po.po_number, po.po_date, po.expected_date,
+			po.subtotal, po.tax, po.total, po.status,
+			COALESCE(po.notes, ''), po.created_at
+		FROM purchase_orders po
+		JOIN vendors v ON v.id = po.vendor_id
+		JOIN companies c ON c.id = po.company_id
+		WHERE po.id = $1 AND c.user_id = $2
+	`, poID, userID)
+
+	po, err := scanPurchaseOrder(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT
+			poi.id, poi.item_id, COALESCE(it.name, ''), COALESCE(poi.description, ''),
+			poi.qty, poi.received_qty, poi.rate, poi.discount, poi.tax_rate, poi.total
+		FROM purchase_order_items poi
+		LEFT JOIN items it ON it.id = poi.item_id
+		WHERE poi.po_id = $1
+		ORDER BY poi.id
+	`, poID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	po.Items = []models.PurchaseOrderItem{}
+	index := map[int64]int{}
+	for rows.Next() {
+		var it models.PurchaseOrderItem
+		if err := rows.Scan(
+			&it.ID,
+			&it.ItemID,
+			&it.ItemName,
+			&it.Description,
+			&it.Qty,
+			&it.ReceivedQty,
+			&it.Rate,
+			&it.Discount,
+			&it.TaxRate,
+			&it.Total,
+		); err != nil {
+			return nil, err
+		}
+		it.Receipts = []models.PurchaseOrderReceipt{}
+		index[it.ID] = len(po.Items)
+		po.Items = append(po.Items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	receiptRows, err := s.db.Query(`
+		SELECT id, po_item_id, qty, received_date, COALESCE(note, ''), created_at
+		FROM purchase_order_receipts
+		WHERE po_id = $1
+		ORDER BY id
+	`, poID)
+	if err != nil {
+		return nil, err
+	}
+	defer receiptRows.Close()
+
+	for receiptRows.Next() {
+		var r models.PurchaseOrderReceipt
+		if err := receiptRows.Scan(
+			&r.ID,
+			&r.POItemID,
+			&r.Qty,
+			&r.ReceivedDate,
+			&r.Note,
+			&r.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if i, ok := index[r.POItemID]; ok {
+			po.Items[i].Receipts = append(po.Items[i].Receipts, r)
+		}
+	}
+
+	return po, receiptRows.Err()
+}
+
+func scanPurchaseOrder(row rowScanner) (*models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := row.Scan(
+		&po.ID,
+		&po.CompanyID,
+		&po.VendorID,
+		&po.VendorName,
+		&po.PONumber,
+		&po.PODate,
+		&po.ExpectedDate,
+		&po.Subtotal,
+		&po.Tax,
+		&po.Total,
+		&po.Status,
+		&po.Notes,
+		&po.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+func (s *PurchaseOrderService) UpdateStatus(userID int, poID int64, status string) error {
+	var current string
+	err := s.db.QueryRow(`
+		SELECT po.status
+		FROM purchase_orders po
+		JOIN companies c ON c.id = po.company_id
+		WHERE po.id = $1 AND c.user_id = $2
+	`, poID, userID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, next := range purchaseOrderTransitions[current] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("cannot change purchase order status from %s to %s", current, status)
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE purchase_orders SET status = $1, updated_at = NOW() WHERE id = $2
+	`, status, poID)
+	return err
+}
+
+// ReceiveTx records goods received against a sent order. Each line gets a
+// receipt row and catalogue items come into stock; the order moves to
+// partially_received, or closed once every line is fully received.
+// It returns the order's new status.
+func (s *PurchaseOrderService) ReceiveTx(
+	tx *sql.Tx,
+	userID int,
+	poID int64,
+	req models.ReceivePurchaseOrderDTO,
+) (string, error) {
+
+	receivedDate := time.Now()
+	if req.ReceivedDate != "" {
+		var err error
+		receivedDate, err = time.Parse("2006-01-02", req.ReceivedDate)
+		if err != nil {
+			return "", errors.New("invalid received_date (YYYY-MM-DD)")
+		}
+	}
+
+	// 1️⃣ Lock order
+	var (
+		companyID        int64
+		poNumber, status string
+		vendorName       string
+	)
+	err := tx.QueryRow(`
+		SELECT po.company_id, po.po_number, po.status, v.name
+		FROM purchase_orders po
+		JOIN companies c ON c.id = po.company_id
+		JOIN vendors v ON v.id = po.vendor_id
+		WHERE po.id = $1 AND c.user_id = $2
+		FOR UPDATE OF po
+	`, poID, userID).Scan(&companyID, &poNumber, &status, &vendorName)
+	if err == sql.ErrNoRows {
+		return "", ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if status != "sent" && status != "partially_received" {
+		return "", fmt.Errorf("%w: order is %s", ErrPurchaseOrderNotOpen, status)
+	}
+
+	// 2️⃣ Lines in id order so concurrent receipts lock rows consistently
+	lines := append([]models.ReceiveLineDTO(nil), req.Lines...)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].POItemID < lines[j].POItemID })
+
+	for i := 1; i < len(lines); i++ {
+		if lines[i].POItemID == lines[i-1].POItemID {
+			return "", fmt.Errorf("po_item_id %d is listed more than once", lines[i].POItemID)
+		}
+	}
+
+	note := "PO " + poNumber + " from " + vendorName
+	if strings.TrimSpace(req.Note) != "" {
+		note += ": " + strings.TrimSpace(req.Note)
+	}
+
+	for _, line := range lines {
+		var (
+			itemID           *int64
+			ordered, already int
+		)
+		err := tx.QueryRow(`
+			SELECT item_id, qty, received_qty
+			FROM purchase_order_items
+			WHERE id = $1 AND po_id = $2
+			FOR UPDATE
+		`, line.POItemID, poID).Scan(&itemID, &ordered, &already)
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("po_item_id %d is not on this purchase order", line.POItemID)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if already+line.Qty > ordered {
+			return "", fmt.Errorf("po_item_id %d: only %d left to receive", line.POItemID, ordered-already)
+		}
+
+		var receiptID int64
+		err = tx.QueryRow(`
+			INSERT INTO purchase_order_receipts
+				(po_id, po_item_id, qty, received_date, note, created_by)
+			VALUES ($1,$2,$3,$4,NULLIF($5, ''),$6)
+			RETURNING id
+		`, poID, line.POItemID, line.Qty, receivedDate, strings.TrimSpace(req.Note), userID).Scan(&receiptID)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = tx.Exec(`
+			UPDATE purchase_order_items
+			SET received_qty = received_qty + $1
+			WHERE id = $2
+		`, line.Qty, line.POItemID)
+		if err != nil {
+			return "", err
+		}
+
+		if itemID != nil {
+			if err := s.stock.ReceiveTx(tx, companyID, *itemID, line.Qty, receiptID, note, userID); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// 3️⃣ Status from what is still outstanding
+	var complete bool
+	err = tx.QueryRow(`
+		SELECT BOOL_AND(received_qty = qty)
+		FROM purchase_order_items
+		WHERE po_id = $1
+	`, poID).Scan(&complete)
+	if err != nil {
+		return "", err
+	}
+
+	status = "partially_received"
+	if complete {
+		status = "closed"
+	}
+
+	_, err = tx.Exec(`
+		UPDATE purchase_orders SET status = $1, updated_at = NOW() WHERE id = $2
+	`, status, poID)
+	if err != nil {
+		return "", err
+	}
+
+	return status, nil
+}
+
+// FetchPurchaseOrderPDFData loads an order into the invoice layout: the
+// vendor takes the party slot and the company is the delivery address.
+func FetchPurchaseOrderPDFData(
+	db *sql.DB,
+	poID int64,
+) (pdf.InvoicePDFData, error) {
+
+	var data pdf.InvoicePDFData
+	var poDate time.Time
+	var expected *time.Time
+	var vendorID int64
+	var companyGSTIN, vendorGSTIN, vendorState string
+
+	/* -----------------------------
+	   1️⃣ Fetch order + company
+	------------------------------ */
+	err := db.QueryRow(`
+		SELECT
+			po.po_number,
+			po.po_date,
+			po.expected_date,
+			po.subtotal,
+			po.tax,
+			po.total,
+			COALESCE(po.notes, ''),
+			po.vendor_id,
+			COALESCE(v.gstin, ''),
+			c.name,
+			COALESCE(c.phone, ''),
+			COALESCE(c.address, ''),
+			COALESCE(c.city, ''),
+			COALESCE(c.state, ''),
+			COALESCE(c.pincode, ''),
+			COALESCE(c.gst, '')
+		FROM purchase_orders po
+		JOIN companies c ON c.id = po.company_id
+		JOIN vendors v ON v.id = po.vendor_id
+		WHERE po.id = $1
+	`, poID).Scan(
+		&data.Invoice.InvoiceNumber,
+		&poDate,
+		&expected,
+		&data.Invoice.Subtotal,
+		&data.Invoice.Tax,
+		&data.Invoice.Total,
+		&data.Invoice.Notes,
+		&vendorID,
+		&vendorGSTIN,
+		&data.Company.Name,
+		&data.Company.Phone,
+		&data.CompanyAddress.Line1,
+		&data.CompanyAddress.City,
+		&data.CompanyAddress.State,
+		&data.CompanyAddress.Zip,
+		&companyGSTIN,
+	)
+	if err != nil {
+		return data, fmt.Errorf("fetch purchase order: %w", err)
+	}
+
+	data.Invoice.InvoiceDate = poDate.Format("02-01-2006")
+	if expected != nil {
+		data.Invoice.DueDate = expected.Format("02-01-2006")
+	}
+	data.CompanyAddress.Name = data.Company.Name
+	data.CompanyAddress.Country = "India"
+
+	delivery := data.CompanyAddress
+	data.ClientShipping = &delivery
+
+	/* -----------------------------
+	   2️⃣ Vendor billing address
+	------------------------------ */
+	var addrGSTIN string
+	err = db.QueryRow(`
+		SELECT
+			COALESCE(va.name, v.name),
+			va.line1,
+			COALESCE(va.city, ''),
+			COALESCE(va.state, ''),
+			COALESCE(va.country, ''),
+			COALESCE(va.postal_code, ''),
+			COALESCE(va.gst_number, '')
+		FROM vendor_addresses va
+		JOIN vendors v ON v.id = va.vendor_id
+		WHERE va.vendor_id = $1 AND va.type = 'billing'
+	`, vendorID).Scan(
+		&data.ClientBilling.Name,
+		&data.ClientBilling.Line1,
+		&data.ClientBilling.City,
+		&data.ClientBilling.State,
+		&data.ClientBilling.Country,
+		&data.ClientBilling.Zip,
+		&addrGSTIN,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return data, fmt.Errorf("fetch vendor address: %w", err)
+	}
+	if vendorGSTIN == "" {
+		vendorGSTIN = addrGSTIN
+	}
+	vendorState = data.ClientBilling.State
+
+	/* -----------------------------
+	   3️⃣ Order items, taxed as the vendor's bill will be
+	------------------------------ */
+	supplierState := utils.ResolveStateCode(vendorGSTIN, vendorState)
+	recipientState := utils.ResolveStateCode(companyGSTIN, data.CompanyAddress.State)
+	data.Invoice.IsInterState = supplierState != "" && recipientState != "" && supplierState != recipientState
+
+	itemRows, err := db.Query(`
+		SELECT
+			COALESCE(it.name, poi.description, ''),
+			COALESCE(it.hsn_code, ''),
+			poi.qty,
+			poi.rate,
+			poi.discount,
+			poi.tax_rate,
+			poi.total
+		FROM purchase_order_items poi
+		LEFT JOIN items it ON it.id = poi.item_id
+		WHERE poi.po_id = $1
+		ORDER BY poi.id
+	`, poID)
+	if err != nil {
+		return data, fmt.Errorf("fetch items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item pdf.InvoiceItem
+		var discount money.Money
+
+		if err := itemRows.Scan(
+			&item.Name,
+			&item.HSNCode,
+			&item.Qty,
+			&item.Rate,
+			&discount,
+			&item.TaxRate,
+			&item.Total,
+		); err != nil {
+			return data, err
+		}
+
+		taxable := item.Rate.Mul(float64(item.Qty)) - discount
+		item.CGST, item.SGST, item.IGST = utils.SplitTax(
+			taxable.Percent(item.TaxRate),
+			data.Invoice.IsInterState,
+		)
+
+		data.Items = append(data.Items, item)
+	}
+
+	return data, itemRows.Err()
+}
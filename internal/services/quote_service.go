@@ -0,0 +1,612 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	"invo-server/internal/pdf"
+	utils "invo-server/internal/util"
+	"time"
+)
+
+// quoteStatusSQL reports open quotes past their validity date as expired
+// without needing a background job to flip the stored status.
+const quoteStatusSQL = `
+	CASE
+		WHEN q.status IN ('draft', 'sent') AND q.valid_until < CURRENT_DATE THEN 'expired'
+		ELSE q.status
+	END`
+
+// allowed manual status transitions (conversion is handled by ConvertTx)
+var quoteTransitions = map[string][]string{
+	"draft": {"sent", "accepted", "rejected", "expired"},
+	"sent":  {"accepted", "rejected", "expired"},
+}
+
+type QuoteService struct {
+	db       *sql.DB
+	invoices *InvoiceService
+}
+
+func NewQuoteService(db *sql.DB, invoices *InvoiceService) *QuoteService {
+	return &QuoteService{db: db, invoices: invoices}
+}
+
+func (s *QuoteService) CreateTx(
+	tx *sql.Tx,
+	userID int,
+	req models.QuoteRequestDTO,
+) (int64, string, error) {
+
+	// 1️⃣ Validate input
+	if len(req.Items) == 0 {
+		return 0, "", errors.New("quote must contain at least one item")
+	}
+
+	quoteDate, err := time.Parse("2006-01-02", req.QuoteDate)
+	if err != nil {
+		return 0, "", errors.New("invalid quote_date (YYYY-MM-DD)")
+	}
+
+	validUntil, err := time.Parse("2006-01-02", req.ValidUntil)
+	if err != nil {
+		return 0, "", errors.New("invalid valid_until (YYYY-MM-DD)")
+	}
+
+	if validUntil.Before(quoteDate) {
+		return 0, "", errors.New("valid_until must not be before quote_date")
+	}
+
+	// 2️⃣ Calculate totals
+	subtotal, tax, total := calculateLineTotals(req.Items)
+
+	// 3️⃣ Generate quote number from the company's numbering scheme
+	quoteNumber, err := nextDocumentNumberTx(tx, int64(req.CompanyID), DocQuote, quoteDate)
+	if err != nil {
+		return 0, "", errors.New("failed to generate quote number")
+	}
+
+	// 4️⃣ Insert quote
+	var quoteID int64
+	err = tx.QueryRow(`
+		INSERT INTO quotes (
+			company_id, user_id, client_id,
+			quote_number, quote_date, valid_until,
+			subtotal, tax, total, status, notes
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,'draft',$10)
+		RETURNING id
+	`,
+		req.CompanyID,
+		userID,
+		req.ClientID,
+		quoteNumber,
+		quoteDate,
+		validUntil,
+		subtotal,
+		tax,
+		total,
+		req.Notes,
+	).Scan(&quoteID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// 5️⃣ Insert items
+	for _, item := range req.Items {
+		_, err = tx.Exec(`
+			INSERT INTO quote_items
+				(quote_id, item_id, qty, rate, discount, tax_rate, total)
+			VALUES ($1,$2,$3,$4,$5,$6,$7)
+		`,
+			quoteID,
+			item.ItemID,
+			item.Qty,
+			item.Rate,
+			item.Discount,
+			item.TaxRate,
+			lineTotal(item),
+		)
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	return quoteID, quoteNumber, nil
+}
+
+func (s *QuoteService) GetAll(
+	companyID int64,
+	status string,
+	limit, offset int,
+) ([]models.QuoteListDTO, error) {
+
+	query := `
+		SELECT
+			q.id,
+			q.quote_number,
+			q.client_id,
+			cl.name,
+			q.quote_date,
+			q.valid_until,
+			q.total,
+			` + quoteStatusSQL + ` AS effective_status,
+			q.invoice_id
+		FROM quotes q
+		JOIN clients cl ON cl.id = q.client_id
+		WHERE q.company_id = $1
+	`
+	args := []interface{}{companyID}
+
+	if status != "" {
+		query += ` AND ` + quoteStatusSQL + ` = $2`
+		args = append(args, status)
+	}
+
+	query += fmt.Sprintf(`
+		ORDER BY q.quote_date DESC, q.id DESC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.QuoteListDTO{}
+
+	for rows.Next() {
+		var q models.QuoteListDTO
+		if err := rows.Scan(
+			&q.ID,
+			&q.QuoteNumber,
+			&q.ClientID,
+			&q.ClientName,
+			&q.QuoteDate,
+			&q.ValidUntil,
+			&q.Total,
+			&q.Status,
+			&q.InvoiceID,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, q)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *QuoteService) GetByID(
+	userID int,
+	quoteID int64,
+) (*models.QuoteDetailResponse, error) {
+
+	var q models.QuoteDetailResponse
+
+	err := s.db.QueryRow(`
+		SELECT
+			q.id,
+			q.company_id,
+			q.quote_number,
+			q.client_id,
+			cl.name,
+			q.quote_date,
+			q.valid_until,
+			q.subtotal,
+			q.tax,
+			q.total,
+			`+quoteStatusSQL+`,
+			q.notes,
+			q.invoice_id,
+			i.invoice_number,
+			q.converted_at,
+			q.created_at
+		FROM quotes q
+		JOIN clients cl ON cl.id = q.client_id
+		LEFT JOIN invoices i ON i.id = q.invoice_id
+		WHERE q.id = $1 AND q.user_id = $2
+	`, quoteID, userID).Scan(
+		&q.ID,
+		&q.CompanyID,
+		&q.QuoteNumber,
+		&q.ClientID,
+		&q.ClientName,
+		&q.QuoteDate,
+		&q.ValidUntil,
+		&q.Subtotal,
+		&q.Tax,
+		&q.Total,
+		&q.Status,
+		&q.Notes,
+		&q.InvoiceID,
+		&q.InvoiceNumber,
+		&q.ConvertedAt,
+		&q.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT
+			qi.id,
+			qi.item_id,
+			it.name,
+			qi.qty,
+			qi.rate,
+			COALESCE(qi.discount, 0),
+			qi.tax_rate,
+			qi.total
+		FROM quote_items qi
+		LEFT JOIN items it ON it.id = qi.item_id
+		WHERE qi.quote_id = $1
+		ORDER BY qi.id
+	`, quoteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	q.Items = []models.QuoteItemResponse{}
+	for rows.Next() {
+		var it models.QuoteItemResponse
+		if err := rows.Scan(
+			&it.ID,
+			&it.ItemID,
+			&it.ItemName,
+			&it.Qty,
+			&it.Rate,
+			&it.Discount,
+			&it.TaxRate,
+			&it.Total,
+		); err != nil {
+			return nil, err
+		}
+		q.Items = append(q.Items, it)
+	}
+
+	return &q, rows.Err()
+}
+
+func (s *QuoteService) UpdateStatus(
+	userID int,
+	quoteID int64,
+	status string,
+) error {
+
+	var current string
+	var invoiceID *int
+	err := s.db.QueryRow(`
+		SELECT `+quoteStatusSQL+`, q.invoice_id
+		FROM quotes q
+		WHERE q.id = $1 AND q.user_id = $2
+	`, quoteID, userID).Scan(&current, &invoiceID)
+	if err != nil {
+		return err
+	}
+
+	if invoiceID != nil {
+		return errors.New("quote has already been converted to an invoice")
+	}
+
+	allowed := false
+	for _, next := range quoteTransitions[current] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("cannot change quote status from %s to %s", current, status)
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE quotes SET status = $1, updated_at = NOW() WHERE id = $2
+	`, status, quoteID)
+	return err
+}
+
+// ConvertTx creates a draft invoice carrying the quote's lines. Client
+// addresses are snapshotted by InvoiceService.CreateTx exactly as for a
+// manually created invoice, and both documents are linked to each other.
+func (s *QuoteService) ConvertTx(
+	tx *sql.Tx,
+	userID int,
+	quoteID int64,
+	req models.ConvertQuoteRequestDTO,
+) (*models.CreatedInvoice, error) {
+
+	var (
+		companyID, clientID int
+		status              string
+		invoiceID           *int
+		notes               *string
+	)
+
+	// 1️⃣ Lock quote
+	err := tx.QueryRow(`
+		SELECT q.company_id, q.client_id, `+quoteStatusSQL+`, q.invoice_id, q.notes
+		FROM quotes q
+		WHERE q.id = $1 AND q.user_id = $2
+		FOR UPDATE
+	`, quoteID, userID).Scan(&companyID, &clientID, &status, &invoiceID, &notes)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoiceID != nil {
+		return nil, errors.New("quote has already been converted to an invoice")
+	}
+
+	if status == "rejected" || status == "expired" {
+		return nil, fmt.Errorf("cannot convert a %s quote", status)
+	}
+
+	// 2️⃣ Load lines
+	rows, err := tx.Query(`
+		SELECT item_id, qty, rate, discount, tax_rate
+		FROM quote_items
+		WHERE quote_id = $1
+		ORDER BY id
+	`, quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.InvoiceItemRequest
+	for rows.Next() {
+		var it models.InvoiceItemRequest
+		var itemID *int
+		if err := rows.Scan(&itemID, &it.Qty, &it.Rate, &it.Discount, &it.TaxRate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if itemID == nil {
+			rows.Close()
+			return nil, errors.New("quote references an item that no longer exists")
+		}
+		it.ItemID = *itemID
+		items = append(items, it)
+	}
+	rows.Close()
+
+	// 3️⃣ Dates
+	invDate := time.Now()
+	if req.InvoiceDate != "" {
+		invDate, err = time.Parse("2006-01-02", req.InvoiceDate)
+		if err != nil {
+			return nil, errors.New("invalid invoice_date (YYYY-MM-DD)")
+		}
+	}
+
+	dueDate := invDate.AddDate(0, 0, 30)
+	if req.DueDate != "" {
+		dueDate, err = time.Parse("2006-01-02", req.DueDate)
+		if err != nil {
+			return nil, errors.New("invalid due_date (YYYY-MM-DD)")
+		}
+	}
+
+	// 4️⃣ Create invoice
+	created, err := s.invoices.CreateTx(tx, userID, models.InvoiceRequestDTO{
+		CompanyID:   companyID,
+		ClientID:    clientID,
+		InvoiceDate: invDate.Format("2006-01-02"),
+		DueDate:     dueDate.Format("2006-01-02"),
+		Notes:       notes,
+		Items:       items,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Link documents
+	_, err = tx.Exec(`
+		UPDATE invoices SET quote_id = $1 WHERE id = $2
+	`, quoteID, created.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE quotes
+		SET status = 'accepted',
+			invoice_id = $1,
+			converted_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $2
+	`, created.ID, quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func FetchQuotePDFData(
+	db *sql.DB,
+	quoteID int,
+) (pdf.InvoicePDFData, error) {
+
+	var data pdf.InvoicePDFData
+	var quoteDate, validUntil time.Time
+	var clientID int
+	var companyGSTIN, billingGSTIN, billingState string
+
+	/* -----------------------------
+	   1️⃣ Fetch quote + company
+	------------------------------ */
+	err := db.QueryRow(`
+		SELECT
+			q.quote_number,
+			q.quote_date,
+			q.valid_until,
+			q.subtotal,
+			q.tax,
+			q.total,
+			COALESCE(q.notes, ''),
+			q.client_id,
+			c.name,
+			COALESCE(c.phone, ''),
+			COALESCE(c.address, ''),
+			COALESCE(c.city, ''),
+			COALESCE(c.state, ''),
+			COALESCE(c.pincode, ''),
+			COALESCE(c.gst, '')
+		FROM quotes q
+		JOIN companies c ON c.id = q.company_id
+		WHERE q.id = $1
+	`, quoteID).Scan(
+		&data.Invoice.InvoiceNumber,
+		&quoteDate,
+		&validUntil,
+		&data.Invoice.Subtotal,
+		&data.Invoice.Tax,
+		&data.Invoice.Total,
+		&data.Invoice.Notes,
+		&clientID,
+		&data.Company.Name,
+		&data.Company.Phone,
+		&data.CompanyAddress.Line1,
+		&data.CompanyAddress.City,
+		&data.CompanyAddress.State,
+		&data.CompanyAddress.Zip,
+		&companyGSTIN,
+	)
+	if err != nil {
+		return data, fmt.Errorf("fetch quote: %w", err)
+	}
+
+	data.Invoice.InvoiceDate = quoteDate.Format("02-01-2006")
+	data.Invoice.DueDate = validUntil.Format("02-01-2006")
+	data.CompanyAddress.Name = data.Company.Name
+	data.CompanyAddress.Country = "India"
+
+	/* -----------------------------
+	   2️⃣ Client addresses (live, quotes are not snapshotted)
+	------------------------------ */
+	rows, err := db.Query(`
+		SELECT
+			type,
+			COALESCE(name, ''),
+			line1,
+			COALESCE(city, ''),
+			COALESCE(state, ''),
+			COALESCE(country, ''),
+			COALESCE(postal_code, ''),
+			COALESCE(gst_number, '')
+		FROM client_addresses
+		WHERE client_id = $1
+	`, clientID)
+	if err != nil {
+		return data, fmt.Errorf("fetch client addresses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var addrType, gstin string
+		var addr pdf.Address
+
+		if err := rows.Scan(
+			&addrType,
+			&addr.Name,
+			&addr.Line1,
+			&addr.City,
+			&addr.State,
+			&addr.Country,
+			&addr.Zip,
+			&gstin,
+		); err != nil {
+			return data, err
+		}
+
+		if addrType == "billing" {
+			data.ClientBilling = addr
+			billingGSTIN, billingState = gstin, addr.State
+		} else if addrType == "shipping" {
+			data.ClientShipping = &addr
+		}
+	}
+
+	/* -----------------------------
+	   3️⃣ Quote items, taxed as an invoice would be
+	------------------------------ */
+	supplierState := utils.ResolveStateCode(companyGSTIN, data.CompanyAddress.State)
+	pos := utils.ResolveStateCode(billingGSTIN, billingState)
+	data.Invoice.IsInterState = utils.IsInterState(supplierState, pos)
+	data.Invoice.PlaceOfSupply = utils.PlaceOfSupplyLabel(pos)
+
+	itemRows, err := db.Query(`
+		SELECT
+			COALESCE(it.name, ''),
+			COALESCE(it.hsn_code, ''),
+			qi.qty,
+			qi.rate,
+			COALESCE(qi.discount, 0),
+			qi.tax_rate,
+			qi.total
+		FROM quote_items qi
+		LEFT JOIN items it ON it.id = qi.item_id
+		WHERE qi.quote_id = $1
+		ORDER BY qi.id
+	`, quoteID)
+	if err != nil {
+		return data, fmt.Errorf("fetch items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item pdf.InvoiceItem
+		var discount money.Money
+
+		if err := itemRows.Scan(
+			&item.Name,
+			&item.HSNCode,
+			&item.Qty,
+			&item.Rate,
+			&discount,
+			&item.TaxRate,
+			&item.Total,
+		); err != nil {
+			return data, err
+		}
+
+		taxable := item.Rate.Mul(float64(item.Qty)) - discount
+		item.CGST, item.SGST, item.IGST = utils.SplitTax(
+			taxable.Percent(item.TaxRate),
+			data.Invoice.IsInterState,
+		)
+
+		data.Items = append(data.Items, item)
+	}
+
+	/* -----------------------------
+	   4️⃣ Default bank
+	------------------------------ */
+	err = db.QueryRow(`
+		SELECT
+			bank_name,
+			account_number,
+			ifsc_code,
+			COALESCE(branch, '')
+		FROM company_bank_accounts
+		WHERE company_id = (
+			SELECT company_id FROM quotes WHERE id = $1
+		)
+		AND is_default = true
+		LIMIT 1
+	`, quoteID).Scan(
+		&data.Bank.BankName,
+		&data.Bank.AccountNumber,
+		&data.Bank.IFSCCode,
+		&data.Bank.Branch,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return data, fmt.Errorf("fetch bank details: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,554 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"invo-server/internal/models"
+	"invo-server/internal/pdf"
+	"log"
+	"time"
+)
+
+type RecurringInvoiceService struct {
+	db       *sql.DB
+	invoices *InvoiceService
+	email    *EmailService
+}
+
+func NewRecurringInvoiceService(
+	db *sql.DB,
+	invoices *InvoiceService,
+	email *EmailService,
+) *RecurringInvoiceService {
+	return &RecurringInvoiceService{db: db, invoices: invoices, email: email}
+}
+
+// recurringRunDate returns the date of the n-th occurrence (0-based) of a
+// profile. Dates are always derived from the start date rather than the
+// previous run so month-end starts don't drift (Jan 31 → Feb 28 → Mar 31).
+func recurringRunDate(start time.Time, frequency string, n int) time.Time {
+	switch frequency {
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "quarterly":
+		return addMonthsClamped(start, 3*n)
+	case "yearly":
+		return addMonthsClamped(start, 12*n)
+	default: // monthly
+		return addMonthsClamped(start, n)
+	}
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+func (s *RecurringInvoiceService) Create(
+	userID int,
+	req models.RecurringInvoiceRequestDTO,
+) (int64, error) {
+
+	// 1️⃣ Validate input
+	switch req.Frequency {
+	case "weekly", "monthly", "quarterly", "yearly":
+	default:
+		return 0, errors.New("frequency must be weekly, monthly, quarterly or yearly")
+	}
+
+	if len(req.Items) == 0 {
+		return 0, errors.New("profile must contain at least one item")
+	}
+
+	if req.AutoEmail && !req.AutoIssue {
+		return 0, errors.New("auto_email requires auto_issue")
+	}
+
+	if req.DueInDays < 0 {
+		return 0, errors.New("due_in_days cannot be negative")
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return 0, errors.New("invalid start_date (YYYY-MM-DD)")
+	}
+
+	var endDate *time.Time
+	if req.EndDate != nil && *req.EndDate != "" {
+		d, err := time.Parse("2006-01-02", *req.EndDate)
+		if err != nil {
+			return 0, errors.New("invalid end_date (YYYY-MM-DD)")
+		}
+		if d.Before(startDate) {
+			return 0, errors.New("end_date must not be before start_date")
+		}
+		endDate = &d
+	}
+
+	// 2️⃣ Insert profile + items
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var profileID int64
+	err = tx.QueryRow(`
+		INSERT INTO recurring_invoice_profiles (
+			company_id, user_id, client_id, name, frequency,
+			start_date, end_date, next_run_date,
+			due_in_days, auto_issue, auto_email, notes
+		)
+		VALUES ($1,$2,$3,NULLIF($4,''),$5,$6,$7,$6,$8,$9,$10,$11)
+		RETURNING id
+	`,
+		req.CompanyID,
+		userID,
+		req.ClientID,
+		req.Name,
+		req.Frequency,
+		startDate,
+		endDate,
+		req.DueInDays,
+		req.AutoIssue,
+		req.AutoEmail,
+		req.Notes,
+	).Scan(&profileID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, it := range req.Items {
+		_, err = tx.Exec(`
+			INSERT INTO recurring_invoice_items
+				(profile_id, item_id, qty, rate, discount, tax_rate)
+			VALUES ($1,$2,$3,$4,$5,$6)
+		`, profileID, it.ItemID, it.Qty, it.Rate, it.Discount, it.TaxRate)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return profileID, tx.Commit()
+}
+
+func (s *RecurringInvoiceService) List(
+	companyID int64,
+) ([]models.RecurringInvoiceProfile, error) {
+
+	rows, err := s.db.Query(`
+		SELECT
+			p.id, p.company_id, p.client_id, cl.name, p.name, p.frequency,
+			p.start_date, p.end_date, p.next_run_date, p.runs_completed,
+			p.due_in_days, p.auto_issue, p.auto_email, p.notes,
+			p.status, p.last_error, p.last_run_at
+		FROM recurring_invoice_profiles p
+		JOIN clients cl ON cl.id = p.client_id
+		WHERE p.company_id = $1
+		ORDER BY p.next_run_date ASC, p.id ASC
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.RecurringInvoiceProfile{}
+
+	for rows.Next() {
+		var p models.RecurringInvoiceProfile
+		if err := scanRecurringProfile(rows, &p); err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *RecurringInvoiceService) GetByID(
+	userID int,
+	profileID int64,
+) (*models.RecurringInvoiceProfile, error) {
+
+	var p models.RecurringInvoiceProfile
+
+	row := s.db.QueryRow(`
+		SELECT
+			p.id, p.company_id, p.client_id, cl.name, p.name, p.frequency,
+			p.start_date, p.end_date, p.next_run_date, p.runs_completed,
+			p.due_in_days, p.auto_issue, p.auto_email, p.notes,
+			p.status, p.last_error, p.last_run_at
+		FROM recurring_invoice_profiles p
+		JOIN clients cl ON cl.id = p.client_id
+		WHERE p.id = $1 AND p.user_id = $2
+	`, profileID, userID)
+	if err := scanRecurringProfile(row, &p); err != nil {
+		return nil, err
+	}
+
+	items, err := s.profileItems(s.db, profileID)
+	if err != nil {
+		return nil, err
+	}
+	p.Items = items
+
+	rows, err := s.db.Query(`
+		SELECT r.run_date, r.invoice_id, i.invoice_number, r.emailed_at
+		FROM recurring_invoice_runs r
+		LEFT JOIN invoices i ON i.id = r.invoice_id
+		WHERE r.profile_id = $1
+		ORDER BY r.run_date DESC
+	`, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	p.Runs = []models.RecurringInvoiceRunDTO{}
+	for rows.Next() {
+		var r models.RecurringInvoiceRunDTO
+		if err := rows.Scan(&r.RunDate, &r.InvoiceID, &r.InvoiceNumber, &r.EmailedAt); err != nil {
+			return nil, err
+		}
+		p.Runs = append(p.Runs, r)
+	}
+
+	return &p, rows.Err()
+}
+
+// SetStatus pauses or resumes a profile. Resuming does not skip the runs
+// missed while paused; the scheduler catches them up on its next tick.
+func (s *RecurringInvoiceService) SetStatus(
+	userID int,
+	profileID int64,
+	status string,
+) error {
+
+	res, err := s.db.Exec(`
+		UPDATE recurring_invoice_profiles
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND user_id = $3 AND status != 'completed'
+	`, status, profileID, userID)
+	if err != nil {
+		return err
+	}
+
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecurringProfile(row rowScanner, p *models.RecurringInvoiceProfile) error {
+	return row.Scan(
+		&p.ID,
+		&p.CompanyID,
+		&p.ClientID,
+		&p.ClientName,
+		&p.Name,
+		&p.Frequency,
+		&p.StartDate,
+		&p.EndDate,
+		&p.NextRunDate,
+		&p.RunsCompleted,
+		&p.DueInDays,
+		&p.AutoIssue,
+		&p.AutoEmail,
+		&p.Notes,
+		&p.Status,
+		&p.LastError,
+		&p.LastRunAt,
+	)
+}
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (s *RecurringInvoiceService) profileItems(
+	q queryer,
+	profileID int64,
+) ([]models.InvoiceItemRequest, error) {
+
+	rows, err := q.Query(`
+		SELECT item_id, qty, rate, discount, tax_rate
+		FROM recurring_invoice_items
+		WHERE profile_id = $1
+		ORDER BY id
+	`, profileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.InvoiceItemRequest{}
+	for rows.Next() {
+		var it models.InvoiceItemRequest
+		if err := rows.Scan(&it.ItemID, &it.Qty, &it.Rate, &it.Discount, &it.TaxRate); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+
+	return items, rows.Err()
+}
+
+// ─── Scheduler ───────────────────────────────────────────────────────────────
+
+// Start runs RunDue immediately (to catch up after downtime) and then on
+// every tick until ctx is cancelled.
+func (s *RecurringInvoiceService) Start(ctx context.Context, interval time.Duration) {
+	log.Printf("🔁 Recurring invoice scheduler started (every %s)", interval)
+
+	s.RunDue(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunDue(ctx)
+		}
+	}
+}
+
+// RunDue generates every occurrence whose date has passed, one transaction
+// per occurrence. A failing profile is left where it is and retried on the
+// next tick, so an outage never drops a run.
+func (s *RecurringInvoiceService) RunDue(ctx context.Context) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id
+		FROM recurring_invoice_profiles
+		WHERE status = 'active'
+		  AND next_run_date <= CURRENT_DATE
+		ORDER BY next_run_date ASC
+	`)
+	if err != nil {
+		log.Printf("❌ Recurring invoices: failed to load due profiles: %v", err)
+		return
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		for ctx.Err() == nil {
+			ran, err := s.runOnce(ctx, id)
+			if err != nil {
+				log.Printf("❌ Recurring profile %d failed: %v", id, err)
+				_, _ = s.db.Exec(`
+					UPDATE recurring_invoice_profiles
+					SET last_error = $1, updated_at = NOW()
+					WHERE id = $2
+				`, err.Error(), id)
+				break
+			}
+			if !ran {
+				break
+			}
+		}
+	}
+}
+
+// runOnce generates the profile's next due occurrence. It reports false
+// when nothing is due (or another instance holds the profile lock).
+func (s *RecurringInvoiceService) runOnce(ctx context.Context, profileID int64) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var (
+		userID, companyID, clientID int
+		frequency                   string
+		startDate, runDate          time.Time
+		endDate                     *time.Time
+		runsCompleted, dueInDays    int
+		autoIssue, autoEmail        bool
+		notes                       *string
+	)
+
+	// 1️⃣ Lock the profile (skip if another instance is on it)
+	err = tx.QueryRow(`
+		SELECT user_id, company_id, client_id, frequency,
+		       start_date, end_date, next_run_date, runs_completed,
+		       due_in_days, auto_issue, auto_email, notes
+		FROM recurring_invoice_profiles
+		WHERE id = $1
+		  AND status = 'active'
+		  AND next_run_date <= CURRENT_DATE
+		FOR UPDATE SKIP LOCKED
+	`, profileID).Scan(
+		&userID, &companyID, &clientID, &frequency,
+		&startDate, &endDate, &runDate, &runsCompleted,
+		&dueInDays, &autoIssue, &autoEmail, &notes,
+	)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if endDate != nil && runDate.After(*endDate) {
+		_, err = tx.Exec(`
+			UPDATE recurring_invoice_profiles
+			SET status = 'completed', updated_at = NOW()
+			WHERE id = $1
+		`, profileID)
+		if err != nil {
+			return false, err
+		}
+		committed = true
+		return false, tx.Commit()
+	}
+
+	// 2️⃣ Claim the occurrence
+	var runID int64
+	err = tx.QueryRow(`
+		INSERT INTO recurring_invoice_runs (profile_id, run_date)
+		VALUES ($1, $2)
+		ON CONFLICT (profile_id, run_date) DO NOTHING
+		RETURNING id
+	`, profileID, runDate).Scan(&runID)
+
+	var created *models.CreatedInvoice
+
+	switch {
+	case err == sql.ErrNoRows:
+		// already generated; only the schedule needs to move on
+	case err != nil:
+		return false, err
+	default:
+		// 3️⃣ Create (and optionally issue) the invoice
+		items, err := s.profileItems(tx, profileID)
+		if err != nil {
+			return false, err
+		}
+
+		created, err = s.invoices.CreateTx(tx, userID, models.InvoiceRequestDTO{
+			CompanyID:   companyID,
+			ClientID:    clientID,
+			InvoiceDate: runDate.Format("2006-01-02"),
+			DueDate:     runDate.AddDate(0, 0, dueInDays).Format("2006-01-02"),
+			Notes:       notes,
+			Items:       items,
+		})
+		if err != nil {
+			return false, fmt.Errorf("create invoice for %s: %w", runDate.Format("2006-01-02"), err)
+		}
+
+		if autoIssue {
+			if _, err := s.invoices.IssueTx(tx, userID, created.ID, true); err != nil {
+				return false, fmt.Errorf("issue invoice %s: %w", created.InvoiceNumber, err)
+			}
+		}
+
+		_, err = tx.Exec(`
+			UPDATE recurring_invoice_runs SET invoice_id = $1 WHERE id = $2
+		`, created.ID, runID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// 4️⃣ Advance the schedule
+	runsCompleted++
+	nextRun := recurringRunDate(startDate, frequency, runsCompleted)
+
+	status := "active"
+	if endDate != nil && nextRun.After(*endDate) {
+		status = "completed"
+	}
+
+	_, err = tx.Exec(`
+		UPDATE recurring_invoice_profiles
+		SET next_run_date = $1,
+			runs_completed = $2,
+			status = $3,
+			last_error = NULL,
+			last_run_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $4
+	`, nextRun, runsCompleted, status, profileID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	committed = true
+
+	if created != nil {
+		log.Printf("✅ Recurring profile %d generated invoice %s", profileID, created.InvoiceNumber)
+	}
+
+	// 5️⃣ Email outside the transaction; a failed send never undoes the run
+	if created != nil && autoEmail {
+		if err := s.sendInvoice(clientID, created); err != nil {
+			log.Printf("❌ Recurring invoice %s email failed: %v", created.InvoiceNumber, err)
+		} else {
+			_, _ = s.db.Exec(`
+				UPDATE recurring_invoice_runs SET emailed_at = NOW() WHERE id = $1
+			`, runID)
+		}
+	}
+
+	return true, nil
+}
+
+func (s *RecurringInvoiceService) sendInvoice(clientID int, inv *models.CreatedInvoice) error {
+	var toName, toEmail string
+	err := s.db.QueryRow(`
+		SELECT cl.name, COALESCE(NULLIF(ca.email, ''), cl.email)
+		FROM clients cl
+		LEFT JOIN client_addresses ca
+		       ON ca.client_id = cl.id AND ca.type = 'billing'
+		WHERE cl.id = $1
+	`, clientID).Scan(&toName, &toEmail)
+	if err != nil {
+		return fmt.Errorf("fetch client email: %w", err)
+	}
+
+	data, err := FetchInvoicePDFData(s.db, inv.ID)
+	if err != nil {
+		return err
+	}
+
+	pdfBytes, err := pdf.GenerateTallyInvoicePDF(data, "original")
+	if err != nil {
+		return fmt.Errorf("generate pdf: %w", err)
+	}
+
+	return s.email.SendInvoiceEmail(toEmail, toName, inv.InvoiceNumber, pdfBytes)
+}
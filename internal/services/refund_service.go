@@ -0,0 +1,346 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+	"invo-server/internal/pdf"
+)
+
+var (
+	ErrRefundNotFound  = errors.New("refund not found")
+	ErrPaymentNotFound = errors.New("payment not found")
+)
+
+type RefundService struct {
+	db     *sql.DB
+	ledger *LedgerService
+}
+
+func NewRefundService(db *sql.DB, ledger *LedgerService) *RefundService {
+	return &RefundService{db: db, ledger: ledger}
+}
+
+// CreateTx pays money back out of a credit note's balance or the part of a
+// payment that isn't allocated to any invoice, and debits the client ledger
+// so the credit the client held is gone.
+func (s *RefundService) CreateTx(
+	tx *sql.Tx,
+	userID int,
+	req models.RefundRequestDTO,
+) (*models.Refund, error) {
+
+	// 1️⃣ Validate input
+	if (req.CreditNoteID == nil) == (req.PaymentID == nil) {
+		return nil, errors.New("exactly one of credit_note_id and payment_id is required")
+	}
+
+	refundDate := time.Now()
+	if req.RefundDate != "" {
+		var err error
+		refundDate, err = time.Parse("2006-01-02", req.RefundDate)
+		if err != nil {
+			return nil, errors.New("invalid refund_date (YYYY-MM-DD)")
+		}
+	}
+
+	r := &models.Refund{
+		CreditNoteID: req.CreditNoteID,
+		PaymentID:    req.PaymentID,
+		Amount:       req.Amount,
+		RefundDate:   refundDate,
+		RefundMethod: strings.TrimSpace(req.RefundMethod),
+		Reference:    strings.TrimSpace(req.Reference),
+		Notes:        strings.TrimSpace(req.Notes),
+	}
+
+	// 2️⃣ Draw down the source
+	var source string
+	if req.CreditNoteID != nil {
+		var total, balance money.Money
+		var number string
+		err := tx.QueryRow(`
+			SELECT cn.company_id, cn.client_id, cn.credit_number, cn.total, cn.balance
+			FROM credit_notes cn
+			JOIN companies c ON c.id = cn.company_id
+			WHERE cn.id = $1 AND c.user_id = $2
+			FOR UPDATE OF cn
+		`, *req.CreditNoteID, userID).Scan(&r.CompanyID, &r.ClientID, &number, &total, &balance)
+		if err == sql.ErrNoRows {
+			return nil, ErrCreditNoteNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if req.Amount > balance {
+			return nil, fmt.Errorf("refund exceeds credit note balance of %s", balance)
+		}
+
+		balance -= req.Amount
+		_, err = tx.Exec(`
+			UPDATE credit_notes SET balance = $1, status = $2 WHERE id = $3
+		`, balance, creditNoteStatus(total, balance), *req.CreditNoteID)
+		if err != nil {
+			return nil, err
+		}
+
+		r.CreditNumber = &number
+		source = "credit note " + number
+	} else {
+		var unapplied money.Money
+		var number *string
+		var status string
+		err := tx.QueryRow(`
+			SELECT p.company_id, p.client_id, p.receipt_number, p.status, `+paymentUnappliedSQL+`
+			FROM payments p
+			JOIN companies c ON c.id = p.company_id
+			WHERE p.id = $1 AND c.user_id = $2
+			FOR UPDATE OF p
+		`, *req.PaymentID, userID).Scan(&r.CompanyID, &r.ClientID, &number, &status, &unapplied)
+		if err == sql.ErrNoRows {
+			return nil, ErrPaymentNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if status == "voided" {
+			return nil, ErrPaymentVoided
+		}
+
+		if req.Amount > unapplied {
+			return nil, fmt.Errorf("refund exceeds unapplied payment amount of %s", unapplied)
+		}
+
+		r.ReceiptNumber = number
+		source = "payment"
+		if number != nil {
+			source += " " + *number
+		}
+	}
+
+	// 3️⃣ Number and insert
+	refundNumber, err := nextDocumentNumberTx(tx, r.CompanyID, DocRefund, refundDate)
+	if err != nil {
+		return nil, err
+	}
+	r.RefundNumber = refundNumber
+
+	err = tx.QueryRow(`
+		INSERT INTO refunds (
+			company_id, client_id, refund_number,
+			credit_note_id, payment_id, amount, refund_date,
+			refund_method, reference, notes, created_by
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,NULLIF($9, ''),NULLIF($10, ''),$11)
+		RETURNING id, created_at
+	`,
+		r.CompanyID,
+		r.ClientID,
+		refundNumber,
+		req.CreditNoteID,
+		req.PaymentID,
+		req.Amount,
+		refundDate,
+		r.RefundMethod,
+		r.Reference,
+		r.Notes,
+		userID,
+	).Scan(&r.ID, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Ledger entry (debit: the client's credit is paid out)
+	err = s.ledger.AddEntryTx(
+		tx,
+		r.CompanyID,
+		r.ClientID,
+		"REFUND",
+		r.ID,
+		req.Amount,
+		0,
+		"Refund "+refundNumber+" against "+source,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+const refundSelectSQL = `
+	SELECT
+		r.id, r.company_id, r.client_id, cl.name, r.refund_number,
+		r.credit_note_id, cn.credit_number, r.payment_id, p.receipt_number,
+		r.amount, r.refund_date, r.refund_method,
+		COALESCE(r.reference, ''), COALESCE(r.notes, ''), r.created_at
+	FROM refunds r
+	JOIN clients cl ON cl.id = r.client_id
+	LEFT JOIN credit_notes cn ON cn.id = r.credit_note_id
+	LEFT JOIN payments p ON p.id = r.payment_id`
+
+func scanRefund(row rowScanner) (*models.Refund, error) {
+	var r models.Refund
+	err := row.Scan(
+		&r.ID,
+		&r.CompanyID,
+		&r.ClientID,
+		&r.ClientName,
+		&r.RefundNumber,
+		&r.CreditNoteID,
+		&r.CreditNumber,
+		&r.PaymentID,
+		&r.ReceiptNumber,
+		&r.Amount,
+		&r.RefundDate,
+		&r.RefundMethod,
+		&r.Reference,
+		&r.Notes,
+		&r.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *RefundService) GetByID(userID int, refundID int64) (*models.Refund, error) {
+	r, err := scanRefund(s.db.QueryRow(refundSelectSQL+`
+		JOIN companies c ON c.id = r.company_id
+		WHERE r.id = $1 AND c.user_id = $2
+	`, refundID, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrRefundNotFound
+	}
+	return r, err
+}
+
+// List returns the company's refunds, newest first.
+func (s *RefundService) List(companyID int64, limit, offset int) ([]models.Refund, error) {
+	rows, err := s.db.Query(refundSelectSQL+`
+		WHERE r.company_id = $1
+		ORDER BY r.refund_date DESC, r.id DESC
+		LIMIT $2 OFFSET $3
+	`, companyID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []models.Refund{}
+	for rows.Next() {
+		r, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, *r)
+	}
+
+	return refunds, rows.Err()
+}
+
+// FetchRefundVoucherPDFData loads a refund with the company and the
+// client's billing address for the voucher.
+func FetchRefundVoucherPDFData(db *sql.DB, refundID int64) (pdf.VoucherPDFData, error) {
+	var data pdf.VoucherPDFData
+	var refundDate time.Time
+	var clientID int64
+	var creditNumber, receiptNumber *string
+	var method, reference string
+
+	err := db.QueryRow(`
+		SELECT
+			r.refund_number,
+			r.refund_date,
+			r.amount,
+			r.refund_method,
+			COALESCE(r.reference, ''),
+			COALESCE(r.notes, ''),
+			r.client_id,
+			cl.name,
+			cn.credit_number,
+			p.receipt_number,
+			c.name,
+			COALESCE(c.phone, ''),
+			COALESCE(c.address, ''),
+			COALESCE(c.city, ''),
+			COALESCE(c.state, ''),
+			COALESCE(c.pincode, ''),
+			COALESCE(c.gst, '')
+		FROM refunds r
+		JOIN companies c ON c.id = r.company_id
+		JOIN clients cl ON cl.id = r.client_id
+		LEFT JOIN credit_notes cn ON cn.id = r.credit_note_id
+		LEFT JOIN payments p ON p.id = r.payment_id
+		WHERE r.id = $1
+	`, refundID).Scan(
+		&data.Number,
+		&refundDate,
+		&data.Amount,
+		&method,
+		&reference,
+		&data.Notes,
+		&clientID,
+		&data.Party.Name,
+		&creditNumber,
+		&receiptNumber,
+		&data.Company.Name,
+		&data.Company.Phone,
+		&data.CompanyAddress.Line1,
+		&data.CompanyAddress.City,
+		&data.CompanyAddress.State,
+		&data.CompanyAddress.Zip,
+		&data.CompanyGSTIN,
+	)
+	if err != nil {
+		return data, fmt.Errorf("fetch refund: %w", err)
+	}
+
+	data.Date = refundDate.Format("02-01-2006")
+	data.CompanyAddress.Name = data.Company.Name
+	data.CompanyAddress.Country = "India"
+
+	switch {
+	case creditNumber != nil:
+		data.Details = append(data.Details, [2]string{"Against", "Credit Note " + *creditNumber})
+	case receiptNumber != nil:
+		data.Details = append(data.Details, [2]string{"Against", "Receipt " + *receiptNumber})
+	default:
+		data.Details = append(data.Details, [2]string{"Against", "Unapplied payment"})
+	}
+	data.Details = append(data.Details, [2]string{"Mode", method})
+	if reference != "" {
+		data.Details = append(data.Details, [2]string{"Reference", reference})
+	}
+
+	// Client billing address, when there is one
+	var addrName string
+	err = db.QueryRow(`
+		SELECT COALESCE(name, ''), line1, COALESCE(city, ''), COALESCE(state, ''),
+		       COALESCE(country, ''), COALESCE(postal_code, '')
+		FROM client_addresses
+		WHERE client_id = $1 AND type = 'billing'
+	`, clientID).Scan(
+		&addrName,
+		&data.Party.Line1,
+		&data.Party.City,
+		&data.Party.State,
+		&data.Party.Country,
+		&data.Party.Zip,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return data, fmt.Errorf("fetch client address: %w", err)
+	}
+	if addrName != "" {
+		data.Party.Name = addrName
+	}
+
+	return data, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"database/sql"
+	"math"
+	"time"
+
+	"invo-server/internal/models"
+	utils "invo-server/internal/util"
+)
+
+type ReportService struct {
+	db *sql.DB
+}
+
+func NewReportService(db *sql.DB) *ReportService {
+	return &ReportService{db: db}
+}
+
+// hsnSummarySQL groups invoice lines by HSN code and tax rate. Callers
+// append the WHERE clause.
+const hsnSummarySQL = `
+	SELECT
+		COALESCE(ii.hsn_code, '') AS hsn,
+		UPPER(COALESCE(MAX(it.unit), '')),
+		ii.tax_rate,
+		SUM(ii.qty),
+		SUM(ii.rate * ii.qty - COALESCE(ii.discount, 0)),
+		SUM(ii.cgst_amount),
+		SUM(ii.sgst_amount),
+		SUM(ii.igst_amount),
+		SUM(ii.total)
+	FROM invoice_items ii
+	JOIN invoices i ON i.id = ii.invoice_id
+	LEFT JOIN items it ON it.id = ii.item_id
+`
+
+const hsnSummaryGroupBy = `
+	GROUP BY COALESCE(ii.hsn_code, ''), ii.tax_rate
+	ORDER BY hsn, ii.tax_rate
+`
+
+func queryHSNSummary(q queryer, where string, args ...interface{}) ([]models.HSNSummaryRow, error) {
+	rows, err := q.Query(hsnSummarySQL+where+hsnSummaryGroupBy, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.HSNSummaryRow{}
+
+	for rows.Next() {
+		var r models.HSNSummaryRow
+		if err := rows.Scan(
+			&r.HSNCode,
+			&r.UQC,
+			&r.TaxRate,
+			&r.Quantity,
+			&r.TaxableValue,
+			&r.CGST,
+			&r.SGST,
+			&r.IGST,
+			&r.TotalValue,
+		); err != nil {
+			return nil, err
+		}
+		r.TotalTax = r.CGST + r.SGST + r.IGST
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// InvoiceHSNSummary returns the HSN-wise tax table of a single invoice.
+func InvoiceHSNSummary(db *sql.DB, invoiceID int) ([]models.HSNSummaryRow, error) {
+	return queryHSNSummary(db, `WHERE ii.invoice_id = $1`, invoiceID)
+}
+
+// HSNSummary returns the HSN-wise outward supplies of a company between
+// from and to (inclusive). Drafts and cancelled invoices are not supplies.
+func (s *ReportService) HSNSummary(
+	companyID int64,
+	from, to time.Time,
+) (*models.HSNSummaryReport, error) {
+
+	rows, err := queryHSNSummary(s.db, `
+		WHERE i.company_id = $1
+		  AND i.invoice_date BETWEEN $2 AND $3
+		  AND i.status NOT IN ('draft', 'cancelled')
+	`, companyID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.HSNSummaryReport{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		Rows: rows,
+	}
+
+	for _, r := range rows {
+		report.Totals.Quantity += r.Quantity
+		report.Totals.TaxableValue += r.TaxableValue
+		report.Totals.CGST += r.CGST
+		report.Totals.SGST += r.SGST
+		report.Totals.IGST += r.IGST
+		report.Totals.TotalTax += r.TotalTax
+		report.Totals.TotalValue += r.TotalValue
+	}
+
+	return report, nil
+}
+
+// Reorder lists items whose stock is below their low-stock threshold, with
+// the average daily quantity sold over the last days days so purchasing
+// can size the order.
+func (s *ReportService) Reorder(companyID int64, days int) (*models.ReorderReport, error) {
+	rows, err := s.db.Query(`
+		SELECT
+			it.id,
+			it.name,
+			COALESCE(it.sku, ''),
+			COALESCE(it.unit, ''),
+			it.quantity,
+			it.low_stock_alert,
+			COALESCE(sold.qty, 0)
+		FROM items it
+		LEFT JOIN (
+			SELECT ii.item_id, SUM(ii.qty) AS qty
+			FROM invoice_items ii
+			JOIN invoices i ON i.id = ii.invoice_id
+			WHERE i.company_id = $1
+			  AND i.status NOT IN ('draft', 'cancelled')
+			  AND i.invoice_date > CURRENT_DATE - $2::INT
+			GROUP BY ii.item_id
+		) sold ON sold.item_id = it.id
+		WHERE it.company_id = $1
+		  AND it.low_stock_alert > 0
+		  AND it.quantity < it.low_stock_alert
+		ORDER BY it.low_stock_alert - it.quantity DESC, it.name
+	`, companyID, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &models.ReorderReport{
+		Days: days,
+		Rows: []models.ReorderRow{},
+	}
+
+	for rows.Next() {
+		var r models.ReorderRow
+		if err := rows.Scan(
+			&r.ItemID,
+			&r.Name,
+			&r.SKU,
+			&r.Unit,
+			&r.Quantity,
+			&r.LowStockAlert,
+			&r.SoldQty,
+		); err != nil {
+			return nil, err
+		}
+
+		r.Shortfall = r.LowStockAlert - r.Quantity
+		r.AvgDailySales = utils.Round2(float64(r.SoldQty) / float64(days))
+
+		if r.AvgDailySales > 0 {
+			left := utils.Round2(math.Max(float64(r.Quantity), 0) / (float64(r.SoldQty) / float64(days)))
+			r.DaysOfStock = &left
+		}
+
+		report.Rows = append(report.Rows, r)
+	}
+
+	return report, rows.Err()
+}
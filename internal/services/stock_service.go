@@ -0,0 +1,439 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"invo-server/internal/models"
+)
+
+// Negative stock policies, set with NEGATIVE_STOCK_POLICY.
+const (
+	StockPolicyAllow = "allow" // issue anyway and let stock go below zero
+	StockPolicyBlock = "block" // refuse to issue an invoice without enough stock
+)
+
+var (
+	ErrStockItemNotFound = errors.New("item not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+// InsufficientStockError lists the items an invoice would take below zero.
+type InsufficientStockError struct {
+	Items []StockShortage
+}
+
+type StockShortage struct {
+	ItemID    int64  `json:"item_id"`
+	Name      string `json:"name"`
+	Available int    `json:"available"`
+	Required  int    `json:"required"`
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for %d item(s)", len(e.Items))
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+type StockService struct {
+	db     *sql.DB
+	policy string
+}
+
+func NewStockService(db *sql.DB, policy string) *StockService {
+	if policy != StockPolicyBlock {
+		policy = StockPolicyAllow
+	}
+	return &StockService{db: db, policy: policy}
+}
+
+// stockLine is a net quantity change for one item.
+type stockLine struct {
+	itemID int64
+	qty    int
+}
+
+// postMovementTx applies one movement. The item row is locked while the
+// quantity changes, so concurrent movements on the same item queue up and
+// balance_after always matches items.quantity.
+func postMovementTx(
+	tx *sql.Tx,
+	companyID int64,
+	itemID int64,
+	qty int,
+	sourceType string,
+	sourceID *int64,
+	note string,
+	userID *int,
+) (*models.StockMovement, error) {
+
+	var (
+		balance, threshold int
+		name               string
+	)
+	err := tx.QueryRow(`
+		UPDATE items
+		SET quantity = quantity + $3,
+			updated_at = NOW()
+		WHERE id = $1 AND company_id = $2
+		RETURNING quantity, COALESCE(low_stock_alert, 0), name
+	`, itemID, companyID, qty).Scan(&balance, &threshold, &name)
+	if err == sql.ErrNoRows {
+		return nil, ErrStockItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lowStockCrossed(balance-qty, balance, threshold) {
+		if err := raiseLowStockTx(tx, companyID, itemID, name, balance, threshold); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &models.StockMovement{
+		ItemID:       itemID,
+		SourceType:   sourceType,
+		SourceID:     sourceID,
+		Quantity:     qty,
+		BalanceAfter: balance,
+		Note:         note,
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO stock_movements (
+			company_id, item_id, source_type, source_id,
+			quantity, balance_after, note, created_by
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		RETURNING id, created_at
+	`,
+		companyID,
+		itemID,
+		sourceType,
+		sourceID,
+		qty,
+		balance,
+		note,
+		userID,
+	).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// lockStockTx locks the items in id order (so two invoices sharing items
+// can't deadlock) and returns the shortages the outward lines would cause.
+func lockStockTx(tx *sql.Tx, companyID int64, lines []stockLine) ([]StockShortage, error) {
+	var shortages []StockShortage
+
+	for _, l := range lines {
+		var (
+			name      string
+			available int
+		)
+		err := tx.QueryRow(`
+			SELECT name, quantity
+			FROM items
+			WHERE id = $1 AND company_id = $2
+			FOR UPDATE
+		`, l.itemID, companyID).Scan(&name, &available)
+		if err == sql.ErrNoRows {
+			return nil, ErrStockItemNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if l.qty < 0 && available+l.qty < 0 {
+			shortages = append(shortages, StockShortage{
+				ItemID:    l.itemID,
+				Name:      name,
+				Available: available,
+				Required:  -l.qty,
+			})
+		}
+	}
+
+	return shortages, nil
+}
+
+func scanStockLines(rows *sql.Rows) ([]stockLine, error) {
+	defer rows.Close()
+
+	var lines []stockLine
+	for rows.Next() {
+		var l stockLine
+		if err := rows.Scan(&l.itemID, &l.qty); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// IssueInvoiceTx posts the outward movements for an invoice being issued.
+// Service lines (SAC 99xx) carry no stock and are skipped.
+func (s *StockService) IssueInvoiceTx(tx *sql.Tx, companyID, invoiceID int64, number string) error {
+	rows, err := tx.Query(`
+		SELECT ii.item_id, -SUM(ii.qty)::INT
+		FROM invoice_items ii
+		JOIN items it ON it.id = ii.item_id
+		WHERE ii.invoice_id = $1
+		  AND COALESCE(it.hsn_code, '') NOT LIKE '99%'
+		GROUP BY ii.item_id
+		ORDER BY ii.item_id
+	`, invoiceID)
+	if err != nil {
+		return err
+	}
+
+	lines, err := scanStockLines(rows)
+	if err != nil {
+		return err
+	}
+
+	shortages, err := lockStockTx(tx, companyID, lines)
+	if err != nil {
+		return err
+	}
+	if len(shortages) > 0 && s.policy == StockPolicyBlock {
+		return &InsufficientStockError{Items: shortages}
+	}
+
+	for _, l := range lines {
+		if _, err := postMovementTx(tx, companyID, l.itemID, l.qty, "INVOICE", &invoiceID, "Invoice "+number, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurchaseTx posts the inward movements for a purchase bill. As with
+// invoices, service lines (SAC 99xx) and lines without a catalogue item
+// carry no stock.
+func (s *StockService) PurchaseTx(tx *sql.Tx, companyID, billID int64, note string) error {
+	rows, err := tx.Query(`
+		SELECT pi.item_id, SUM(pi.qty)::INT
+		FROM purchase_bill_items pi
+		JOIN items it ON it.id = pi.item_id
+		WHERE pi.bill_id = $1
+		  AND COALESCE(it.hsn_code, '') NOT LIKE '99%'
+		GROUP BY pi.item_id
+		ORDER BY pi.item_id
+	`, billID)
+	if err != nil {
+		return err
+	}
+
+	lines, err := scanStockLines(rows)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range lines {
+		if _, err := postMovementTx(tx, companyID, l.itemID, l.qty, "PURCHASE", &billID, note, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReceiveTx brings goods received against a purchase order line into
+// stock. Services (HSN chapter 99) aren't stocked and are skipped.
+func (s *StockService) ReceiveTx(
+	tx *sql.Tx,
+	companyID, itemID int64,
+	qty int,
+	receiptID int64,
+	note string,
+	userID int,
+) error {
+
+	var service bool
+	err := tx.QueryRow(`
+		SELECT COALESCE(hsn_code, '') LIKE '99%'
+		FROM items
+		WHERE id = $1 AND company_id = $2
+	`, itemID, companyID).Scan(&service)
+	if err == sql.ErrNoRows {
+		return ErrStockItemNotFound
+	}
+	if err != nil || service {
+		return err
+	}
+
+	_, err = postMovementTx(tx, companyID, itemID, qty, "PO_RECEIPT", &receiptID, note, &userID)
+	return err
+}
+
+// ReverseInvoiceTx puts back whatever stock an invoice still holds, e.g.
+// when it is cancelled after being issued. Goods already returned on the
+// invoice's credit notes are back in stock and are not counted again.
+func (s *StockService) ReverseInvoiceTx(tx *sql.Tx, companyID, invoiceID int64, number string) error {
+	rows, err := tx.Query(`
+		SELECT item_id, -SUM(quantity)::INT
+		FROM stock_movements
+		WHERE (source_type = 'INVOICE' AND source_id = $1)
+		   OR (source_type = 'CREDIT_NOTE' AND source_id IN (
+				SELECT id FROM credit_notes WHERE invoice_id = $1
+		   ))
+		GROUP BY item_id
+		HAVING SUM(quantity) <> 0
+		ORDER BY item_id
+	`, invoiceID)
+	if err != nil {
+		return err
+	}
+
+	lines, err := scanStockLines(rows)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range lines {
+		if _, err := postMovementTx(tx, companyID, l.itemID, l.qty, "INVOICE", &invoiceID, "Invoice "+number+" cancelled", nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReturnTx posts the inward movements for goods returned on a credit note.
+// Service lines (SAC 99xx) carry no stock and are skipped.
+func (s *StockService) ReturnTx(
+	tx *sql.Tx,
+	companyID int64,
+	creditNoteID int64,
+	number string,
+	items []models.CreditNoteItemDTO,
+) error {
+
+	qty := map[int64]int{}
+	var ids []int64
+	for _, it := range items {
+		if _, seen := qty[it.ItemID]; !seen {
+			ids = append(ids, it.ItemID)
+		}
+		qty[it.ItemID] += int(it.Qty)
+	}
+
+	lines := make([]stockLine, 0, len(ids))
+	for _, id := range ids {
+		lines = append(lines, stockLine{itemID: id, qty: qty[id]})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].itemID < lines[j].itemID })
+
+	for _, l := range lines {
+		var service bool
+		err := tx.QueryRow(`
+			SELECT COALESCE(hsn_code, '') LIKE '99%'
+			FROM items
+			WHERE id = $1 AND company_id = $2
+		`, l.itemID, companyID).Scan(&service)
+		if err == sql.ErrNoRows {
+			return ErrStockItemNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if service {
+			continue
+		}
+
+		if _, err := postMovementTx(tx, companyID, l.itemID, l.qty, "CREDIT_NOTE", &creditNoteID, "Credit note "+number, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AdjustTx records a manual stock correction. Unlike invoices, an
+// adjustment may never leave the item below zero.
+func (s *StockService) AdjustTx(
+	tx *sql.Tx,
+	userID int,
+	itemID int64,
+	req models.StockAdjustmentRequestDTO,
+) (*models.StockMovement, error) {
+
+	var companyID int64
+	err := tx.QueryRow(`
+		SELECT company_id FROM items WHERE id = $1 AND user_id = $2
+	`, itemID, userID).Scan(&companyID)
+	if err == sql.ErrNoRows {
+		return nil, ErrStockItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	line := []stockLine{{itemID: itemID, qty: req.Quantity}}
+	shortages, err := lockStockTx(tx, companyID, line)
+	if err != nil {
+		return nil, err
+	}
+	if len(shortages) > 0 {
+		return nil, &InsufficientStockError{Items: shortages}
+	}
+
+	return postMovementTx(tx, companyID, itemID, req.Quantity, "ADJUSTMENT", nil, req.Note, &userID)
+}
+
+// History returns an item's movements, newest first.
+func (s *StockService) History(userID int, itemID int64, limit, offset int) ([]models.StockMovement, error) {
+	var exists bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM items WHERE id = $1 AND user_id = $2)
+	`, itemID, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrStockItemNotFound
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, item_id, source_type, source_id, quantity, balance_after,
+		       COALESCE(note, ''), created_at
+		FROM stock_movements
+		WHERE item_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`, itemID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(
+			&m.ID,
+			&m.ItemID,
+			&m.SourceType,
+			&m.SourceID,
+			&m.Quantity,
+			&m.BalanceAfter,
+			&m.Note,
+			&m.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"invo-server/internal/models"
+	"invo-server/internal/money"
+)
+
+// VendorLedgerService keeps the payables ledger. It mirrors LedgerService
+// with the sides swapped: bills are credits, payments are debits, and the
+// balance is what the company owes the vendor.
+type VendorLedgerService struct {
+	db *sql.DB
+}
+
+func NewVendorLedgerService(db *sql.DB) *VendorLedgerService {
+	return &VendorLedgerService{db: db}
+}
+
+func (s *VendorLedgerService) getLastBalanceTx(
+	tx *sql.Tx,
+	companyID, vendorID int64,
+) (money.Money, error) {
+
+	var balance money.Money
+
+	err := tx.QueryRow(`
+		SELECT balance
+		FROM vendor_ledger_entries
+		WHERE company_id = $1 AND vendor_id = $2
+		ORDER BY id DESC
+		LIMIT 1
+	`, companyID, vendorID).Scan(&balance)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return balance, err
+}
+
+func (s *VendorLedgerService) AddEntryTx(
+	tx *sql.Tx,
+	companyID int64,
+	vendorID int64,
+	sourceType string,
+	sourceID int64,
+	debit money.Money,
+	credit money.Money,
+	description string,
+) error {
+
+	// Serialise postings per vendor so balances don't interleave
+	if _, err := tx.Exec(`SELECT 1 FROM vendors WHERE id = $1 FOR UPDATE`, vendorID); err != nil {
+		return err
+	}
+
+	lastBalance, err := s.getLastBalanceTx(tx, companyID, vendorID)
+	if err != nil {
+		return err
+	}
+
+	newBalance := lastBalance + credit - debit
+
+	_, err = tx.Exec(`
+		INSERT INTO vendor_ledger_entries (
+			company_id,
+			vendor_id,
+			source_type,
+			source_id,
+			debit,
+			credit,
+			balance,
+			description
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+	`,
+		companyID,
+		vendorID,
+		sourceType,
+		sourceID,
+		debit,
+		credit,
+		newBalance,
+		description,
+	)
+
+	return err
+}
+
+func (s *VendorLedgerService) GetVendorLedger(
+	ctx context.Context,
+	companyID, vendorID int64,
+) ([]models.VendorLedgerEntry, error) {
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			le.id,
+			le.company_id,
+			le.vendor_id,
+			v.name,
+			le.source_type,
+			le.source_id,
+			le.debit,
+			le.credit,
+			le.balance,
+			COALESCE(le.description, ''),
+			le.created_at
+		FROM vendor_ledger_entries le
+		JOIN vendors v ON v.id = le.vendor_id
+		WHERE le.company_id = $1 AND le.vendor_id = $2
+		ORDER BY le.id ASC
+	`, companyID, vendorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.VendorLedgerEntry{}
+
+	for rows.Next() {
+		var e models.VendorLedgerEntry
+		if err := rows.Scan(
+			&e.ID,
+			&e.CompanyID,
+			&e.VendorID,
+			&e.VendorName,
+			&e.SourceType,
+			&e.SourceID,
+			&e.Debit,
+			&e.Credit,
+			&e.Balance,
+			&e.Description,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
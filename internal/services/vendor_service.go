@@ -0,0 +1,206 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"invo-server/internal/models"
+	utils "invo-server/internal/util"
+)
+
+var (
+	ErrVendorNotFound = errors.New("vendor not found")
+	ErrInvalidGSTIN   = errors.New("invalid GSTIN")
+)
+
+type VendorService struct {
+	db *sql.DB
+}
+
+func NewVendorService(db *sql.DB) *VendorService {
+	return &VendorService{db: db}
+}
+
+func (s *VendorService) Create(userID int, req models.Vendor) (*models.Vendor, error) {
+	req.GSTIN = strings.ToUpper(strings.TrimSpace(req.GSTIN))
+	if req.GSTIN != "" && !utils.ValidGSTIN(req.GSTIN) {
+		return nil, ErrInvalidGSTIN
+	}
+
+	err := s.db.QueryRow(`
+		INSERT INTO vendors (company_id, user_id, name, email, phone, gstin)
+		SELECT $1, $2, $3, $4, $5, NULLIF($6, '')
+		WHERE EXISTS (
+			SELECT 1 FROM companies WHERE id = $1 AND user_id = $2
+		)
+		RETURNING id, created_at, updated_at
+	`,
+		req.CompanyID,
+		userID,
+		req.Name,
+		req.Email,
+		req.Phone,
+		req.GSTIN,
+	).Scan(&req.ID, &req.CreatedAt, &req.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	req.UserID = userID
+	return &req, nil
+}
+
+func (s *VendorService) List(companyID int64) ([]models.Vendor, error) {
+	rows, err := s.db.Query(`
+		SELECT id, user_id, company_id, name,
+		       COALESCE(email, ''), COALESCE(phone, ''), COALESCE(gstin, ''),
+		       created_at, updated_at
+		FROM vendors
+		WHERE company_id = $1
+		ORDER BY name
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vendors := []models.Vendor{}
+	for rows.Next() {
+		var v models.Vendor
+		if err := rows.Scan(
+			&v.ID,
+			&v.UserID,
+			&v.CompanyID,
+			&v.Name,
+			&v.Email,
+			&v.Phone,
+			&v.GSTIN,
+			&v.CreatedAt,
+			&v.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		vendors = append(vendors, v)
+	}
+
+	return vendors, rows.Err()
+}
+
+func (s *VendorService) GetByID(userID int, vendorID int64) (*models.Vendor, error) {
+	var v models.Vendor
+	err := s.db.QueryRow(`
+		SELECT v.id, v.user_id, v.company_id, v.name,
+		       COALESCE(v.email, ''), COALESCE(v.phone, ''), COALESCE(v.gstin, ''),
+		       v.created_at, v.updated_at
+		FROM vendors v
+		JOIN companies c ON c.id = v.company_id
+		WHERE v.id = $1 AND c.user_id = $2
+	`, vendorID, userID).Scan(
+		&v.ID,
+		&v.UserID,
+		&v.CompanyID,
+		&v.Name,
+		&v.Email,
+		&v.Phone,
+		&v.GSTIN,
+		&v.CreatedAt,
+		&v.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrVendorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// SaveAddress creates or replaces the vendor's billing or shipping address.
+func (s *VendorService) SaveAddress(userID int, vendorID int64, req models.Address) error {
+	res, err := s.db.Exec(`
+		INSERT INTO vendor_addresses (
+			vendor_id, type,
+			name, line1, line2, city, state,
+			postal_code, country, phone, email, gst_number
+		)
+		SELECT
+			$1, $2,
+			$3,$4,$5,$6,$7,$8,$9,$10,$11,$12
+		WHERE EXISTS (
+			SELECT 1 FROM vendors v
+			JOIN companies c ON c.id = v.company_id
+			WHERE v.id = $1 AND c.user_id = $13
+		)
+		ON CONFLICT (vendor_id, type)
+		DO UPDATE SET
+			name = EXCLUDED.name,
+			line1 = EXCLUDED.line1,
+			line2 = EXCLUDED.line2,
+			city = EXCLUDED.city,
+			state = EXCLUDED.state,
+			postal_code = EXCLUDED.postal_code,
+			country = EXCLUDED.country,
+			phone = EXCLUDED.phone,
+			email = EXCLUDED.email,
+			gst_number = EXCLUDED.gst_number,
+			updated_at = NOW()
+	`,
+		vendorID,
+		req.AddressType,
+		req.Name,
+		req.Line1,
+		req.Line2,
+		req.City,
+		req.State,
+		req.PostalCode,
+		req.Country,
+		req.Phone,
+		req.Email,
+		req.GSTNumber,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrVendorNotFound
+	}
+	return nil
+}
+
+// GetAddress returns nil when the vendor has no address of that type.
+func (s *VendorService) GetAddress(userID int, vendorID int64, addrType string) (*models.Address, error) {
+	var a models.Address
+
+	err := s.db.QueryRow(`
+		SELECT va.type, va.name, va.line1, va.line2, va.city, va.state,
+		       va.postal_code, va.country, va.phone, va.email, va.gst_number
+		FROM vendor_addresses va
+		JOIN vendors v ON v.id = va.vendor_id
+		JOIN companies c ON c.id = v.company_id
+		WHERE va.vendor_id = $1 AND va.type = $2 AND c.user_id = $3
+	`, vendorID, addrType, userID).Scan(
+		&a.AddressType,
+		&a.Name,
+		&a.Line1,
+		&a.Line2,
+		&a.City,
+		&a.State,
+		&a.PostalCode,
+		&a.Country,
+		&a.Phone,
+		&a.Email,
+		&a.GSTNumber,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
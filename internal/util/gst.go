@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"invo-server/internal/money"
+)
+
+// GST state codes (first two digits of a GSTIN), keyed by lower-case name.
+var gstStateCodes = map[string]string{
+	"jammu and kashmir": "01",
+	"himachal pradesh":  "02",
+	"punjab":            "03",
+	"chandigarh":        "04",
+	"uttarakhand":       "05",
+	"haryana":           "06",
+	"delhi":             "07",
+	"rajasthan":         "08",
+	"uttar pradesh":     "09",
+	"bihar":             "10",
+	"sikkim":            "11",
+	"arunachal pradesh": "12",
+	"nagaland":          "13",
+	"manipur":           "14",
+	"mizoram":           "15",
+	"tripura":           "16",
+	"meghalaya":         "17",
+	"assam":             "18",
+	"west bengal":       "19",
+	"jharkhand":         "20",
+	"odisha":            "21",
+	"chhattisgarh":      "22",
+	"madhya pradesh":    "23",
+	"gujarat":           "24",
+	"dadra and nagar haveli and daman and diu": "26",
+	"maharashtra":                 "27",
+	"karnataka":                   "29",
+	"goa":                         "30",
+	"lakshadweep":                 "31",
+	"kerala":                      "32",
+	"tamil nadu":                  "33",
+	"puducherry":                  "34",
+	"andaman and nicobar islands": "35",
+	"telangana":                   "36",
+	"andhra pradesh":              "37",
+	"ladakh":                      "38",
+	"other territory":             "97",
+}
+
+// common spellings that differ from the official names above
+var gstStateAliases = map[string]string{
+	"j&k":                 "jammu and kashmir",
+	"jammu & kashmir":     "jammu and kashmir",
+	"new delhi":           "delhi",
+	"nct of delhi":        "delhi",
+	"orissa":              "odisha",
+	"uttaranchal":         "uttarakhand",
+	"pondicherry":         "puducherry",
+	"andaman & nicobar":   "andaman and nicobar islands",
+	"daman and diu":       "dadra and nagar haveli and daman and diu",
+	"dadra nagar haveli":  "dadra and nagar haveli and daman and diu",
+	"tamilnadu":           "tamil nadu",
+	"chattisgarh":         "chhattisgarh",
+	"andaman and nicobar": "andaman and nicobar islands",
+}
+
+// StateCode resolves a state name (or an already numeric code) to its
+// two-digit GST state code. Unknown input returns "".
+func StateCode(state string) string {
+	s := strings.ToLower(strings.TrimSpace(state))
+	if s == "" {
+		return ""
+	}
+
+	if len(s) == 2 && s[0] >= '0' && s[0] <= '9' && s[1] >= '0' && s[1] <= '9' {
+		return s
+	}
+
+	if alias, ok := gstStateAliases[s]; ok {
+		s = alias
+	}
+	return gstStateCodes[s]
+}
+
+// GSTINStateCode returns the state code embedded in a GSTIN, or "" when
+// the GSTIN is missing or malformed.
+func GSTINStateCode(gstin string) string {
+	g := strings.TrimSpace(gstin)
+	if len(g) != 15 {
+		return ""
+	}
+	if g[0] < '0' || g[0] > '9' || g[1] < '0' || g[1] > '9' {
+		return ""
+	}
+	return g[:2]
+}
+
+// ResolveStateCode prefers the GSTIN prefix over the free-text state.
+func ResolveStateCode(gstin, state string) string {
+	if code := GSTINStateCode(gstin); code != "" {
+		return code
+	}
+	return StateCode(state)
+}
+
+// StateName returns the display name for a GST state code, e.g.
+// "Karnataka" for "29". Unknown codes are returned unchanged.
+func StateName(code string) string {
+	for name, c := range gstStateCodes {
+		if c != code {
+			continue
+		}
+		words := strings.Fields(name)
+		for i, w := range words {
+			if w != "and" {
+				words[i] = strings.ToUpper(w[:1]) + w[1:]
+			}
+		}
+		return strings.Join(words, " ")
+	}
+	return code
+}
+
+// PlaceOfSupplyLabel formats a state code the way it is printed on tax
+// invoices: "Karnataka (29)".
+func PlaceOfSupplyLabel(code string) string {
+	if code == "" {
+		return ""
+	}
+	return StateName(code) + " (" + code + ")"
+}
+
+// IsInterState reports whether a supply from supplierState to
+// placeOfSupply attracts IGST. When either side is unknown the supply is
+// treated as intra-state, matching how invoices were taxed before.
+func IsInterState(supplierState, placeOfSupply string) bool {
+	return supplierState != "" && placeOfSupply != "" && supplierState != placeOfSupply
+}
+
+// Round2 rounds a figure such as an average to two decimals. Amounts are
+// money.Money and never go through it.
+func Round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// SplitTax divides a line's tax into heads. Intra-state tax is halved
+// into CGST/SGST with any odd paisa going to SGST so the heads always add
+// back up to the line tax.
+func SplitTax(tax money.Money, interState bool) (cgst, sgst, igst money.Money) {
+	if interState {
+		return 0, 0, tax
+	}
+	cgst, sgst = tax.Halve()
+	return cgst, sgst, 0
+}
+
+type TaxLine struct {
+	TaxRate float64
+	CGST    money.Money
+	SGST    money.Money
+	IGST    money.Money
+}
+
+type TaxHead struct {
+	Head   string      `json:"head"` // CGST | SGST | IGST
+	Rate   float64     `json:"rate"`
+	Amount money.Money `json:"amount"`
+}
+
+// SummarizeTaxHeads groups line taxes by rate into the heads printed on a
+// tax invoice, e.g. "CGST @ 9%" and "SGST @ 9%" for an 18% line.
+func SummarizeTaxHeads(lines []TaxLine) []TaxHead {
+	type bucket struct{ cgst, sgst, igst money.Money }
+
+	byRate := map[float64]*bucket{}
+	var rates []float64
+
+	for _, l := range lines {
+		b, ok := byRate[l.TaxRate]
+		if !ok {
+			b = &bucket{}
+			byRate[l.TaxRate] = b
+			rates = append(rates, l.TaxRate)
+		}
+		b.cgst += l.CGST
+		b.sgst += l.SGST
+		b.igst += l.IGST
+	}
+
+	sort.Float64s(rates)
+
+	heads := []TaxHead{}
+	for _, r := range rates {
+		b := byRate[r]
+		if b.cgst != 0 || b.sgst != 0 {
+			heads = append(heads,
+				TaxHead{Head: "CGST", Rate: r / 2, Amount: b.cgst},
+				TaxHead{Head: "SGST", Rate: r / 2, Amount: b.sgst},
+			)
+		}
+		if b.igst != 0 {
+			heads = append(heads, TaxHead{Head: "IGST", Rate: r, Amount: b.igst})
+		}
+	}
+	return heads
+}
+
+var (
+	gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+	hsnPattern   = regexp.MustCompile(`^([0-9]{4}|[0-9]{6}|[0-9]{8})$`)
+)
+
+const gstinCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// ValidGSTIN checks the format and the check digit of a GSTIN.
+func ValidGSTIN(gstin string) bool {
+	g := strings.ToUpper(strings.TrimSpace(gstin))
+	if !gstinPattern.MatchString(g) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 14; i++ {
+		v := strings.IndexByte(gstinCharset, g[i]) * (i%2 + 1)
+		sum += v/36 + v%36
+	}
+	check := (36 - sum%36) % 36
+	return g[14] == gstinCharset[check]
+}
+
+// ValidHSN accepts 4, 6 or 8 digit HSN/SAC codes.
+func ValidHSN(code string) bool {
+	return hsnPattern.MatchString(strings.TrimSpace(code))
+}
+
+// ParseReturnPeriod turns "2025-03" into the first and last day of the
+// month and the GST portal's "fp" form, "032025".
+func ParseReturnPeriod(period string) (from, to time.Time, fp string, err error) {
+	from, err = time.Parse("2006-01", period)
+	if err != nil {
+		return from, to, "", fmt.Errorf("invalid period %q (YYYY-MM)", period)
+	}
+	to = from.AddDate(0, 1, -1)
+	return from, to, from.Format("012006"), nil
+}
+
+// common item units mapped to GST unit quantity codes
+var uqcCodes = map[string]string{
+	"nos": "NOS", "no": "NOS", "pcs": "PCS", "pc": "PCS", "piece": "PCS", "pieces": "PCS",
+	"unit": "UNT", "units": "UNT",
+	"kg": "KGS", "kgs": "KGS", "kilogram": "KGS",
+	"g": "GMS", "gm": "GMS", "gms": "GMS", "gram": "GMS",
+	"l": "LTR", "ltr": "LTR", "litre": "LTR", "liter": "LTR",
+	"ml": "MLT",
+	"m":  "MTR", "mtr": "MTR", "meter": "MTR", "metre": "MTR",
+	"box": "BOX", "bag": "BAG", "set": "SET", "pair": "PRS", "dozen": "DOZ", "doz": "DOZ",
+	"hr": "OTH", "hour": "OTH",
+}
+
+// UQCCode maps a free-text item unit to a GST UQC, "OTH" when unknown.
+func UQCCode(unit string) string {
+	u := strings.ToLower(strings.TrimSpace(unit))
+	for _, code := range uqcCodes {
+		if strings.EqualFold(code, u) {
+			return code
+		}
+	}
+	if code, ok := uqcCodes[u]; ok {
+		return code
+	}
+	return "OTH"
+}
@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,3 +18,111 @@ func FinancialYear(date time.Time) string {
 	}
 	return fmt.Sprintf("FY%02d-%02d", (year-1)%100, year%100)
 }
+
+// Reset policies for document number sequences.
+const (
+	ResetNever   = "never"
+	ResetYearly  = "yearly"
+	ResetFY      = "fy"
+	ResetMonthly = "monthly"
+)
+
+// numberToken matches {FY}, {YYYY}, {MM}, {SEQ} and {SEQ:n}.
+var numberToken = regexp.MustCompile(`\{([A-Z]+)(?::([0-9]+))?\}`)
+
+// NumberingPeriod returns the counter bucket a document dated date falls
+// into: "" for never, "2026" for yearly, "FY25-26" for fy and "2026-04"
+// for monthly.
+func NumberingPeriod(reset string, date time.Time) string {
+	switch reset {
+	case ResetYearly:
+		return date.Format("2006")
+	case ResetFY:
+		return FinancialYear(date)
+	case ResetMonthly:
+		return date.Format("2006-01")
+	default:
+		return ""
+	}
+}
+
+// ValidateNumberTemplate checks a numbering template. It must contain one
+// {SEQ} token, and enough date tokens that a sequence reset can't produce
+// a number that was already used.
+func ValidateNumberTemplate(template, reset string) error {
+	if strings.TrimSpace(template) == "" {
+		return errors.New("template is required")
+	}
+
+	seq := 0
+	has := map[string]bool{}
+
+	for _, m := range numberToken.FindAllStringSubmatch(template, -1) {
+		switch m[1] {
+		case "SEQ":
+			seq++
+			if m[2] != "" {
+				if n, _ := strconv.Atoi(m[2]); n < 1 || n > 10 {
+					return errors.New("{SEQ:n} padding must be between 1 and 10")
+				}
+			}
+		case "FY", "YYYY", "MM":
+			if m[2] != "" {
+				return fmt.Errorf("{%s} does not take a width", m[1])
+			}
+			has[m[1]] = true
+		default:
+			return fmt.Errorf("unknown token {%s}", m[1])
+		}
+	}
+
+	if seq != 1 {
+		return errors.New("template must contain exactly one {SEQ} or {SEQ:n} token")
+	}
+
+	rest := numberToken.ReplaceAllString(template, "")
+	if strings.ContainsAny(rest, "{}") {
+		return errors.New("template has an unclosed or malformed token")
+	}
+
+	switch reset {
+	case ResetNever:
+	case ResetYearly:
+		if !has["YYYY"] {
+			return errors.New("yearly reset needs {YYYY} in the template")
+		}
+	case ResetFY:
+		if !has["FY"] {
+			return errors.New("financial-year reset needs {FY} in the template")
+		}
+	case ResetMonthly:
+		if !has["MM"] || !(has["YYYY"] || has["FY"]) {
+			return errors.New("monthly reset needs {MM} and {YYYY} or {FY} in the template")
+		}
+	default:
+		return errors.New("reset_policy must be never, yearly, fy or monthly")
+	}
+
+	return nil
+}
+
+// FormatDocumentNumber expands a numbering template. {FY} is the financial
+// year as "FY25-26", {YYYY} and {MM} come from the document date and
+// {SEQ:n} is the sequence zero-padded to n digits.
+func FormatDocumentNumber(template string, date time.Time, seq int) string {
+	return numberToken.ReplaceAllStringFunc(template, func(tok string) string {
+		m := numberToken.FindStringSubmatch(tok)
+		switch m[1] {
+		case "FY":
+			return FinancialYear(date)
+		case "YYYY":
+			return date.Format("2006")
+		case "MM":
+			return date.Format("01")
+		case "SEQ":
+			width, _ := strconv.Atoi(m[2])
+			return fmt.Sprintf("%0*d", width, seq)
+		}
+		return tok
+	})
+}
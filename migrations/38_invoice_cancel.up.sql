@@ -0,0 +1,3 @@
+ALTER TABLE invoices
+ADD COLUMN cancelled_at TIMESTAMP,
+ADD COLUMN cancel_reason TEXT;
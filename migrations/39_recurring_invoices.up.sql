@@ -0,0 +1,51 @@
+CREATE TABLE recurring_invoice_profiles (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id INT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    name VARCHAR(255),
+    frequency VARCHAR(20) NOT NULL CHECK (
+        frequency IN ('weekly', 'monthly', 'quarterly', 'yearly')
+    ),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    next_run_date DATE NOT NULL,
+    runs_completed INT NOT NULL DEFAULT 0,
+    due_in_days INT NOT NULL DEFAULT 30 CHECK (due_in_days >= 0),
+    auto_issue BOOLEAN NOT NULL DEFAULT FALSE,
+    auto_email BOOLEAN NOT NULL DEFAULT FALSE,
+    notes TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (
+        status IN ('active', 'paused', 'completed')
+    ),
+    last_error TEXT,
+    last_run_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_recurring_profiles_due
+    ON recurring_invoice_profiles(next_run_date)
+    WHERE status = 'active';
+
+CREATE TABLE recurring_invoice_items (
+    id BIGSERIAL PRIMARY KEY,
+    profile_id BIGINT NOT NULL REFERENCES recurring_invoice_profiles(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    qty INT NOT NULL CHECK (qty > 0),
+    rate NUMERIC(10,2) NOT NULL CHECK (rate >= 0),
+    discount NUMERIC(10,2) DEFAULT 0 CHECK (discount >= 0),
+    tax_rate NUMERIC(5,2) DEFAULT 0 CHECK (tax_rate >= 0)
+);
+
+-- One row per scheduled occurrence; the unique key stops a run from
+-- being generated twice when the scheduler catches up after downtime.
+CREATE TABLE recurring_invoice_runs (
+    id BIGSERIAL PRIMARY KEY,
+    profile_id BIGINT NOT NULL REFERENCES recurring_invoice_profiles(id) ON DELETE CASCADE,
+    run_date DATE NOT NULL,
+    invoice_id INT REFERENCES invoices(id) ON DELETE SET NULL,
+    emailed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (profile_id, run_date)
+);
@@ -0,0 +1,43 @@
+CREATE TABLE quote_counters (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    financial_year VARCHAR(9) NOT NULL, -- FY24-25
+    next_number INT NOT NULL DEFAULT 1,
+    UNIQUE (company_id, financial_year)
+);
+
+CREATE TABLE quotes (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id INT NOT NULL REFERENCES clients(id) ON DELETE CASCADE,
+    quote_number VARCHAR(50) NOT NULL,
+    quote_date DATE NOT NULL,
+    valid_until DATE NOT NULL,
+    subtotal NUMERIC(10,2) NOT NULL,
+    tax NUMERIC(10,2) NOT NULL,
+    total NUMERIC(10,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (
+        status IN ('draft', 'sent', 'accepted', 'rejected', 'expired')
+    ),
+    notes TEXT,
+    invoice_id INT REFERENCES invoices(id) ON DELETE SET NULL,
+    converted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (company_id, quote_number)
+);
+
+CREATE TABLE quote_items (
+    id BIGSERIAL PRIMARY KEY,
+    quote_id BIGINT NOT NULL REFERENCES quotes(id) ON DELETE CASCADE,
+    item_id INTEGER REFERENCES items(id) ON DELETE SET NULL,
+    qty INT NOT NULL CHECK (qty > 0),
+    rate NUMERIC(10,2) NOT NULL CHECK (rate >= 0),
+    discount NUMERIC(10,2) DEFAULT 0 CHECK (discount >= 0),
+    tax_rate NUMERIC(5,2) DEFAULT 0 CHECK (tax_rate >= 0),
+    total NUMERIC(10,2) NOT NULL
+);
+
+ALTER TABLE invoices
+ADD COLUMN quote_id BIGINT REFERENCES quotes(id) ON DELETE SET NULL;
@@ -0,0 +1,34 @@
+-- Place of supply decides CGST+SGST (intra-state) vs IGST (inter-state).
+ALTER TABLE invoices
+ADD COLUMN IF NOT EXISTS place_of_supply VARCHAR(2),
+ADD COLUMN IF NOT EXISTS is_inter_state BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS cgst NUMERIC(10,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS sgst NUMERIC(10,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS igst NUMERIC(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE invoice_items
+ADD COLUMN IF NOT EXISTS cgst_amount NUMERIC(10,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS sgst_amount NUMERIC(10,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS igst_amount NUMERIC(10,2) NOT NULL DEFAULT 0;
+
+-- Existing invoices were all taxed as intra-state. The line tax is split
+-- the way money.Halve does it: CGST is the half rounded toward zero and
+-- SGST takes the odd paisa.
+UPDATE invoice_items ii
+SET cgst_amount = TRUNC(t.tax / 2, 2),
+    sgst_amount = t.tax - TRUNC(t.tax / 2, 2)
+FROM (
+    SELECT id, ROUND((rate * qty - COALESCE(discount, 0)) * COALESCE(tax_rate, 0) / 100, 2) AS tax
+    FROM invoice_items
+) t
+WHERE t.id = ii.id;
+
+UPDATE invoices i
+SET cgst = s.cgst,
+    sgst = s.sgst
+FROM (
+    SELECT invoice_id, SUM(cgst_amount) AS cgst, SUM(sgst_amount) AS sgst
+    FROM invoice_items
+    GROUP BY invoice_id
+) s
+WHERE s.invoice_id = i.id;
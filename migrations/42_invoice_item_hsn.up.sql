@@ -0,0 +1,10 @@
+-- Snapshot the HSN/SAC code on the invoice line so later edits to the item
+-- don't change filed returns.
+ALTER TABLE invoice_items
+ADD COLUMN IF NOT EXISTS hsn_code VARCHAR(8);
+
+UPDATE invoice_items ii
+SET hsn_code = it.hsn_code
+FROM items it
+WHERE it.id = ii.item_id
+  AND ii.hsn_code IS NULL;
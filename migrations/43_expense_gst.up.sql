@@ -0,0 +1,8 @@
+-- GST paid on expenses, used for input tax credit in GSTR-3B.
+ALTER TABLE expensess
+ADD COLUMN IF NOT EXISTS vendor_gstin VARCHAR(15),
+ADD COLUMN IF NOT EXISTS taxable_value NUMERIC(10,2) NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS cgst NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (cgst >= 0),
+ADD COLUMN IF NOT EXISTS sgst NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (sgst >= 0),
+ADD COLUMN IF NOT EXISTS igst NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (igst >= 0),
+ADD COLUMN IF NOT EXISTS itc_eligible BOOLEAN NOT NULL DEFAULT FALSE;
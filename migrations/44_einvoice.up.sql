@@ -0,0 +1,12 @@
+-- IRN registration of an invoice with the Invoice Registration Portal.
+ALTER TABLE invoices
+ADD COLUMN IF NOT EXISTS irn VARCHAR(64),
+ADD COLUMN IF NOT EXISTS irn_ack_no VARCHAR(20),
+ADD COLUMN IF NOT EXISTS irn_ack_date TIMESTAMP,
+ADD COLUMN IF NOT EXISTS irn_signed_qr TEXT,
+ADD COLUMN IF NOT EXISTS irn_status VARCHAR(20)
+    CHECK (irn_status IN ('generated', 'cancelled')),
+ADD COLUMN IF NOT EXISTS irn_cancelled_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS irn_cancel_reason TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_irn_key ON invoices(irn) WHERE irn IS NOT NULL;
@@ -0,0 +1,19 @@
+-- E-way bills generated for goods invoices (one per invoice).
+CREATE TABLE IF NOT EXISTS eway_bills (
+    id SERIAL PRIMARY KEY,
+    invoice_id INT NOT NULL UNIQUE REFERENCES invoices(id) ON DELETE CASCADE,
+    ewb_no VARCHAR(12) NOT NULL UNIQUE,
+    ewb_date TIMESTAMP NOT NULL,
+    valid_until TIMESTAMP,
+    trans_mode VARCHAR(1) NOT NULL DEFAULT '1'
+        CHECK (trans_mode IN ('1', '2', '3', '4')),
+    transporter_id VARCHAR(15),
+    transporter_name VARCHAR(255),
+    vehicle_no VARCHAR(20),
+    vehicle_type VARCHAR(1),
+    distance_km INT NOT NULL CHECK (distance_km > 0),
+    trans_doc_no VARCHAR(20),
+    trans_doc_date DATE,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
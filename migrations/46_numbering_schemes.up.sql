@@ -0,0 +1,62 @@
+-- Per-company document numbering. A company without a scheme row for a
+-- document type gets the built-in default for that type.
+CREATE TABLE IF NOT EXISTS numbering_schemes (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    document_type VARCHAR(20) NOT NULL
+        CHECK (document_type IN ('invoice', 'credit_note', 'quote', 'receipt')),
+    template VARCHAR(100) NOT NULL, -- e.g. INV/{FY}/{SEQ:4}
+    reset_policy VARCHAR(10) NOT NULL DEFAULT 'fy'
+        CHECK (reset_policy IN ('never', 'yearly', 'fy', 'monthly')),
+    start_number INT NOT NULL DEFAULT 1 CHECK (start_number > 0),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (company_id, document_type)
+);
+
+-- Last number issued per company, document type and reset period
+-- ('' for never, '2026', 'FY25-26' or '2026-04').
+CREATE TABLE IF NOT EXISTS numbering_counters (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    document_type VARCHAR(20) NOT NULL,
+    period_key VARCHAR(20) NOT NULL DEFAULT '',
+    last_number INT NOT NULL,
+    UNIQUE (company_id, document_type, period_key)
+);
+
+-- Carry over the FY counters used so far
+INSERT INTO numbering_counters (company_id, document_type, period_key, last_number)
+SELECT company_id, 'invoice', financial_year, next_number
+FROM invoice_counters
+ON CONFLICT DO NOTHING;
+
+INSERT INTO numbering_counters (company_id, document_type, period_key, last_number)
+SELECT company_id, 'quote', financial_year, next_number
+FROM quote_counters
+ON CONFLICT DO NOTHING;
+
+-- Credit notes were numbered CN-YYYY-NNNNN from one global sequence;
+-- continue each company after the highest number it already has.
+INSERT INTO numbering_counters (company_id, document_type, period_key, last_number)
+SELECT company_id, 'credit_note', split_part(credit_number, '-', 2),
+       MAX(split_part(credit_number, '-', 3)::INT)
+FROM credit_notes
+WHERE credit_number ~ '^CN-[0-9]{4}-[0-9]+$'
+GROUP BY company_id, split_part(credit_number, '-', 2)
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS invoice_counters;
+DROP TABLE IF EXISTS quote_counters;
+DROP SEQUENCE IF EXISTS credit_note_seq;
+
+ALTER TABLE payments
+ADD COLUMN IF NOT EXISTS receipt_number VARCHAR(50);
+
+-- Numbers are now per company, so enforce that they stay unique there
+CREATE UNIQUE INDEX IF NOT EXISTS invoices_company_number_key
+    ON invoices(company_id, invoice_number);
+CREATE UNIQUE INDEX IF NOT EXISTS credit_notes_company_number_key
+    ON credit_notes(company_id, credit_number);
+CREATE UNIQUE INDEX IF NOT EXISTS payments_company_receipt_key
+    ON payments(company_id, receipt_number) WHERE receipt_number IS NOT NULL;
@@ -0,0 +1,29 @@
+-- Every change to items.quantity is recorded here; quantity is signed
+-- (+ inward, - outward) and balance_after is the stock once it applied.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    item_id INT NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+    source_type VARCHAR(20) NOT NULL
+        CHECK (source_type IN ('OPENING', 'INVOICE', 'CREDIT_NOTE', 'ADJUSTMENT')),
+    source_id BIGINT,
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    balance_after INT NOT NULL,
+    note TEXT,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_item_idx
+    ON stock_movements(item_id, id);
+CREATE INDEX IF NOT EXISTS stock_movements_source_idx
+    ON stock_movements(source_type, source_id);
+
+UPDATE items SET quantity = 0 WHERE quantity IS NULL;
+ALTER TABLE items ALTER COLUMN quantity SET NOT NULL;
+
+-- Existing stock becomes the opening balance so history adds up
+INSERT INTO stock_movements (company_id, item_id, source_type, quantity, balance_after, note, created_by)
+SELECT company_id, id, 'OPENING', quantity, quantity, 'Opening stock', user_id
+FROM items
+WHERE quantity <> 0;
@@ -0,0 +1,19 @@
+-- In-app notifications. Low-stock alerts are raised when an item's stock
+-- drops below its low_stock_alert threshold; emailed_at is set once the
+-- alert has gone out in a daily digest.
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL CHECK (type IN ('low_stock')),
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    item_id INT REFERENCES items(id) ON DELETE CASCADE,
+    read_at TIMESTAMP,
+    emailed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_company_idx
+    ON notifications(company_id, id DESC);
+CREATE INDEX IF NOT EXISTS notifications_unemailed_idx
+    ON notifications(company_id) WHERE emailed_at IS NULL;
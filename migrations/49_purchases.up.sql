@@ -0,0 +1,131 @@
+-- =========================
+-- Vendors (accounts payable counterpart of clients)
+-- =========================
+CREATE TABLE IF NOT EXISTS vendors (
+    id SERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    phone VARCHAR(20),
+    gstin VARCHAR(15),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS vendors_company_idx ON vendors(company_id);
+
+CREATE TABLE IF NOT EXISTS vendor_addresses (
+    id SERIAL PRIMARY KEY,
+    vendor_id INT NOT NULL REFERENCES vendors(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('billing', 'shipping')),
+    name VARCHAR(255),
+    line1 TEXT NOT NULL,
+    line2 TEXT,
+    city VARCHAR(100),
+    state VARCHAR(100),
+    postal_code VARCHAR(20),
+    country VARCHAR(100),
+    phone VARCHAR(30),
+    email VARCHAR(255),
+    gst_number VARCHAR(50),
+    is_default BOOLEAN DEFAULT false,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (vendor_id, type)
+);
+
+-- =========================
+-- Purchase bills
+-- =========================
+CREATE TABLE IF NOT EXISTS purchase_bills (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    vendor_id INTEGER NOT NULL REFERENCES vendors(id),
+    bill_number VARCHAR(50) NOT NULL, -- the vendor's invoice number
+    bill_date DATE NOT NULL,
+    due_date DATE NOT NULL,
+    supplier_state_code VARCHAR(2),
+    subtotal NUMERIC(12,2) NOT NULL,
+    cgst NUMERIC(12,2) NOT NULL DEFAULT 0,
+    sgst NUMERIC(12,2) NOT NULL DEFAULT 0,
+    igst NUMERIC(12,2) NOT NULL DEFAULT 0,
+    tax NUMERIC(12,2) NOT NULL,
+    total NUMERIC(12,2) NOT NULL,
+    paid_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    remaining_amount NUMERIC(12,2) NOT NULL,
+    itc_eligible BOOLEAN NOT NULL DEFAULT TRUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'unpaid'
+        CHECK (status IN ('unpaid', 'partial', 'paid')),
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (company_id, vendor_id, bill_number)
+);
+
+CREATE INDEX IF NOT EXISTS purchase_bills_company_idx
+    ON purchase_bills(company_id, bill_date);
+
+CREATE TABLE IF NOT EXISTS purchase_bill_items (
+    id BIGSERIAL PRIMARY KEY,
+    bill_id BIGINT NOT NULL REFERENCES purchase_bills(id) ON DELETE CASCADE,
+    item_id INTEGER REFERENCES items(id) ON DELETE SET NULL,
+    description TEXT,
+    hsn_code VARCHAR(8),
+    qty INT NOT NULL CHECK (qty > 0),
+    rate NUMERIC(12,2) NOT NULL CHECK (rate >= 0),
+    discount NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (discount >= 0),
+    tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (tax_rate >= 0),
+    cgst_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    sgst_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    igst_amount NUMERIC(12,2) NOT NULL DEFAULT 0,
+    total NUMERIC(12,2) NOT NULL
+);
+
+-- =========================
+-- Payments made to vendors
+-- =========================
+CREATE TABLE IF NOT EXISTS vendor_payments (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    vendor_id INTEGER NOT NULL REFERENCES vendors(id),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    payment_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    payment_method VARCHAR(50),
+    reference TEXT,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS vendor_payment_allocations (
+    id BIGSERIAL PRIMARY KEY,
+    vendor_payment_id BIGINT NOT NULL REFERENCES vendor_payments(id) ON DELETE CASCADE,
+    bill_id BIGINT NOT NULL REFERENCES purchase_bills(id),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0)
+);
+
+-- =========================
+-- Vendor ledger: credit = bill (we owe more), debit = payment;
+-- balance is what we owe the vendor.
+-- =========================
+CREATE TABLE IF NOT EXISTS vendor_ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id),
+    vendor_id INTEGER NOT NULL REFERENCES vendors(id),
+    source_type TEXT NOT NULL CHECK (source_type IN ('BILL', 'PAYMENT')),
+    source_id BIGINT NOT NULL,
+    debit NUMERIC(12,2) DEFAULT 0,
+    credit NUMERIC(12,2) DEFAULT 0,
+    balance NUMERIC(12,2) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS vendor_ledger_vendor_idx
+    ON vendor_ledger_entries(company_id, vendor_id, id);
+
+-- Purchases bring goods into stock
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_source_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_source_type_check
+    CHECK (source_type IN ('OPENING', 'INVOICE', 'CREDIT_NOTE', 'ADJUSTMENT', 'PURCHASE'));
@@ -0,0 +1,65 @@
+-- =========================
+-- Purchase orders raised on vendors
+-- =========================
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    vendor_id INTEGER NOT NULL REFERENCES vendors(id),
+    po_number VARCHAR(50) NOT NULL,
+    po_date DATE NOT NULL,
+    expected_date DATE,
+    subtotal NUMERIC(12,2) NOT NULL,
+    tax NUMERIC(12,2) NOT NULL,
+    total NUMERIC(12,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (
+        status IN ('draft', 'sent', 'partially_received', 'closed')
+    ),
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (company_id, po_number)
+);
+
+CREATE INDEX IF NOT EXISTS purchase_orders_company_idx
+    ON purchase_orders(company_id, po_date);
+
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+    id BIGSERIAL PRIMARY KEY,
+    po_id BIGINT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    item_id INTEGER REFERENCES items(id) ON DELETE SET NULL,
+    description TEXT,
+    qty INT NOT NULL CHECK (qty > 0),
+    received_qty INT NOT NULL DEFAULT 0 CHECK (received_qty >= 0 AND received_qty <= qty),
+    rate NUMERIC(12,2) NOT NULL CHECK (rate >= 0),
+    discount NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (discount >= 0),
+    tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (tax_rate >= 0),
+    total NUMERIC(12,2) NOT NULL
+);
+
+-- One row per line per delivery
+CREATE TABLE IF NOT EXISTS purchase_order_receipts (
+    id BIGSERIAL PRIMARY KEY,
+    po_id BIGINT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    po_item_id BIGINT NOT NULL REFERENCES purchase_order_items(id) ON DELETE CASCADE,
+    qty INT NOT NULL CHECK (qty > 0),
+    received_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    note TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS purchase_order_receipts_item_idx
+    ON purchase_order_receipts(po_item_id, id);
+
+-- Bills raised against an order; the goods came in with the receipts
+ALTER TABLE purchase_bills
+ADD COLUMN IF NOT EXISTS purchase_order_id BIGINT REFERENCES purchase_orders(id) ON DELETE SET NULL;
+
+ALTER TABLE numbering_schemes DROP CONSTRAINT IF EXISTS numbering_schemes_document_type_check;
+ALTER TABLE numbering_schemes ADD CONSTRAINT numbering_schemes_document_type_check
+    CHECK (document_type IN ('invoice', 'credit_note', 'quote', 'receipt', 'purchase_order'));
+
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_source_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_source_type_check
+    CHECK (source_type IN ('OPENING', 'INVOICE', 'CREDIT_NOTE', 'ADJUSTMENT', 'PURCHASE', 'PO_RECEIPT'));
@@ -0,0 +1,19 @@
+-- Credit note balances applied against a client's open invoices
+CREATE TABLE IF NOT EXISTS credit_note_allocations (
+    id BIGSERIAL PRIMARY KEY,
+    credit_note_id BIGINT NOT NULL REFERENCES credit_notes(id) ON DELETE CASCADE,
+    invoice_id BIGINT NOT NULL REFERENCES invoices(id),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS credit_note_allocations_cn_idx
+    ON credit_note_allocations(credit_note_id);
+
+CREATE INDEX IF NOT EXISTS credit_note_allocations_invoice_idx
+    ON credit_note_allocations(invoice_id);
+
+-- status moves issued -> partially_applied -> applied as the balance is
+-- used up; it can never go below zero
+ALTER TABLE credit_notes
+ADD CONSTRAINT credit_notes_balance_check CHECK (balance >= 0);
@@ -0,0 +1,32 @@
+-- Money paid back to a client out of a credit note balance or the
+-- unapplied part of a payment
+CREATE TABLE IF NOT EXISTS refunds (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    client_id BIGINT NOT NULL REFERENCES clients(id),
+    refund_number VARCHAR(50) NOT NULL,
+    credit_note_id BIGINT REFERENCES credit_notes(id),
+    payment_id BIGINT REFERENCES payments(id),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    refund_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    refund_method VARCHAR(50) NOT NULL,
+    reference TEXT,
+    notes TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (company_id, refund_number),
+    CONSTRAINT refunds_source_check CHECK (
+        (credit_note_id IS NOT NULL) <> (payment_id IS NOT NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS refunds_credit_note_idx ON refunds(credit_note_id);
+CREATE INDEX IF NOT EXISTS refunds_payment_idx ON refunds(payment_id);
+
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_source_type_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_source_type_check
+    CHECK (source_type IN ('INVOICE', 'PAYMENT', 'CREDIT_NOTE', 'ADJUSTMENT', 'REFUND'));
+
+ALTER TABLE numbering_schemes DROP CONSTRAINT IF EXISTS numbering_schemes_document_type_check;
+ALTER TABLE numbering_schemes ADD CONSTRAINT numbering_schemes_document_type_check
+    CHECK (document_type IN ('invoice', 'credit_note', 'quote', 'receipt', 'purchase_order', 'refund'));
@@ -0,0 +1,39 @@
+-- Upward revisions of issued invoices (under-billed rate, quantity or tax)
+CREATE TABLE IF NOT EXISTS debit_notes (
+    id BIGSERIAL PRIMARY KEY,
+    company_id BIGINT NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    client_id BIGINT NOT NULL REFERENCES clients(id),
+    invoice_id BIGINT NOT NULL REFERENCES invoices(id),
+    debit_number VARCHAR(50) NOT NULL,
+    debit_date DATE NOT NULL,
+    reason TEXT,
+    subtotal NUMERIC(12,2) NOT NULL DEFAULT 0,
+    tax NUMERIC(12,2) NOT NULL DEFAULT 0,
+    total NUMERIC(12,2) NOT NULL CHECK (total > 0),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (company_id, debit_number)
+);
+
+CREATE INDEX IF NOT EXISTS debit_notes_invoice_idx ON debit_notes(invoice_id);
+
+-- item_id is NULL for a value-only revision of the invoice
+CREATE TABLE IF NOT EXISTS debit_note_items (
+    id BIGSERIAL PRIMARY KEY,
+    debit_note_id BIGINT NOT NULL REFERENCES debit_notes(id) ON DELETE CASCADE,
+    item_id BIGINT REFERENCES items(id),
+    description TEXT,
+    hsn_code VARCHAR(8),
+    qty NUMERIC(10,2) NOT NULL CHECK (qty > 0),
+    rate NUMERIC(12,2) NOT NULL CHECK (rate >= 0),
+    tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (tax_rate >= 0),
+    total NUMERIC(12,2) NOT NULL
+);
+
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_source_type_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_source_type_check
+    CHECK (source_type IN ('INVOICE', 'PAYMENT', 'CREDIT_NOTE', 'ADJUSTMENT', 'REFUND', 'DEBIT_NOTE'));
+
+ALTER TABLE numbering_schemes DROP CONSTRAINT IF EXISTS numbering_schemes_document_type_check;
+ALTER TABLE numbering_schemes ADD CONSTRAINT numbering_schemes_document_type_check
+    CHECK (document_type IN ('invoice', 'credit_note', 'quote', 'receipt', 'purchase_order', 'refund', 'debit_note'));
@@ -0,0 +1,12 @@
+-- A voided payment stays on file, with its ledger credit reversed and its
+-- allocations taken off the invoices
+ALTER TABLE payments
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'recorded',
+ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS void_reason TEXT;
+
+ALTER TABLE payments
+ADD CONSTRAINT payments_status_check CHECK (status IN ('recorded', 'voided'));
+
+CREATE INDEX IF NOT EXISTS idx_payments_company_created
+    ON payments(company_id, created_at DESC);
@@ -0,0 +1,8 @@
+-- How a payment without explicit allocations is spread over open invoices;
+-- invoice_date keeps the original oldest-invoice-first behaviour
+ALTER TABLE companies
+ADD COLUMN IF NOT EXISTS payment_allocation_strategy VARCHAR(20) NOT NULL DEFAULT 'invoice_date';
+
+ALTER TABLE companies
+ADD CONSTRAINT companies_payment_allocation_strategy_check
+    CHECK (payment_allocation_strategy IN ('due_date', 'invoice_date', 'smallest_balance', 'proportional'));